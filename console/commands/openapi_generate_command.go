@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// OpenAPIGenerateCommand writes the OpenAPI document of the app's routes to
+// a file, e.g. to commit it or feed it to a client generator
+type OpenAPIGenerateCommand struct {
+	commands.BaseCommand
+	App *fiber.App
+}
+
+func (c *OpenAPIGenerateCommand) GetSignature() string {
+	return "openapi:generate"
+}
+
+func (c *OpenAPIGenerateCommand) GetDescription() string {
+	return "Write the OpenAPI document of the registered routes to a file"
+}
+
+func (c *OpenAPIGenerateCommand) Execute(args []string) error {
+	output := configutil.String("openapi.output", "./docs/openapi.json")
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--output="):
+			output = strings.TrimPrefix(arg, "--output=")
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console openapi:generate",
+				"go run main.go console openapi:generate --output=./api/openapi.json",
+			})
+			return nil
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+
+	doc := openapi.Generate(c.App, openapi.LoadInfo())
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(output, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	c.PrintSuccess(fmt.Sprintf("OpenAPI document written to %s (%d paths)", output, len(doc.Paths)))
+	return nil
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/routes"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RouteListCommand prints the routes registered by the router, with their
+// handler and middleware chain, without starting the server
+type RouteListCommand struct {
+	commands.BaseCommand
+	App *fiber.App
+	// Output defaults to os.Stdout
+	Output io.Writer
+}
+
+func (c *RouteListCommand) GetSignature() string {
+	return "route:list"
+}
+
+func (c *RouteListCommand) GetDescription() string {
+	return "List the registered routes with their handler and middleware"
+}
+
+func (c *RouteListCommand) Execute(args []string) error {
+	var (
+		prefix, middleware, method string
+		asJSON                     bool
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--prefix="):
+			prefix = strings.TrimPrefix(arg, "--prefix=")
+		case strings.HasPrefix(arg, "--middleware="):
+			middleware = strings.TrimPrefix(arg, "--middleware=")
+		case strings.HasPrefix(arg, "--method="):
+			method = strings.ToUpper(strings.TrimPrefix(arg, "--method="))
+		case arg == "--json":
+			asJSON = true
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console route:list",
+				"go run main.go console route:list --prefix=/api/v1",
+				"go run main.go console route:list --middleware=JWT",
+				"go run main.go console route:list --method=POST --json",
+			})
+			return nil
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+
+	var list []routes.Route
+	for _, route := range routes.List(c.App) {
+		if prefix != "" && !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		if middleware != "" && !route.HasMiddleware(middleware) {
+			continue
+		}
+		if method != "" && route.Method != method {
+			continue
+		}
+		list = append(list, route)
+	}
+	slices.SortStableFunc(list, func(a, b routes.Route) int {
+		if n := strings.Compare(a.Path, b.Path); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+
+	out := c.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if asJSON {
+		if list == nil {
+			list = []routes.Route{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(list)
+	}
+
+	if len(list) == 0 {
+		c.PrintInfo("No routes match the given filters")
+		return nil
+	}
+	return writeRouteTable(out, list)
+}
+
+// writeRouteTable leaves out the app-wide middleware, which is the same for
+// every route, and lists it once below the table
+func writeRouteTable(out io.Writer, list []routes.Route) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tNAME\tHANDLER\tMIDDLEWARE")
+
+	var global []string
+	for _, route := range list {
+		var chain []string
+		for _, m := range route.Middleware {
+			if m.Prefix == "/" {
+				if !slices.Contains(global, m.Name) {
+					global = append(global, m.Name)
+				}
+				continue
+			}
+			chain = append(chain, m.Name)
+		}
+
+		path := route.Path
+		if route.Deprecated {
+			path += " (deprecated)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", route.Method, path, route.Name, route.Handler, strings.Join(chain, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(global) > 0 {
+		fmt.Fprintf(out, "\nApp-wide middleware: %s\n", strings.Join(global, ", "))
+	}
+	fmt.Fprintf(out, "\n%d routes\n", len(list))
+	return nil
+}
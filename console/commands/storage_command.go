@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+// Helpers shared by the storage:* commands, which work on any disk of
+// config/filesystems.yaml selected with --disk=<name>
+
+// listingDisk is a disk that can list its files
+type listingDisk interface {
+	storage.Disk
+	storage.Lister
+}
+
+func openLister(name string) (listingDisk, error) {
+	disk, err := storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := disk.(listingDisk)
+	if !ok {
+		return nil, fmt.Errorf("disk %q cannot list its files", diskName(name))
+	}
+	return lister, nil
+}
+
+func diskName(name string) string {
+	if name == "" {
+		return storage.DefaultDisk()
+	}
+	return name
+}
+
+func outputOrStdout(out io.Writer) io.Writer {
+	if out == nil {
+		return os.Stdout
+	}
+	return out
+}
+
+// formatSize formats bytes with binary units, e.g. 1.5 MiB
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
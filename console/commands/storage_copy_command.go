@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+// StorageCopyCommand copies the files of a disk to another one, e.g. to
+// move the uploads from the local disk to S3. Files already on the target
+// are skipped unless --overwrite is given. The files table is left as is:
+// point filesystems.default and the disk column of files at the new disk
+// once the copy is done.
+type StorageCopyCommand struct {
+	commands.BaseCommand
+	// Output receives the progress; defaults to os.Stdout
+	Output io.Writer
+}
+
+func (c *StorageCopyCommand) GetSignature() string {
+	return "storage:copy"
+}
+
+func (c *StorageCopyCommand) GetDescription() string {
+	return "Copy the files of a disk, or of a directory of it, to another disk"
+}
+
+func (c *StorageCopyCommand) Execute(args []string) error {
+	var (
+		from, to, prefix  string
+		dryRun, overwrite bool
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--from="):
+			from = strings.TrimPrefix(arg, "--from=")
+		case strings.HasPrefix(arg, "--to="):
+			to = strings.TrimPrefix(arg, "--to=")
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--overwrite":
+			overwrite = true
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console storage:copy --from=local --to=s3 --dry-run",
+				"go run main.go console storage:copy --from=local --to=s3",
+				"go run main.go console storage:copy files/2026 --from=local --to=s3 --overwrite",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		case prefix == "":
+			prefix = arg
+		default:
+			return fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+	if from == "" || to == "" {
+		return fmt.Errorf("both --from and --to disks are required")
+	}
+	if from == to {
+		return fmt.Errorf("--from and --to are the same disk")
+	}
+
+	source, err := openLister(from)
+	if err != nil {
+		return err
+	}
+	target, err := storage.Open(to)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	entries, err := source.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	out := outputOrStdout(c.Output)
+	var copied, skipped, failed int
+	var bytes int64
+	for i, entry := range entries {
+		progress := fmt.Sprintf("[%d/%d] %s (%s)", i+1, len(entries), entry.Path, formatSize(entry.Size))
+
+		if !overwrite {
+			exists, err := target.Exists(ctx, entry.Path)
+			if err != nil {
+				return err
+			}
+			if exists {
+				fmt.Fprintf(out, "%s skipped, already on %s\n", progress, to)
+				skipped++
+				continue
+			}
+		}
+		if dryRun {
+			fmt.Fprintf(out, "%s would be copied\n", progress)
+			copied++
+			bytes += entry.Size
+			continue
+		}
+
+		if err := copyFile(ctx, source, target, entry.Path); err != nil {
+			fmt.Fprintf(out, "%s failed: %v\n", progress, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s copied\n", progress)
+		copied++
+		bytes += entry.Size
+	}
+
+	summary := fmt.Sprintf("%d files (%s) copied from %s to %s, %d skipped", copied, formatSize(bytes), from, to, skipped)
+	if dryRun {
+		summary = fmt.Sprintf("Dry run: %d files (%s) would be copied from %s to %s, %d skipped", copied, formatSize(bytes), from, to, skipped)
+	}
+	if failed > 0 {
+		c.PrintWarning(summary)
+		return fmt.Errorf("%d files could not be copied", failed)
+	}
+	c.PrintSuccess(summary)
+	return nil
+}
+
+// copyFile spools the file to a temporary file, since disks need a
+// seekable body, and copies it with the type detected from its content
+func copyFile(ctx context.Context, source, target storage.Disk, path string) error {
+	body, err := source.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "storage-copy-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	detected, err := mimetype.DetectReader(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return target.Put(ctx, path, tmp, detected.String())
+}
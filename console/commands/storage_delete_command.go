@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+// StorageDeleteCommand deletes files of a disk. It does not touch the
+// files table, so deleting an uploaded file breaks its downloads.
+type StorageDeleteCommand struct {
+	commands.BaseCommand
+}
+
+func (c *StorageDeleteCommand) GetSignature() string {
+	return "storage:delete"
+}
+
+func (c *StorageDeleteCommand) GetDescription() string {
+	return "Delete files of a disk"
+}
+
+func (c *StorageDeleteCommand) Execute(args []string) error {
+	var (
+		disk  string
+		paths []string
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--disk="):
+			disk = strings.TrimPrefix(arg, "--disk=")
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console storage:delete reports/2026/report.pdf",
+				"go run main.go console storage:delete tmp/a.txt tmp/b.txt --disk=s3",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("expected at least one path to delete")
+	}
+
+	target, err := storage.Open(disk)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, path := range paths {
+		exists, err := target.Exists(ctx, path)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			c.PrintWarning(fmt.Sprintf("No file %s on disk %s", path, diskName(disk)))
+			continue
+		}
+		if err := target.Delete(ctx, path); err != nil {
+			return err
+		}
+		c.PrintSuccess(fmt.Sprintf("Deleted %s from disk %s", path, diskName(disk)))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+// StorageGetCommand reads a file of a disk into a local file, or to the
+// standard output
+type StorageGetCommand struct {
+	commands.BaseCommand
+	// Output receives the file when no local file is given; defaults to
+	// os.Stdout
+	Output io.Writer
+}
+
+func (c *StorageGetCommand) GetSignature() string {
+	return "storage:get"
+}
+
+func (c *StorageGetCommand) GetDescription() string {
+	return "Read a file of a disk into a local file, or to the standard output"
+}
+
+func (c *StorageGetCommand) Execute(args []string) error {
+	var (
+		disk       string
+		force      bool
+		positional []string
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--disk="):
+			disk = strings.TrimPrefix(arg, "--disk=")
+		case arg == "--force":
+			force = true
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console storage:get reports/2026/report.pdf ./report.pdf",
+				"go run main.go console storage:get reports/2026/report.pdf ./report.pdf --force",
+				"go run main.go console storage:get notes.txt --disk=s3 | less",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		return fmt.Errorf("expected a path on the disk and an optional local file, got %d arguments", len(positional))
+	}
+	path := positional[0]
+
+	source, err := storage.Open(disk)
+	if err != nil {
+		return err
+	}
+	body, err := source.Get(context.Background(), path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("no file %s on disk %s", path, diskName(disk))
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if len(positional) == 1 || positional[1] == "-" {
+		_, err := io.Copy(outputOrStdout(c.Output), body)
+		return err
+	}
+
+	target := positional[1]
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(target, flags, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use --force to replace it", target)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	written, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+
+	c.PrintSuccess(fmt.Sprintf("Wrote %s from disk %s to %s (%s)", path, diskName(disk), target, formatSize(written)))
+	return nil
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/galaplate/core/console/commands"
+)
+
+// StorageListCommand lists the files of a disk, optionally under a
+// directory
+type StorageListCommand struct {
+	commands.BaseCommand
+	// Output defaults to os.Stdout
+	Output io.Writer
+}
+
+func (c *StorageListCommand) GetSignature() string {
+	return "storage:list"
+}
+
+func (c *StorageListCommand) GetDescription() string {
+	return "List the files of a disk with their size and modification time"
+}
+
+func (c *StorageListCommand) Execute(args []string) error {
+	var (
+		disk, prefix string
+		asJSON       bool
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--disk="):
+			disk = strings.TrimPrefix(arg, "--disk=")
+		case arg == "--json":
+			asJSON = true
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console storage:list",
+				"go run main.go console storage:list files/2026 --disk=s3",
+				"go run main.go console storage:list avatars --json",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		case prefix == "":
+			prefix = arg
+		default:
+			return fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+
+	lister, err := openLister(disk)
+	if err != nil {
+		return err
+	}
+	entries, err := lister.List(context.Background(), prefix)
+	if err != nil {
+		return err
+	}
+
+	out := outputOrStdout(c.Output)
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		c.PrintInfo(fmt.Sprintf("No files on disk %s", diskName(disk)))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tSIZE\tMODIFIED")
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Path, formatSize(entry.Size), entry.LastModified.Local().Format(time.DateTime))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n%d files, %s\n", len(entries), formatSize(total))
+	return nil
+}
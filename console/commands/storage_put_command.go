@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+// StoragePutCommand writes a local file to a disk
+type StoragePutCommand struct {
+	commands.BaseCommand
+}
+
+func (c *StoragePutCommand) GetSignature() string {
+	return "storage:put"
+}
+
+func (c *StoragePutCommand) GetDescription() string {
+	return "Write a local file to a path of a disk, replacing any file there"
+}
+
+func (c *StoragePutCommand) Execute(args []string) error {
+	var (
+		disk, contentType string
+		positional        []string
+	)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--disk="):
+			disk = strings.TrimPrefix(arg, "--disk=")
+		case strings.HasPrefix(arg, "--type="):
+			contentType = strings.TrimPrefix(arg, "--type=")
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console storage:put ./report.pdf reports/2026/report.pdf",
+				"go run main.go console storage:put ./logo.svg public/logo.svg --disk=s3 --type=image/svg+xml",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("expected a local file and a path on the disk, got %d arguments", len(positional))
+	}
+	source, path := positional[0], positional[1]
+
+	target, err := storage.Open(disk)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", source, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", source, err)
+	}
+
+	// Detected from the content, like uploads
+	if contentType == "" {
+		detected, err := mimetype.DetectReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		contentType = detected.String()
+		if _, err := file.Seek(0, 0); err != nil {
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+	}
+
+	if err := target.Put(context.Background(), path, file, contentType); err != nil {
+		return err
+	}
+	c.PrintSuccess(fmt.Sprintf("Wrote %s (%s, %s) to %s on disk %s", source, formatSize(info.Size()), contentType, path, diskName(disk)))
+	return nil
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+// StorageUsageCommand sums the files and bytes stored on every configured
+// disk, or on the directories of a single disk
+type StorageUsageCommand struct {
+	commands.BaseCommand
+	// Output defaults to os.Stdout
+	Output io.Writer
+}
+
+func (c *StorageUsageCommand) GetSignature() string {
+	return "storage:usage"
+}
+
+func (c *StorageUsageCommand) GetDescription() string {
+	return "Show the number of files and bytes stored per disk, or per directory of a disk"
+}
+
+type usage struct {
+	name  string
+	files int
+	bytes int64
+	err   error
+}
+
+func (c *StorageUsageCommand) Execute(args []string) error {
+	var disk string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--disk="):
+			disk = strings.TrimPrefix(arg, "--disk=")
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console storage:usage",
+				"go run main.go console storage:usage --disk=s3",
+			})
+			return nil
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+
+	if disk != "" {
+		return c.directories(disk)
+	}
+
+	var names []string
+	for name := range configutil.Map("filesystems.disks") {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	rows := make([]usage, 0, len(names))
+	for _, name := range names {
+		row := usage{name: name}
+		lister, err := openLister(name)
+		if err == nil {
+			var entries []storage.Entry
+			entries, err = lister.List(context.Background(), "")
+			for _, entry := range entries {
+				row.files++
+				row.bytes += entry.Size
+			}
+		}
+		row.err = err
+		rows = append(rows, row)
+	}
+	return c.write("DISK", rows)
+}
+
+// directories breaks the usage of disk down by top level directory
+func (c *StorageUsageCommand) directories(disk string) error {
+	lister, err := openLister(disk)
+	if err != nil {
+		return err
+	}
+	entries, err := lister.List(context.Background(), "")
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		c.PrintInfo(fmt.Sprintf("No files on disk %s", disk))
+		return nil
+	}
+
+	// Files at the root of the disk are counted under "."
+	byDir := map[string]*usage{}
+	for _, entry := range entries {
+		dir, _, found := strings.Cut(entry.Path, "/")
+		if !found {
+			dir = "."
+		}
+		if byDir[dir] == nil {
+			byDir[dir] = &usage{name: dir}
+		}
+		byDir[dir].files++
+		byDir[dir].bytes += entry.Size
+	}
+
+	rows := make([]usage, 0, len(byDir))
+	for _, row := range byDir {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b usage) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return c.write("DIRECTORY", rows)
+}
+
+func (c *StorageUsageCommand) write(header string, rows []usage) error {
+	out := outputOrStdout(c.Output)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tFILES\tSIZE\n", header)
+
+	var files int
+	var bytes int64
+	for _, row := range rows {
+		if row.err != nil {
+			fmt.Fprintf(w, "%s\t-\t%v\n", row.name, row.err)
+			continue
+		}
+		files += row.files
+		bytes += row.bytes
+		fmt.Fprintf(w, "%s\t%d\t%s\n", row.name, row.files, formatSize(row.bytes))
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%s\n", files, formatSize(bytes))
+	return w.Flush()
+}
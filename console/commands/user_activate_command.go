@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/models"
+)
+
+// UserActivateCommand activates inactive users. It is an upgrade step:
+// registrations used to create inactive users, while status is now
+// enforced at login. Users deactivated through the admin API are left
+// alone unless named; users deactivated by hand in SQL cannot be told
+// apart, so review the --dry-run output first.
+type UserActivateCommand struct {
+	commands.BaseCommand
+	// Output receives the affected users; defaults to os.Stdout
+	Output io.Writer
+}
+
+func (c *UserActivateCommand) GetSignature() string {
+	return "user:activate"
+}
+
+func (c *UserActivateCommand) GetDescription() string {
+	return "Activate the given inactive users, or every one an administrator did not deactivate"
+}
+
+func (c *UserActivateCommand) Execute(args []string) error {
+	var (
+		emails []string
+		dryRun bool
+	)
+	for _, arg := range args {
+		switch {
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console user:activate --dry-run",
+				"go run main.go console user:activate",
+				"go run main.go console user:activate alice@example.com bob@example.com",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		default:
+			emails = append(emails, arg)
+		}
+	}
+
+	query := database.Connect.Model(&models.User{}).Where("status = ?", false)
+	if len(emails) > 0 {
+		query = query.Where("email IN ?", emails)
+	} else {
+		// The users whose status an administrator set are named in the
+		// audit log
+		var auditable []string
+		err := database.Connect.Model(&models.AuditLog{}).
+			Where("auditable_type = ? AND action = ?", "users", "admin.user.status_updated").
+			Distinct().Pluck("auditable_id", &auditable).Error
+		if err != nil {
+			return fmt.Errorf("failed to read the audit log: %w", err)
+		}
+		var set []uint64
+		for _, id := range auditable {
+			if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+				set = append(set, n)
+			}
+		}
+		if len(set) > 0 {
+			query = query.Where("id NOT IN ?", set)
+		}
+	}
+
+	var users []models.User
+	if err := query.Order("id").Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to find inactive users: %w", err)
+	}
+	if len(users) == 0 {
+		c.PrintInfo("No inactive user to activate")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(outputOrStdout(c.Output), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tREGISTERED")
+	for _, user := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", user.ID, user.Email, user.CreatedAt.Format("2006-01-02"))
+	}
+	w.Flush()
+
+	if dryRun {
+		c.PrintInfo(fmt.Sprintf("Dry run: %d users would be activated", len(users)))
+		return nil
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	ctx := audit.WithAction(context.Background(), "console.user.activated")
+	if err := database.Connect.WithContext(ctx).Model(&models.User{}).Where("id IN ?", ids).Update("status", true).Error; err != nil {
+		return fmt.Errorf("failed to activate users: %w", err)
+	}
+
+	c.PrintSuccess(fmt.Sprintf("%d users activated", len(users)))
+	return nil
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/galaplate/core/console/commands"
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/models"
+)
+
+// UserRoleCommand changes the role of a user, e.g. to create the first
+// administrator of the admin API
+type UserRoleCommand struct {
+	commands.BaseCommand
+}
+
+func (c *UserRoleCommand) GetSignature() string {
+	return "user:role"
+}
+
+func (c *UserRoleCommand) GetDescription() string {
+	return "Set the role (user or admin) of the user with the given email"
+}
+
+func (c *UserRoleCommand) Execute(args []string) error {
+	var positional []string
+	for _, arg := range args {
+		switch {
+		case arg == "--help" || arg == "-h":
+			c.ShowUsage(c.GetSignature(), c.GetDescription(), []string{
+				"go run main.go console user:role admin@example.com admin",
+				"go run main.go console user:role former-admin@example.com user",
+			})
+			return nil
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown argument %q", arg)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("expected an email and a role, got %d arguments", len(positional))
+	}
+
+	email, role := positional[0], positional[1]
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return fmt.Errorf("unknown role %q, expected %s or %s", role, models.RoleUser, models.RoleAdmin)
+	}
+
+	result := database.Connect.Model(&models.User{}).Where("email = ?", email).Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no user with email %s", email)
+	}
+
+	c.PrintSuccess(fmt.Sprintf("%s is now %s", email, role))
+	return nil
+}
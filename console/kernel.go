@@ -2,11 +2,22 @@ package console
 
 import (
 	"github.com/galaplate/core/console"
-	// "github.com/galaplate/galaplate/console/commands"
+	"github.com/galaplate/galaplate/console/commands"
+	"github.com/gofiber/fiber/v2"
 )
 
-func RegisterCommands(kernel *console.Kernel) {
+func RegisterCommands(kernel *console.Kernel, app *fiber.App) {
 	// Register your custom console commands here
 	// Example:
 	// kernel.Register(&commands.SendwelcomeemailcommandCommand{})
+	kernel.Register(&commands.OpenAPIGenerateCommand{App: app})
+	kernel.Register(&commands.RouteListCommand{App: app})
+	kernel.Register(&commands.UserRoleCommand{})
+	kernel.Register(&commands.UserActivateCommand{})
+	kernel.Register(&commands.StorageListCommand{})
+	kernel.Register(&commands.StoragePutCommand{})
+	kernel.Register(&commands.StorageGetCommand{})
+	kernel.Register(&commands.StorageDeleteCommand{})
+	kernel.Register(&commands.StorageCopyCommand{})
+	kernel.Register(&commands.StorageUsageCommand{})
 }
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792310400 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792310400{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_rate_limits_table",
+			Timestamp: 1792310400,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792310400) Up(schema *database.Schema) error {
+	err := schema.Create("rate_limits", func(table *database.Blueprint) {
+		table.ID()
+		table.String("bucket").NotNullable()
+		table.BigInteger("window_start").NotNullable()
+		table.BigInteger("hits").NotNullable().Default(0)
+		table.DateTime("expires_at")
+	})
+	if err != nil {
+		return err
+	}
+
+	// Indexes are added separately: inline index definitions are not
+	// valid SQLite
+	if err := schema.Table("rate_limits", func(table *database.Blueprint) {
+		table.UniqueIndex([]string{"bucket", "window_start"}, "rate_limits_bucket_window_unique")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("rate_limits", func(table *database.Blueprint) {
+		table.Index([]string{"expires_at"}, "rate_limits_expires_at_index")
+	})
+}
+
+func (m *Migration1792310400) Down(schema *database.Schema) error {
+	return schema.DropIfExists("rate_limits")
+}
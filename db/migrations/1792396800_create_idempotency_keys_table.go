@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792396800 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792396800{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_idempotency_keys_table",
+			Timestamp: 1792396800,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792396800) Up(schema *database.Schema) error {
+	err := schema.Create("idempotency_keys", func(table *database.Blueprint) {
+		table.ID()
+		table.String("scope").NotNullable()
+		table.String("idempotency_key").NotNullable()
+		table.String("fingerprint", 64).NotNullable()
+		table.String("state", 20).NotNullable()
+		table.Integer("response_status").Default(0)
+		table.Text("response_headers").Nullable()
+		table.LongBlob("response_body").Nullable()
+		table.DateTime("locked_until").Nullable()
+		table.DateTime("expires_at")
+		table.Timestamps()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Indexes are added separately: inline index definitions are not
+	// valid SQLite
+	if err := schema.Table("idempotency_keys", func(table *database.Blueprint) {
+		table.UniqueIndex([]string{"scope", "idempotency_key"}, "idempotency_keys_scope_key_unique")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("idempotency_keys", func(table *database.Blueprint) {
+		table.Index([]string{"expires_at"}, "idempotency_keys_expires_at_index")
+	})
+}
+
+func (m *Migration1792396800) Down(schema *database.Schema) error {
+	return schema.DropIfExists("idempotency_keys")
+}
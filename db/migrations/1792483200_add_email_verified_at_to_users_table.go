@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792483200 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792483200{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_email_verified_at_to_users_table",
+			Timestamp: 1792483200,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792483200) Up(schema *database.Schema) error {
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.DateTime("email_verified_at").Nullable()
+	})
+}
+
+func (m *Migration1792483200) Down(schema *database.Schema) error {
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.DropColumn("email_verified_at")
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792486800 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792486800{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_audit_logs_table",
+			Timestamp: 1792486800,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792486800) Up(schema *database.Schema) error {
+	err := schema.Create("audit_logs", func(table *database.Blueprint) {
+		table.ID()
+		table.BigInteger("actor_id").Nullable()
+		table.String("action", 100).NotNullable()
+		table.String("auditable_type", 100).NotNullable()
+		table.String("auditable_id", 64).NotNullable()
+		table.Text("changes").Nullable()
+		table.String("request_id", 64).Nullable()
+		table.String("ip_address", 45).Nullable()
+		table.String("user_agent").Nullable()
+		table.DateTime("created_at")
+	})
+	if err != nil {
+		return err
+	}
+
+	// Indexes are added separately: inline index definitions are not
+	// valid SQLite
+	if err := schema.Table("audit_logs", func(table *database.Blueprint) {
+		table.Index([]string{"auditable_type", "auditable_id"}, "audit_logs_auditable_index")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("audit_logs", func(table *database.Blueprint) {
+		table.Index([]string{"actor_id"}, "audit_logs_actor_id_index")
+	})
+}
+
+func (m *Migration1792486800) Down(schema *database.Schema) error {
+	return schema.DropIfExists("audit_logs")
+}
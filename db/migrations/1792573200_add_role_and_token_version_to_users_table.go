@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792573200 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792573200{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_role_and_token_version_to_users_table",
+			Timestamp: 1792573200,
+		},
+	}
+	database.Register(migration)
+}
+
+// Columns are added one statement at a time: SQLite cannot add several
+// columns in a single ALTER TABLE
+func (m *Migration1792573200) Up(schema *database.Schema) error {
+	if err := schema.Table("users", func(table *database.Blueprint) {
+		table.String("role", 20).Default("user").NotNullable()
+	}); err != nil {
+		return err
+	}
+	if err := schema.Table("users", func(table *database.Blueprint) {
+		table.Integer("token_version").Default(0).NotNullable()
+	}); err != nil {
+		return err
+	}
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.Boolean("password_reset_required").Default(false).NotNullable()
+	})
+}
+
+func (m *Migration1792573200) Down(schema *database.Schema) error {
+	for _, column := range []string{"password_reset_required", "token_version", "role"} {
+		if err := schema.Table("users", func(table *database.Blueprint) {
+			table.DropColumn(column)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
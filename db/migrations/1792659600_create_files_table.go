@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792659600 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792659600{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_files_table",
+			Timestamp: 1792659600,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792659600) Up(schema *database.Schema) error {
+	err := schema.Create("files", func(table *database.Blueprint) {
+		table.ID()
+		table.BigInteger("user_id").Nullable()
+		table.String("collection", 50).NotNullable()
+		table.String("disk", 50).NotNullable()
+		table.String("path").NotNullable()
+		table.String("name").NotNullable()
+		table.String("mime_type", 100).NotNullable()
+		table.BigInteger("size").NotNullable()
+		table.String("checksum", 64).NotNullable()
+		table.Timestamps()
+	})
+	if err != nil {
+		return err
+	}
+
+	return schema.Table("files", func(table *database.Blueprint) {
+		table.Index([]string{"user_id"}, "files_user_id_index")
+	})
+}
+
+func (m *Migration1792659600) Down(schema *database.Schema) error {
+	return schema.DropIfExists("files")
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792663200 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792663200{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_avatar_id_to_users_table",
+			Timestamp: 1792663200,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792663200) Up(schema *database.Schema) error {
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.BigInteger("avatar_id").Nullable()
+	})
+}
+
+func (m *Migration1792663200) Down(schema *database.Schema) error {
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.DropColumn("avatar_id")
+	})
+}
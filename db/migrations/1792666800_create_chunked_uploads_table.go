@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792666800 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792666800{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_chunked_uploads_table",
+			Timestamp: 1792666800,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792666800) Up(schema *database.Schema) error {
+	err := schema.Create("chunked_uploads", func(table *database.Blueprint) {
+		table.ID()
+		table.BigInteger("user_id").NotNullable()
+		table.String("collection", 50).NotNullable()
+		table.String("name").NotNullable()
+		table.BigInteger("size").NotNullable()
+		table.BigInteger("received").Default(0)
+		table.DateTime("expires_at")
+		table.Timestamps()
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := schema.Table("chunked_uploads", func(table *database.Blueprint) {
+		table.Index([]string{"user_id"}, "chunked_uploads_user_id_index")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("chunked_uploads", func(table *database.Blueprint) {
+		table.Index([]string{"expires_at"}, "chunked_uploads_expires_at_index")
+	})
+}
+
+func (m *Migration1792666800) Down(schema *database.Schema) error {
+	return schema.DropIfExists("chunked_uploads")
+}
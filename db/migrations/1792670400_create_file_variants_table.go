@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792670400 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792670400{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_file_variants_table",
+			Timestamp: 1792670400,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792670400) Up(schema *database.Schema) error {
+	err := schema.Create("file_variants", func(table *database.Blueprint) {
+		table.ID()
+		table.BigInteger("file_id").NotNullable()
+		table.String("name", 50).NotNullable()
+		table.String("path").NotNullable()
+		table.String("mime_type", 100).NotNullable()
+		table.Integer("width").NotNullable()
+		table.Integer("height").NotNullable()
+		table.BigInteger("size").NotNullable()
+		table.Timestamps()
+	})
+	if err != nil {
+		return err
+	}
+
+	return schema.Table("file_variants", func(table *database.Blueprint) {
+		table.UniqueIndex([]string{"file_id", "name"}, "file_variants_file_id_name_unique")
+	})
+}
+
+func (m *Migration1792670400) Down(schema *database.Schema) error {
+	return schema.DropIfExists("file_variants")
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792674000 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792674000{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_deletion_scheduled_at_to_users_table",
+			Timestamp: 1792674000,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792674000) Up(schema *database.Schema) error {
+	if err := schema.Table("users", func(table *database.Blueprint) {
+		table.DateTime("deletion_scheduled_at").Nullable()
+	}); err != nil {
+		return err
+	}
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.Index([]string{"deletion_scheduled_at"}, "users_deletion_scheduled_at_index")
+	})
+}
+
+func (m *Migration1792674000) Down(schema *database.Schema) error {
+	if err := schema.Table("users", func(table *database.Blueprint) {
+		table.DropIndex("users_deletion_scheduled_at_index")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("users", func(table *database.Blueprint) {
+		table.DropColumn("deletion_scheduled_at")
+	})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792677600 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792677600{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_data_exports_table",
+			Timestamp: 1792677600,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792677600) Up(schema *database.Schema) error {
+	err := schema.Create("data_exports", func(table *database.Blueprint) {
+		table.ID()
+		table.BigInteger("user_id").NotNullable()
+		table.String("status", 20).NotNullable()
+		table.BigInteger("file_id").Nullable()
+		table.DateTime("expires_at").Nullable()
+		table.Timestamps()
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := schema.Table("data_exports", func(table *database.Blueprint) {
+		table.Index([]string{"user_id"}, "data_exports_user_id_index")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("data_exports", func(table *database.Blueprint) {
+		table.Index([]string{"expires_at"}, "data_exports_expires_at_index")
+	})
+}
+
+func (m *Migration1792677600) Down(schema *database.Schema) error {
+	return schema.DropIfExists("data_exports")
+}
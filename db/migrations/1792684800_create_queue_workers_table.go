@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"github.com/galaplate/core/database"
+)
+
+type Migration1792684800 struct {
+	database.BaseMigration
+}
+
+func init() {
+	migration := &Migration1792684800{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_queue_workers_table",
+			Timestamp: 1792684800,
+		},
+	}
+	database.Register(migration)
+}
+
+func (m *Migration1792684800) Up(schema *database.Schema) error {
+	err := schema.Create("queue_workers", func(table *database.Blueprint) {
+		table.ID()
+		table.String("worker").NotNullable()
+		table.DateTime("last_seen_at")
+		table.DateTime("created_at")
+	})
+	if err != nil {
+		return err
+	}
+
+	// Indexes are added separately: inline index definitions are not
+	// valid SQLite
+	if err := schema.Table("queue_workers", func(table *database.Blueprint) {
+		table.UniqueIndex([]string{"worker"}, "queue_workers_worker_unique")
+	}); err != nil {
+		return err
+	}
+	return schema.Table("queue_workers", func(table *database.Blueprint) {
+		table.Index([]string{"last_seen_at"}, "queue_workers_last_seen_at_index")
+	})
+}
+
+func (m *Migration1792684800) Down(schema *database.Schema) error {
+	return schema.DropIfExists("queue_workers")
+}
@@ -1,20 +1,53 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/galaplate/core/bootstrap"
 	"github.com/galaplate/core/config"
 	"github.com/galaplate/core/console"
+	"github.com/galaplate/core/database"
 	"github.com/galaplate/core/logger"
+	"github.com/galaplate/core/queue"
+	"github.com/galaplate/core/scheduler"
 	pkgConsole "github.com/galaplate/galaplate/console"
 	_ "github.com/galaplate/galaplate/db/migrations"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/lifecycle"
+	"github.com/galaplate/galaplate/pkg/middleware"
+	_ "github.com/galaplate/galaplate/pkg/scheduler"
+	"github.com/galaplate/galaplate/pkg/telemetry"
+	"github.com/galaplate/galaplate/pkg/uploads"
 	"github.com/galaplate/galaplate/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	queueSize   int
+	workerCount int
 )
 
 func withSetupRoutes(ac *bootstrap.AppConfig) {
-	ac.SetupRoutes = router.SetupRouter
+	ac.SetupRoutes = func(app *fiber.App) {
+		if err := router.SetupDatabase(); err != nil {
+			logger.Fatal(fmt.Sprintf("Could not set up the database: %s", err.Error()))
+		}
+		router.SetupRouter(app)
+	}
+	apperror.Configure(ac.FiberConfig)
+	ac.FiberConfig.BodyLimit = uploads.BodyLimit()
+
+	// The queue and scheduler are started below so that their shutdown is
+	// ordered with the HTTP server and the other lifecycle hooks
+	queueSize, workerCount = ac.QueueSize, ac.WorkerCount
+	ac.StartBackgroundJobs = false
 }
 
 func main() {
@@ -23,7 +56,7 @@ func main() {
 	if len(os.Args) > 1 && os.Args[1] == "console" {
 		// bootstrap.Init(cfg)
 		kernel := console.NewKernel()
-		pkgConsole.RegisterCommands(kernel)
+		pkgConsole.RegisterCommands(kernel, app)
 
 		if err := kernel.Run(os.Args); err != nil {
 			logger.Fatal(fmt.Sprintf("Console command failed: %s", err.Error()))
@@ -36,9 +69,78 @@ func main() {
 		port = "8080"
 	}
 
+	registerLifecycleHooks()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	logger.Info(fmt.Sprintf("Server will started in port %s", port))
 
-	if err := app.Listen(":" + port); err != nil {
+	timeout := configutil.Duration("app.shutdown.timeout", 30*time.Second)
+	if err := lifecycle.Serve(ctx, app, ":"+port, timeout); err != nil {
 		logger.Fatal(fmt.Sprintf("Server won't run: %s", err.Error()))
 	}
 }
+
+// registerLifecycleHooks starts the background workers and registers the
+// shutdown hooks. Shutdown hooks run in reverse order: workers are drained
+// first and the database pool is closed last.
+func registerLifecycleHooks() {
+	lifecycle.OnShutdown("database", func(ctx context.Context) error {
+		sqlDB, err := database.Connect.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	lifecycle.OnShutdown("access_log", func(ctx context.Context) error {
+		return middleware.AccessLogMiddlewareInstance.Close()
+	})
+
+	lifecycle.OnShutdown("telemetry", telemetry.Shutdown)
+
+	// Records the jobs claimed by the workers below, to requeue them alone
+	// if the workers are interrupted
+	claims := jobs.NewClaims()
+	if err := database.Connect.Use(claims); err != nil {
+		logger.Fatal(fmt.Sprintf("Could not track job claims: %s", err.Error()))
+	}
+	// Records when the workers below last polled, for the queue readiness
+	// check
+	heartbeat := jobs.NewHeartbeat(configutil.Duration("health.queue.heartbeat_interval", 10*time.Second))
+	if err := database.Connect.Use(heartbeat); err != nil {
+		logger.Fatal(fmt.Sprintf("Could not record the queue heartbeat: %s", err.Error()))
+	}
+	q := queue.New(queueSize)
+	sch := scheduler.New()
+
+	lifecycle.OnStart("background_jobs", func(ctx context.Context) error {
+		q.Start(workerCount)
+		if err := sch.RunTasks(); err != nil {
+			return err
+		}
+		sch.Start()
+		return nil
+	})
+
+	lifecycle.OnShutdown("scheduler", sch.Shutdown)
+
+	lifecycle.OnShutdown("queue", func(ctx context.Context) error {
+		jobsTimeout := configutil.Duration("app.shutdown.jobs_timeout", 25*time.Second)
+		ctx, cancel := context.WithTimeout(ctx, jobsTimeout)
+		defer cancel()
+
+		if err := q.Shutdown(ctx); err != nil {
+			requeued, requeueErr := claims.Requeue()
+			logger.Warn("main@queueShutdown", map[string]any{
+				"message":  "workers did not finish in time, running jobs were requeued",
+				"requeued": requeued,
+			})
+			if requeueErr != nil {
+				return requeueErr
+			}
+		}
+		return heartbeat.Stop(ctx)
+	})
+}
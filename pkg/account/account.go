@@ -0,0 +1,99 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/exports"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/uploads"
+	"gorm.io/gorm"
+)
+
+// GracePeriod is how long a deleted account can be restored by an
+// administrator before it is purged
+func GracePeriod() time.Duration {
+	return configutil.Duration("account.deletion.grace_period", 30*24*time.Hour)
+}
+
+// Purge purges the deleted accounts past their grace period and returns
+// how many were
+func Purge(ctx context.Context) (int64, error) {
+	var users []models.User
+	err := database.Connect.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deletion_scheduled_at < ?", time.Now()).
+		Find(&users).Error
+	if err != nil {
+		return 0, fmt.Errorf("find deleted accounts: %w", err)
+	}
+
+	var purged int64
+	for _, user := range users {
+		if err := purge(ctx, &user); err != nil {
+			return purged, fmt.Errorf("purge user %d: %w", user.ID, err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge deletes the files, uploads and exports of user, then the user, and
+// anonymizes the audit logs of the account. Files are deleted first: when
+// one cannot be, the user is left for the next run.
+func purge(ctx context.Context, user *models.User) error {
+	db := database.Connect.WithContext(ctx)
+
+	var exportList []models.DataExport
+	if err := db.Where("user_id = ?", user.ID).Find(&exportList).Error; err != nil {
+		return fmt.Errorf("find exports: %w", err)
+	}
+	for _, export := range exportList {
+		if err := exports.Delete(ctx, &export); err != nil {
+			return err
+		}
+	}
+
+	var files []models.File
+	if err := db.Where("user_id = ?", user.ID).Find(&files).Error; err != nil {
+		return fmt.Errorf("find files: %w", err)
+	}
+	for _, file := range files {
+		if err := uploads.Delete(ctx, &file); err != nil {
+			return err
+		}
+	}
+
+	var pending []models.ChunkedUpload
+	if err := db.Where("user_id = ?", user.ID).Find(&pending).Error; err != nil {
+		return fmt.Errorf("find uploads: %w", err)
+	}
+	for _, upload := range pending {
+		if err := uploads.Abort(ctx, &upload); err != nil {
+			return err
+		}
+	}
+
+	id := fmt.Sprint(user.ID)
+	return database.Connect.WithContext(audit.WithAction(ctx, "account.purged")).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(user).Error; err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		// The changes recorded for the account hold its personal data,
+		// including the ones of the purge itself
+		if err := tx.Model(&models.AuditLog{}).
+			Where("auditable_type = ? AND auditable_id = ?", "users", id).
+			Update("changes", "{}").Error; err != nil {
+			return fmt.Errorf("anonymize audit logs: %w", err)
+		}
+		if err := tx.Model(&models.AuditLog{}).
+			Where("(auditable_type = ? AND auditable_id = ?) OR actor_id = ?", "users", id, user.ID).
+			Updates(map[string]any{"ip_address": "", "user_agent": ""}).Error; err != nil {
+			return fmt.Errorf("anonymize audit logs: %w", err)
+		}
+		return nil
+	})
+}
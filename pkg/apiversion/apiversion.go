@@ -0,0 +1,222 @@
+package apiversion
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// AcceptVersionHeader selects the version of unversioned /api/... paths
+	AcceptVersionHeader = "Accept-Version"
+	// VersionHeader tells the client which version served the request
+	VersionHeader = "API-Version"
+
+	DeprecationHeader = "Deprecation"
+	SunsetHeader      = "Sunset"
+)
+
+// Deprecation describes a deprecated version or route. It is sent in the
+// Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers.
+type Deprecation struct {
+	// At is when the deprecation took effect; zero sends "Deprecation: true"
+	At time.Time
+	// Sunset is when the version or route stops being served
+	Sunset time.Time
+	// Link points to a page describing the deprecation or the replacement
+	Link string
+}
+
+// Version is an API version served under <prefix>/<name>, e.g. /api/v1
+type Version struct {
+	Name       string
+	Deprecated bool
+	Deprecation
+}
+
+var (
+	mu       sync.RWMutex
+	versions = map[string]Version{}
+	routes   = map[string]Deprecation{}
+)
+
+// LoadVersions registers the versions listed in config/api.yaml
+func LoadVersions() {
+	for name := range configutil.Map("api.versions") {
+		key := "api.versions." + name
+		version := Version{
+			Name: name,
+			Deprecation: Deprecation{
+				At:     configTime(key + ".deprecated_at"),
+				Sunset: configTime(key + ".sunset"),
+				Link:   configutil.String(key+".link", ""),
+			},
+		}
+		version.Deprecated = configutil.Bool(key+".deprecated", !version.At.IsZero())
+		Register(version)
+	}
+}
+
+// Register adds v to the known versions, replacing a version of the same name
+func Register(v Version) {
+	mu.Lock()
+	defer mu.Unlock()
+	versions[v.Name] = v
+}
+
+// Deprecate marks the route registered under name as deprecated. It takes
+// precedence over the deprecation of the version the route belongs to.
+func Deprecate(name string, d Deprecation) {
+	mu.Lock()
+	defer mu.Unlock()
+	routes[name] = d
+}
+
+// Versions returns the known versions, oldest first
+func Versions() []Version {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Version, 0, len(versions))
+	for _, v := range versions {
+		list = append(list, v)
+	}
+	slices.SortFunc(list, func(a, b Version) int { return compareNames(a.Name, b.Name) })
+	return list
+}
+
+// Lookup returns the version registered under name
+func Lookup(name string) (Version, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	v, ok := versions[name]
+	return v, ok
+}
+
+// Prefix is the path all versioned groups live under
+func Prefix() string {
+	return strings.TrimSuffix(configutil.String("api.prefix", "/api"), "/")
+}
+
+// Default is the version serving unversioned paths without Accept-Version,
+// the latest registered version when api.default_version is not set
+func Default() string {
+	if name := configutil.String("api.default_version", ""); name != "" {
+		return name
+	}
+	if list := Versions(); len(list) > 0 {
+		return list[len(list)-1].Name
+	}
+	return ""
+}
+
+// Group returns the group of router serving version name, registering the
+// version when it is not known yet:
+//
+//	api := app.Group("/api")
+//	v1 := apiversion.Group(api, "v1")
+//	v1.Post("/login", authController.Login).Name("auth.login")
+func Group(router fiber.Router, name string) fiber.Router {
+	if _, ok := Lookup(name); !ok {
+		Register(Version{Name: name})
+	}
+	return router.Group("/" + name)
+}
+
+// Route is a route of the app along with its version metadata
+type Route struct {
+	Name   string
+	Method string
+	Path   string
+	// Version is empty for routes outside the versioned groups
+	Version    string
+	Deprecated bool
+	Deprecation
+}
+
+// Routes lists the routes of app grouped by method, HEAD routes added by
+// fiber for every GET excluded
+func Routes(app *fiber.App) []Route {
+	var list []Route
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		route := Route{Name: r.Name, Method: r.Method, Path: r.Path}
+		if v, ok := VersionOf(r.Path); ok {
+			route.Version = v.Name
+		}
+		route.Deprecation, route.Deprecated = deprecationOf(route.Name, route.Version)
+		list = append(list, route)
+	}
+	return list
+}
+
+// Deprecated reports whether the route registered under name at path is
+// deprecated, either itself or through its version
+func Deprecated(name, path string) bool {
+	version := ""
+	if v, ok := VersionOf(path); ok {
+		version = v.Name
+	}
+	_, deprecated := deprecationOf(name, version)
+	return deprecated
+}
+
+// VersionOf returns the version a path such as /api/v1/login belongs to
+func VersionOf(path string) (Version, bool) {
+	rest, ok := strings.CutPrefix(path, Prefix()+"/")
+	if !ok {
+		return Version{}, false
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	return Lookup(segment)
+}
+
+func deprecationOf(name, version string) (Deprecation, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if d, ok := routes[name]; ok && name != "" {
+		return d, true
+	}
+	if v, ok := versions[version]; ok && v.Deprecated {
+		return v.Deprecation, true
+	}
+	return Deprecation{}, false
+}
+
+// compareNames orders v2 before v10 by comparing the numeric part of the
+// names, falling back to a string comparison
+func compareNames(a, b string) int {
+	na, errA := strconv.Atoi(strings.TrimLeft(a, "vV"))
+	nb, errB := strconv.Atoi(strings.TrimLeft(b, "vV"))
+	if errA == nil && errB == nil && na != nb {
+		return na - nb
+	}
+	return strings.Compare(a, b)
+}
+
+func configTime(key string) time.Time {
+	value := configutil.String(key, "")
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	logger.Warn("apiversion@LoadVersions", map[string]any{
+		"message": "ignoring invalid date, expected RFC 3339 or YYYY-MM-DD",
+		"key":     key,
+		"value":   value,
+	})
+	return time.Time{}
+}
@@ -0,0 +1,80 @@
+package apiversion
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeUnsupportedVersion is the error code sent when Accept-Version names an
+// unknown version
+const CodeUnsupportedVersion = "unsupported_version"
+
+const versionLocal = "api_version"
+
+// Middleware routes unversioned paths under the prefix to a version and
+// announces deprecations:
+//
+//   - /api/v1/login is served by v1
+//   - /api/login is served by the version in Accept-Version, or the default
+//     version, as if /api/<version>/login had been requested
+//   - responses of deprecated routes and versions carry the Deprecation,
+//     Sunset and Link headers
+//
+// It must be registered before the versioned groups.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		prefix := Prefix()
+		rest, ok := strings.CutPrefix(c.Path(), prefix)
+		if !ok || (rest != "" && rest[0] != '/') {
+			return c.Next()
+		}
+
+		version, versioned := VersionOf(c.Path())
+		if !versioned {
+			name := strings.TrimSpace(c.Get(AcceptVersionHeader))
+			if name == "" {
+				name = Default()
+			}
+			if version, ok = Lookup(name); !ok {
+				return apperror.New(fiber.StatusBadRequest, CodeUnsupportedVersion, "Unsupported API version "+strconv.Quote(name))
+			}
+			c.Path(prefix + "/" + version.Name + rest)
+		}
+
+		c.Locals(versionLocal, version.Name)
+		c.Set(VersionHeader, version.Name)
+
+		err := c.Next()
+
+		// The matched route is only known once the handlers ran
+		if d, deprecated := deprecationOf(c.Route().Name, version.Name); deprecated {
+			setDeprecationHeaders(c, d)
+		}
+		return err
+	}
+}
+
+// Current returns the version serving the request, or an empty string
+// outside the versioned groups
+func Current(c *fiber.Ctx) string {
+	name, _ := c.Locals(versionLocal).(string)
+	return name
+}
+
+func setDeprecationHeaders(c *fiber.Ctx, d Deprecation) {
+	if d.At.IsZero() {
+		c.Set(DeprecationHeader, "true")
+	} else {
+		c.Set(DeprecationHeader, "@"+strconv.FormatInt(d.At.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		c.Set(SunsetHeader, d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		c.Append(fiber.HeaderLink, "<"+d.Link+`>; rel="deprecation"`)
+	}
+}
@@ -0,0 +1,149 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Machine readable error codes sent in the "code" field of every error
+// response. Clients should branch on these rather than on the message.
+const (
+	CodeBadRequest      = "bad_request"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeValidation      = "validation_failed"
+	CodeTooManyRequests = "too_many_requests"
+	CodeInternal        = "internal_error"
+	CodeUnavailable     = "service_unavailable"
+)
+
+// Error is an error that knows how it should be presented to the client.
+// Message and Fields are always safe to send; Err is the internal cause and
+// is only exposed when app.debug is enabled.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of the error with err attached as its internal cause
+func (e *Error) Wrap(err error) *Error {
+	clone := *e
+	clone.Err = err
+	return &clone
+}
+
+// New creates an error with the given status, code and client facing message
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(fiber.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(fiber.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(fiber.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(fiber.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(fiber.StatusConflict, CodeConflict, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(fiber.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
+// Validation creates a 422 error listing the message of every invalid field
+func Validation(message string, fields map[string]string) *Error {
+	e := New(fiber.StatusUnprocessableEntity, CodeValidation, message)
+	e.Fields = fields
+	return e
+}
+
+// Internal wraps an unexpected error. The client only sees a generic message.
+func Internal(err error) *Error {
+	return New(fiber.StatusInternalServerError, CodeInternal, "Internal Server Error").Wrap(err)
+}
+
+// From converts any error returned by a handler into an *Error. Fiber errors
+// keep their status and message, validation errors produced by
+// supports.NewValidator become 422 errors and anything else is internal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErr supports.GlobalErrorHandlerResp
+	if json.Unmarshal([]byte(err.Error()), &validationErr) == nil && validationErr.Status != 0 {
+		e := New(validationErr.Status, codeForStatus(validationErr.Status), validationErr.Message)
+		e.Fields = validationErr.Errors
+		return e
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return New(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message)
+	}
+
+	// Returned by the validator when the request body cannot be decoded
+	if strings.HasPrefix(err.Error(), "body parsing error") {
+		return BadRequest("Malformed request body").Wrap(err)
+	}
+
+	return Internal(err)
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusUnprocessableEntity:
+		return CodeValidation
+	case fiber.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case fiber.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
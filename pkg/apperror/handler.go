@@ -0,0 +1,94 @@
+package apperror
+
+import (
+	"net/http"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+const MIMEProblemJSON = "application/problem+json"
+
+// Envelope is the default error body, matching the shape of the success
+// responses ({"success": true, "data": ...}) returned by the controllers
+type Envelope struct {
+	Success   bool              `json:"success"`
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Debug     string            `json:"debug,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details body, sent when the client asks
+// for application/problem+json
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail"`
+	Instance  string            `json:"instance"`
+	Code      string            `json:"code"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Debug     string            `json:"debug,omitempty"`
+}
+
+// Handler is the application's fiber ErrorHandler. It converts err with From,
+// logs it with the request ID and renders it as an Envelope, or as a Problem
+// when the Accept header prefers application/problem+json. The internal
+// cause is only included in the body when app.debug is enabled.
+func Handler(c *fiber.Ctx, err error) error {
+	appErr := From(err)
+
+	log := logging.FromCtx(c)
+	info := map[string]any{
+		"status": appErr.Status,
+		"code":   appErr.Code,
+		"method": c.Method(),
+		"path":   c.Path(),
+		"error":  err.Error(),
+	}
+	if appErr.Status >= fiber.StatusInternalServerError {
+		log.Error("apperror@Handler", info)
+	} else {
+		log.Debug("apperror@Handler", info)
+	}
+
+	var debug string
+	if appErr.Err != nil && configutil.Bool("app.debug", false) {
+		debug = appErr.Err.Error()
+	}
+
+	requestID, _ := c.Locals("request_id").(string)
+
+	if c.Accepts(fiber.MIMEApplicationJSON, MIMEProblemJSON) == MIMEProblemJSON {
+		c.Status(appErr.Status)
+		return c.JSON(Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(appErr.Status),
+			Status:    appErr.Status,
+			Detail:    appErr.Message,
+			Instance:  c.OriginalURL(),
+			Code:      appErr.Code,
+			Errors:    appErr.Fields,
+			RequestID: requestID,
+			Debug:     debug,
+		}, MIMEProblemJSON)
+	}
+
+	return c.Status(appErr.Status).JSON(Envelope{
+		Success:   false,
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Errors:    appErr.Fields,
+		RequestID: requestID,
+		Debug:     debug,
+	})
+}
+
+// Configure installs Handler as the ErrorHandler of cfg
+func Configure(cfg *fiber.Config) {
+	cfg.ErrorHandler = Handler
+}
@@ -0,0 +1,26 @@
+package audit
+
+import "reflect"
+
+// Change is the old and new value of a changed field
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Changes maps the changed fields to their change
+type Changes map[string]Change
+
+// Set records the change of field, unless old and new are equal
+func (c Changes) Set(field string, old, new any) {
+	if !reflect.DeepEqual(old, new) {
+		c[field] = Change{Old: old, New: new}
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
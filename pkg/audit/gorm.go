@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/requestctx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const snapshotKey = "audit:snapshot"
+
+// GormPlugin records the changes of the models opted in with Track into
+// the audit_logs table, in the transaction making them. The rows touched
+// by an update or a delete are loaded before and after it, so changes must
+// be made through GORM, and bulk updates cost two extra queries. The actor
+// and the request are taken from the statement context, so changes must be
+// made with db.WithContext(c.UserContext()) to be attributed.
+type GormPlugin struct{}
+
+func (p *GormPlugin) Name() string {
+	return "audit"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("audit:after_create", afterCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", snapshot); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:after_update", afterChange("updated")); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", snapshot); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:after_delete", afterChange("deleted"))
+}
+
+// trackedSchema returns the schema of the statement's model if it is
+// tracked
+func trackedSchema(db *gorm.DB) (*schema.Schema, Options, bool) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return nil, Options{}, false
+	}
+	opts, ok := optionsOf(db.Statement.Schema.ModelType)
+	return db.Statement.Schema, opts, ok
+}
+
+func afterCreate(db *gorm.DB) {
+	s, opts, ok := trackedSchema(db)
+	if !ok {
+		return
+	}
+
+	var logs []models.AuditLog
+	eachStruct(db.Statement.ReflectValue, func(row reflect.Value) {
+		logs = append(logs, newLog(db, s, "created", row, diff(db, s, opts, reflect.Value{}, row)))
+	})
+	record(db, logs)
+}
+
+// snapshot loads the rows an update or a delete is about to change
+func snapshot(db *gorm.DB) {
+	s, _, ok := trackedSchema(db)
+	if !ok {
+		return
+	}
+	conditions := conditionsOf(db, s)
+	if len(conditions) == 0 {
+		// GORM refuses updates and deletes without conditions
+		return
+	}
+
+	rows, err := load(db, s, conditions)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(snapshotKey, rows)
+}
+
+// afterChange records the difference between the snapshot and the rows as
+// they are now. Rows gone for good are recorded with every column cleared.
+func afterChange(event string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		s, opts, ok := trackedSchema(db)
+		if !ok {
+			return
+		}
+		value, ok := db.InstanceGet(snapshotKey)
+		if !ok || db.Statement.RowsAffected == 0 {
+			return
+		}
+		before := value.(reflect.Value)
+		if before.Len() == 0 {
+			return
+		}
+
+		pk := s.PrioritizedPrimaryField
+		ids := make([]any, 0, before.Len())
+		for i := 0; i < before.Len(); i++ {
+			id, _ := pk.ValueOf(db.Statement.Context, before.Index(i))
+			ids = append(ids, id)
+		}
+		after, err := load(db, s, []clause.Expression{clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: ids}})
+		if err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+			return
+		}
+		current := map[any]reflect.Value{}
+		for i := 0; i < after.Len(); i++ {
+			id, _ := pk.ValueOf(db.Statement.Context, after.Index(i))
+			current[id] = after.Index(i)
+		}
+
+		var logs []models.AuditLog
+		for i, id := range ids {
+			old := before.Index(i)
+			changes := diff(db, s, opts, old, current[id])
+			if len(changes) > 0 {
+				logs = append(logs, newLog(db, s, event, old, changes))
+			}
+		}
+		record(db, logs)
+	}
+}
+
+// conditionsOf returns the WHERE conditions of the statement, with the
+// primary keys of its model value, which GORM only adds in its own
+// callbacks
+func conditionsOf(db *gorm.DB, s *schema.Schema) []clause.Expression {
+	var conditions []clause.Expression
+	if where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+		conditions = append(conditions, where.Exprs...)
+	}
+
+	pk := s.PrioritizedPrimaryField
+	var ids []any
+	eachStruct(db.Statement.ReflectValue, func(row reflect.Value) {
+		if id, zero := pk.ValueOf(db.Statement.Context, row); !zero {
+			ids = append(ids, id)
+		}
+	})
+	if len(ids) > 0 {
+		conditions = append(conditions, clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: ids})
+	}
+	return conditions
+}
+
+// load finds the rows matching conditions, deleted or not, in the
+// transaction of db
+func load(db *gorm.DB, s *schema.Schema, conditions []clause.Expression) (reflect.Value, error) {
+	rows := reflect.New(reflect.SliceOf(s.ModelType))
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Unscoped().
+		Table(s.Table).
+		Clauses(clause.Where{Exprs: conditions}).
+		Find(rows.Interface()).Error
+	return rows.Elem(), err
+}
+
+// eachStruct calls fn with value, or each element of value when it is a
+// slice or an array
+func eachStruct(value reflect.Value, fn func(reflect.Value)) {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		fn(value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if row := reflect.Indirect(value.Index(i)); row.Kind() == reflect.Struct {
+				fn(row)
+			}
+		}
+	}
+}
+
+// diff compares the columns of two rows; an invalid old or new value
+// stands for a row that does not exist
+func diff(db *gorm.DB, s *schema.Schema, opts Options, old, new reflect.Value) Changes {
+	changes := Changes{}
+	for _, field := range s.Fields {
+		// The primary key is the auditable_id of the log
+		if field.DBName == "" || field.PrimaryKey || field.AutoCreateTime > 0 || field.AutoUpdateTime > 0 || slices.Contains(opts.Ignore, field.DBName) {
+			continue
+		}
+
+		var oldValue, newValue any
+		if old.IsValid() {
+			oldValue = columnValue(db, field, old)
+		}
+		if new.IsValid() {
+			newValue = columnValue(db, field, new)
+		}
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if opts.redacts(field.DBName) {
+			if oldValue != nil {
+				oldValue = Redacted
+			}
+			if newValue != nil {
+				newValue = Redacted
+			}
+		}
+		changes[field.DBName] = Change{Old: oldValue, New: newValue}
+	}
+	return changes
+}
+
+// columnValue returns the value of field as stored, e.g. nil for a NULL
+// pointer or a valid time for a gorm.DeletedAt
+func columnValue(db *gorm.DB, field *schema.Field, row reflect.Value) any {
+	value := field.ReflectValueOf(db.Statement.Context, row)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		if stored, err := valuer.Value(); err == nil {
+			return stored
+		}
+	}
+	return value.Interface()
+}
+
+func newLog(db *gorm.DB, s *schema.Schema, event string, row reflect.Value, changes Changes) models.AuditLog {
+	ctx := db.Statement.Context
+	id, _ := s.PrioritizedPrimaryField.ValueOf(ctx, row)
+	client := requestctx.ClientOf(ctx)
+	log := models.AuditLog{
+		Action:        actionOf(ctx, s.Table, event),
+		AuditableType: s.Table,
+		AuditableID:   fmt.Sprint(id),
+		RequestID:     requestctx.RequestID(ctx),
+		IPAddress:     client.IP,
+		UserAgent:     truncate(client.UserAgent, 255),
+	}
+	if actorID, ok := requestctx.ActorID(ctx); ok {
+		log.ActorID = &actorID
+	}
+	// Changes only hold plain values, which always encode
+	encoded, _ := json.Marshal(changes)
+	log.Changes = string(encoded)
+	return log
+}
+
+// record writes logs in the transaction of db, failing the change when
+// they cannot be written
+func record(db *gorm.DB, logs []models.AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&logs).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: create audit log: %w", err))
+	}
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Redacted replaces the values of redacted fields in recorded changes
+const Redacted = "[redacted]"
+
+// DefaultRedacted are the columns redacted for every tracked model
+var DefaultRedacted = []string{"password", "secret", "token", "api_key"}
+
+// Options configures how the changes of a tracked model are recorded
+type Options struct {
+	// Redact lists the columns whose values are replaced by Redacted, on
+	// top of DefaultRedacted. A change is still recorded, without values.
+	Redact []string
+	// Ignore lists the columns left out of the changes. Timestamps managed
+	// by GORM, such as updated_at, are always left out.
+	Ignore []string
+}
+
+func (o Options) redacts(column string) bool {
+	return slices.Contains(DefaultRedacted, column) || slices.Contains(o.Redact, column)
+}
+
+var (
+	mu      sync.RWMutex
+	tracked = map[reflect.Type]Options{}
+)
+
+// Track opts model into the audit log: every create, update and delete of
+// it made through GORM is recorded by the GormPlugin, with the changed
+// columns. model is a struct or a pointer to one, e.g. &models.User{}.
+func Track(model any, opts Options) {
+	mu.Lock()
+	defer mu.Unlock()
+	tracked[structType(model)] = opts
+}
+
+func optionsOf(modelType reflect.Type) (Options, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	opts, ok := tracked[modelType]
+	return opts, ok
+}
+
+func structType(model any) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+type actionKey struct{}
+
+// WithAction names the action recorded for the changes made with ctx, e.g.
+// "profile.updated". Without it, changes are recorded as
+// "<table>.created", "<table>.updated" or "<table>.deleted".
+func WithAction(ctx context.Context, action string) context.Context {
+	return context.WithValue(ctx, actionKey{}, action)
+}
+
+func actionOf(ctx context.Context, table, event string) string {
+	if ctx != nil {
+		if action, ok := ctx.Value(actionKey{}).(string); ok && action != "" {
+			return action
+		}
+	}
+	return table + "." + event
+}
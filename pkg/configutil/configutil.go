@@ -0,0 +1,130 @@
+package configutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galaplate/core/config"
+)
+
+// String returns the config value at key, or def when it is missing or empty
+func String(key string, def string) string {
+	if value := config.ConfigString(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// Int returns the config value at key as an int, or def when it is missing
+// or not a number
+func Int(key string, def int) int {
+	value := config.Config(key)
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+// Float returns the config value at key as a float64, or def when it is
+// missing or not a number
+func Float(key string, def float64) float64 {
+	value := config.Config(key)
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// Bool returns the config value at key as a bool, or def when it is missing.
+// Strings such as "true", "1" or "yes" are accepted too since values coming
+// from ${ENV:default} placeholders may not be typed by the YAML parser.
+func Bool(key string, def bool) bool {
+	value := config.Config(key)
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "yes", "on":
+			return true
+		case "false", "0", "no", "off":
+			return false
+		}
+	}
+	return def
+}
+
+// Duration returns the config value at key as a time.Duration. Numbers are
+// read as seconds, strings are parsed with time.ParseDuration.
+func Duration(key string, def time.Duration) time.Duration {
+	value := config.Config(key)
+	switch v := value.(type) {
+	case int:
+		return time.Duration(v) * time.Second
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	case string:
+		v = strings.TrimSpace(v)
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return def
+}
+
+// Strings returns the config value at key as a string slice. Both YAML lists
+// and comma separated strings are accepted.
+func Strings(key string, def []string) []string {
+	value := config.Config(key)
+	switch v := value.(type) {
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprintf("%v", item)); s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return def
+		}
+		var result []string
+		for _, item := range strings.Split(v, ",") {
+			if s := strings.TrimSpace(item); s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return def
+}
+
+// Map returns the config value at key as a map, or nil when it is missing
+func Map(key string) map[string]any {
+	if m, ok := config.Config(key).(map[string]any); ok {
+		return m
+	}
+	return nil
+}
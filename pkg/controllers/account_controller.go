@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/supports"
+	"github.com/galaplate/galaplate/pkg/account"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/downloads"
+	"github.com/galaplate/galaplate/pkg/dto"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// DataExportResponse is a data export with a temporary download URL of its
+// archive once it is completed
+type DataExportResponse struct {
+	*models.DataExport
+	URL          string     `json:"url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// AccountController lets users take their data with them and delete their
+// account:
+//
+//	POST   /account/export      queues the archive of the user's data
+//	GET    /account/exports/:id tells whether it is built, with its download URL
+//	DELETE /account             deletes the account after checking the password
+type AccountController struct{}
+
+func NewAccountController() *AccountController {
+	return &AccountController{}
+}
+
+// Export queues a data export, or returns the one still being built
+func (ac *AccountController) Export(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	db := database.Connect.WithContext(c.UserContext())
+
+	var export models.DataExport
+	err := db.Where("user_id = ? AND status = ?", userID, models.DataExportPending).First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = queueExport(c, db, &export, userID)
+	}
+	if err != nil {
+		return apperror.Internal(err)
+	}
+
+	location, err := c.GetRouteURL("account.exports.show", fiber.Map{"id": export.ID})
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("route url: %w", err))
+	}
+	c.Location(location)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Data export queued",
+		"data":    DataExportResponse{DataExport: &export},
+	})
+}
+
+// queueExport creates a pending export and dispatches the job building
+// it; the export is deleted again when the job cannot be queued
+func queueExport(c *fiber.Ctx, db *gorm.DB, export *models.DataExport, userID uint) error {
+	*export = models.DataExport{UserID: userID, Status: models.DataExportPending}
+	if err := db.Create(export).Error; err != nil {
+		return fmt.Errorf("create export: %w", err)
+	}
+	if err := jobs.Dispatch(c.UserContext(), jobs.ExportUserData{}, export.ID); err != nil {
+		return errors.Join(err, db.Delete(export).Error)
+	}
+	return nil
+}
+
+// ShowExport returns an export of the user, with a temporary download URL
+// of its archive once it is completed
+func (ac *AccountController) ShowExport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return apperror.NotFound("Export not found")
+	}
+	user := c.Locals("user").(*models.User)
+
+	db := database.Connect.WithContext(c.UserContext())
+	var export models.DataExport
+	if err := db.Where("user_id = ?", user.ID).First(&export, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFound("Export not found")
+		}
+		return apperror.Internal(fmt.Errorf("find export: %w", err))
+	}
+
+	resp := DataExportResponse{DataExport: &export}
+	if export.Status == models.DataExportCompleted && export.FileID != nil {
+		var file models.File
+		if err := db.First(&file, *export.FileID).Error; err != nil {
+			return apperror.Internal(fmt.Errorf("find archive: %w", err))
+		}
+		url, expiresAt, err := downloads.TemporaryURL(c, &file, nil, user, downloads.DispositionAttachment)
+		if err != nil {
+			return err
+		}
+		resp.URL = url
+		resp.URLExpiresAt = &expiresAt
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// Destroy soft deletes the account of the user after checking their
+// password and revokes their tokens. The account is purged once the grace
+// period of account.deletion has passed; until then an administrator can
+// restore it.
+func (ac *AccountController) Destroy(c *fiber.Ctx) error {
+	req, err := new(dto.AccountDeleteRequest).Validate(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*models.User)
+	if !new(supports.Bcrypt).DoPasswordsMatch(user.Password, req.Password) {
+		return apperror.Validation("The password is incorrect", map[string]string{
+			"password": "The password is incorrect",
+		})
+	}
+
+	now := time.Now()
+	updates := map[string]any{
+		"deleted_at":            now,
+		"deletion_scheduled_at": now.Add(account.GracePeriod()),
+		"token_version":         gorm.Expr("token_version + 1"),
+	}
+	if err := updateUser(c, user, "account.deleted", updates); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Account deleted",
+		"data":    user,
+	})
+}
+
+var AccountControllerInstance = NewAccountController()
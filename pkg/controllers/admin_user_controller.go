@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/dto"
+	"github.com/galaplate/galaplate/pkg/httpcache"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/query"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// What clients may sort and filter the user listing by
+var adminUserQuery = query.Options{
+	Sortable: []string{"id", "username", "email", "status", "role", "created_at", "updated_at"},
+	Filterable: map[string][]string{
+		"status":            {query.OpEq},
+		"role":              {query.OpEq, query.OpIn},
+		"created_at":        {query.OpLt, query.OpLte, query.OpGt, query.OpGte},
+		"email_verified_at": {query.OpNull},
+	},
+	DefaultSort: "id",
+}
+
+type AdminUserController struct{}
+
+func NewAdminUserController() *AdminUserController {
+	return &AdminUserController{}
+}
+
+// Index lists the users, see adminUserQuery for the accepted sort and
+// filter parameters. search matches the username and the email,
+// trashed=with includes deleted users and trashed=only lists them alone.
+func (ac *AdminUserController) Index(c *fiber.Ctx) error {
+	db := database.Connect.WithContext(c.UserContext())
+	switch c.Query("trashed") {
+	case "with":
+		db = db.Unscoped()
+	case "only":
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if search := c.Query("search"); search != "" {
+		like := "%" + search + "%"
+		db = db.Where("username LIKE ? OR email LIKE ?", like, like)
+	}
+
+	page, err := query.Paginate[models.User](c, db, adminUserQuery)
+	if err != nil {
+		return err
+	}
+	return c.JSON(page)
+}
+
+func (ac *AdminUserController) Show(c *fiber.Ctx) error {
+	user, err := findUser(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+	})
+}
+
+// UpdateStatus activates or deactivates the user. Deactivating revokes
+// their tokens, and they cannot log in until they are activated again.
+func (ac *AdminUserController) UpdateStatus(c *fiber.Ctx) error {
+	req, err := new(dto.AdminUserStatusRequest).Validate(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(c)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]any{"status": *req.Status}
+	if !*req.Status {
+		updates["token_version"] = gorm.Expr("token_version + 1")
+	}
+	if err := updateUser(c, user, "admin.user.status_updated", updates); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User status updated",
+		"data":    user,
+	})
+}
+
+// Destroy soft deletes the user and revokes their tokens
+func (ac *AdminUserController) Destroy(c *fiber.Ctx) error {
+	user, err := findUser(c)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt.Valid {
+		return apperror.Conflict("User is already deleted")
+	}
+	if actorID, _ := c.Locals("user_id").(uint); actorID == user.ID {
+		return apperror.Conflict("You cannot delete your own account")
+	}
+
+	err = database.Connect.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		revoke := tx.WithContext(audit.WithAction(c.UserContext(), "admin.user.tokens_revoked"))
+		if err := revoke.Model(&models.User{}).Where("id = ?", user.ID).Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return fmt.Errorf("revoke tokens: %w", err)
+		}
+		del := tx.WithContext(audit.WithAction(c.UserContext(), "admin.user.deleted"))
+		if err := del.Delete(&models.User{}, user.ID).Error; err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	httpcache.InvalidateUser(user.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User deleted",
+	})
+}
+
+// Restore brings back a soft deleted user
+func (ac *AdminUserController) Restore(c *fiber.Ctx) error {
+	user, err := findUser(c)
+	if err != nil {
+		return err
+	}
+	if !user.DeletedAt.Valid {
+		return apperror.Conflict("User is not deleted")
+	}
+
+	// Restoring an account deleted by its user cancels its purge
+	updates := map[string]any{"deleted_at": nil, "deletion_scheduled_at": nil}
+	if err := updateUser(c, user, "admin.user.restored", updates); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User restored",
+		"data":    user,
+	})
+}
+
+// ForcePasswordReset revokes the user's tokens and blocks the API for them
+// until they changed their password
+func (ac *AdminUserController) ForcePasswordReset(c *fiber.Ctx) error {
+	user, err := findUser(c)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]any{
+		"password_reset_required": true,
+		"token_version":           gorm.Expr("token_version + 1"),
+	}
+	if err := updateUser(c, user, "admin.user.password_reset_forced", updates); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "The user must reset their password",
+		"data":    user,
+	})
+}
+
+// RevokeTokens invalidates every token issued to the user so far
+func (ac *AdminUserController) RevokeTokens(c *fiber.Ctx) error {
+	user, err := findUser(c)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]any{"token_version": gorm.Expr("token_version + 1")}
+	if err := updateUser(c, user, "admin.user.tokens_revoked", updates); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tokens revoked",
+		"data":    user,
+	})
+}
+
+// findUser loads the user of the :id parameter, deleted or not
+func findUser(c *fiber.Ctx) (*models.User, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, apperror.NotFound("User not found")
+	}
+
+	var user models.User
+	if err := database.Connect.WithContext(c.UserContext()).Unscoped().First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("User not found")
+		}
+		return nil, apperror.Internal(fmt.Errorf("find user: %w", err))
+	}
+	return &user, nil
+}
+
+// updateUser applies updates to user, recorded in the audit log as action,
+// and reloads user
+func updateUser(c *fiber.Ctx, user *models.User, action string, updates map[string]any) error {
+	ctx := audit.WithAction(c.UserContext(), action)
+	err := database.Connect.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("update user: %w", err)
+		}
+		var updated models.User
+		if err := tx.Unscoped().First(&updated, user.ID).Error; err != nil {
+			return fmt.Errorf("reload user: %w", err)
+		}
+		*user = updated
+		return nil
+	})
+	if err != nil {
+		return apperror.Internal(err)
+	}
+
+	httpcache.InvalidateUser(user.ID)
+	return nil
+}
+
+var AdminUserControllerInstance = NewAdminUserController()
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/query"
+	"github.com/gofiber/fiber/v2"
+)
+
+// What clients may sort and filter the history of a record by
+var auditLogQuery = query.Options{
+	Sortable: []string{"id", "created_at"},
+	Filterable: map[string][]string{
+		"action":     {query.OpEq, query.OpIn},
+		"actor_id":   {query.OpEq},
+		"created_at": {query.OpLt, query.OpLte, query.OpGt, query.OpGte},
+	},
+	DefaultSort: "-id",
+}
+
+// AuditLogResponse is an audit log with its changes decoded, e.g.
+// {"status": {"old": true, "new": false}}
+type AuditLogResponse struct {
+	models.AuditLog
+	Changes json.RawMessage `json:"changes"`
+}
+
+type AuditLogController struct{}
+
+func NewAuditLogController() *AuditLogController {
+	return &AuditLogController{}
+}
+
+// History lists the audit logs of a record, newest first. :type is the
+// table of the record, e.g. users, see auditLogQuery for the accepted sort
+// and filter parameters.
+func (ac *AuditLogController) History(c *fiber.Ctx) error {
+	db := database.Connect.WithContext(c.UserContext()).
+		Where("auditable_type = ? AND auditable_id = ?", c.Params("type"), c.Params("id"))
+
+	page, err := query.Paginate[models.AuditLog](c, db, auditLogQuery)
+	if err != nil {
+		return err
+	}
+
+	logs := make([]AuditLogResponse, 0, len(page.Data))
+	for _, log := range page.Data {
+		changes := json.RawMessage("{}")
+		if json.Valid([]byte(log.Changes)) {
+			changes = json.RawMessage(log.Changes)
+		}
+		logs = append(logs, AuditLogResponse{AuditLog: log, Changes: changes})
+	}
+	return c.JSON(query.Page[AuditLogResponse]{
+		Success: page.Success,
+		Data:    logs,
+		Meta:    page.Meta,
+		Links:   page.Links,
+	})
+}
+
+var AuditLogControllerInstance = NewAuditLogController()
@@ -6,7 +6,9 @@ import (
 
 	"github.com/galaplate/core/database"
 	"github.com/galaplate/core/supports"
+	"github.com/galaplate/galaplate/pkg/apperror"
 	"github.com/galaplate/galaplate/pkg/dto"
+	"github.com/galaplate/galaplate/pkg/metrics"
 	"github.com/galaplate/galaplate/pkg/middleware"
 	"github.com/galaplate/galaplate/pkg/models"
 	"github.com/gofiber/fiber/v2"
@@ -30,32 +32,16 @@ func (ac *AuthController) Register(c *fiber.Ctx) error {
 		return err
 	}
 
-	db := database.Connect
+	db := database.Connect.WithContext(c.UserContext())
 
-	// Check if user with email already exists
-	var existingUser models.User
-	if err := db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"success": false,
-			"message": "User with this email already exists",
-		})
-	}
-
-	// Check if user with username already exists
-	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"success": false,
-			"message": "User with this username already exists",
-		})
+	if err := ensureUniqueUser(db, req.Email, req.Username, 0); err != nil {
+		return err
 	}
 
 	// Hash password
 	hashedPassword, err := new(supports.Bcrypt).HashPassword(req.Password)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to process password",
-		})
+		return apperror.Internal(fmt.Errorf("hash password: %w", err))
 	}
 
 	// Create user
@@ -63,25 +49,19 @@ func (ac *AuthController) Register(c *fiber.Ctx) error {
 		Username: req.Username,
 		Email:    req.Email,
 		Password: hashedPassword,
-		Status:   false,
+		Status:   true,
+		Role:     models.RoleUser,
 	}
 
 	if err := db.Create(&user).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to create user",
-			"error":   err.Error(),
-		})
+		return apperror.Internal(fmt.Errorf("create user: %w", err))
 	}
 
 	// Generate JWT token
 	jwtService := middleware.NewJWTService()
-	token, err := jwtService.GenerateToken(user.ID)
+	token, err := jwtService.GenerateToken(&user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to generate token",
-		})
+		return apperror.Internal(fmt.Errorf("generate token: %w", err))
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -100,42 +80,39 @@ func (ac *AuthController) Login(c *fiber.Ctx) error {
 		return err
 	}
 
-	db := database.Connect
+	db := database.Connect.WithContext(c.UserContext())
 
 	// Find user by email
 	var user models.User
 	if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid credentials",
-			})
+			metrics.RecordLogin("failure")
+			return apperror.Unauthorized("Invalid credentials")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": fmt.Sprintf("Database error: %s", err.Error()),
-		})
+		metrics.RecordLogin("error")
+		return apperror.Internal(fmt.Errorf("find user: %w", err))
 	}
 
 	// Verify password
 	bcryptService := new(supports.Bcrypt)
 	if !bcryptService.DoPasswordsMatch(user.Password, req.Password) {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid credentials",
-		})
+		metrics.RecordLogin("failure")
+		return apperror.Unauthorized("Invalid credentials")
+	}
+	if !user.Status {
+		metrics.RecordLogin("failure")
+		return apperror.New(fiber.StatusForbidden, middleware.CodeAccountInactive, "Account is inactive")
 	}
 
 	// Generate JWT token
 	jwtService := middleware.NewJWTService()
-	token, err := jwtService.GenerateToken(user.ID)
+	token, err := jwtService.GenerateToken(&user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to generate token",
-		})
+		metrics.RecordLogin("error")
+		return apperror.Internal(fmt.Errorf("generate token: %w", err))
 	}
 
+	metrics.RecordLogin("success")
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Login successful",
@@ -146,4 +123,39 @@ func (ac *AuthController) Login(c *fiber.Ctx) error {
 	})
 }
 
+// ensureUniqueUser answers 409 when a user other than exceptID already has
+// email or username. Empty values are not checked.
+func ensureUniqueUser(db *gorm.DB, email, username string, exceptID uint) error {
+	// Soft deleted users keep their email, which the unique index covers
+	if email != "" {
+		taken, err := userExists(db.Unscoped(), "email = ? AND id <> ?", email, exceptID)
+		if err != nil {
+			return apperror.Internal(fmt.Errorf("check email: %w", err))
+		}
+		if taken {
+			return apperror.Conflict("User with this email already exists")
+		}
+	}
+
+	if username != "" {
+		taken, err := userExists(db, "username = ? AND id <> ?", username, exceptID)
+		if err != nil {
+			return apperror.Internal(fmt.Errorf("check username: %w", err))
+		}
+		if taken {
+			return apperror.Conflict("User with this username already exists")
+		}
+	}
+	return nil
+}
+
+func userExists(db *gorm.DB, query string, args ...any) (bool, error) {
+	var existingUser models.User
+	err := db.Where(query, args...).First(&existingUser).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 var AuthControllerInstance = NewAuthController()
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"html/template"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/middleware"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocsController struct{}
+
+type DocsViewData struct {
+	Title    string
+	SpecURL  string
+	CSPNonce string
+}
+
+func NewDocsController() *DocsController {
+	return &DocsController{}
+}
+
+// Spec serves the OpenAPI document of the routes registered on the app
+func (dc *DocsController) Spec(c *fiber.Ctx) error {
+	return c.JSON(openapi.Generate(c.App(), openapi.LoadInfo()))
+}
+
+// UI serves the bundled docs page, which renders the document served by Spec
+func (dc *DocsController) UI(c *fiber.Ctx) error {
+	tmpl, err := template.ParseFiles("templates/api-docs.html")
+	if err != nil {
+		return c.Status(500).SendString("Error loading template")
+	}
+
+	data := DocsViewData{
+		Title:    openapi.LoadInfo().Title,
+		SpecURL:  configutil.String("openapi.path", "/openapi.json"),
+		CSPNonce: middleware.CSPNonce(c),
+	}
+
+	c.Type("html")
+	return tmpl.Execute(c.Response().BodyWriter(), data)
+}
+
+var DocsControllerInstance = NewDocsController()
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/downloads"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/uploads"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// FileURLResponse is a temporary download URL of a file
+type FileURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// FileMetadataResponse is a file with temporary download URLs for it and
+// its variants, all expiring at ExpiresAt
+type FileMetadataResponse struct {
+	File      *models.File `json:"file"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+type FileController struct{}
+
+func NewFileController() *FileController {
+	return &FileController{}
+}
+
+// Store uploads the multipart field "file" for the authenticated user,
+// within the max_size and allowed_types of config/filesystems.yaml
+func (fc *FileController) Store(c *fiber.Ctx) error {
+	header, err := uploads.FormFile(c, "file")
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("user_id").(uint)
+	file, err := uploads.Store(c.UserContext(), header, &userID, "files", uploads.LoadRules(""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "File uploaded",
+		"data":    file,
+	})
+}
+
+// URL hands the owner of the file, or an administrator, a temporary
+// download URL of the file or of its variant named by the variant query
+// parameter. disposition=inline lets browsers display the file rather than
+// save it.
+func (fc *FileController) URL(c *fiber.Ctx) error {
+	disposition, err := queryDisposition(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := findFile(c)
+	if err != nil {
+		return err
+	}
+	user := c.Locals("user").(*models.User)
+	if err := downloads.Authorize(user, file); err != nil {
+		return err
+	}
+	variant, err := findVariant(c, file)
+	if err != nil {
+		return err
+	}
+
+	url, expiresAt, err := downloads.TemporaryURL(c, file, variant, user, disposition)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    FileURLResponse{URL: url, ExpiresAt: expiresAt},
+	})
+}
+
+// Show sends the file to the holder of a URL handed out by URL. The
+// signature is checked before the file is looked up, so that unsigned
+// requests cannot tell which files exist.
+func (fc *FileController) Show(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return apperror.NotFound("File not found")
+	}
+	user, err := downloads.Verify(c, uint(id))
+	if err != nil {
+		return err
+	}
+
+	file, err := findFile(c)
+	if err != nil {
+		return err
+	}
+	if err := downloads.Authorize(user, file); err != nil {
+		return err
+	}
+	variant, err := findVariant(c, file)
+	if err != nil {
+		return err
+	}
+
+	return downloads.Serve(c, file, variant, c.Query("disposition"))
+}
+
+// Metadata returns the file with its image variants, each with a temporary
+// download URL like the ones of URL. Variants are generated in the
+// background after the upload, so they may not be listed yet.
+func (fc *FileController) Metadata(c *fiber.Ctx) error {
+	disposition, err := queryDisposition(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := findFile(c)
+	if err != nil {
+		return err
+	}
+	user := c.Locals("user").(*models.User)
+	if err := downloads.Authorize(user, file); err != nil {
+		return err
+	}
+
+	err = database.Connect.WithContext(c.UserContext()).
+		Where("file_id = ?", file.ID).
+		Order("name").
+		Find(&file.Variants).Error
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("find variants: %w", err))
+	}
+
+	var expiresAt time.Time
+	if file.URL, expiresAt, err = downloads.TemporaryURL(c, file, nil, user, disposition); err != nil {
+		return err
+	}
+	for i := range file.Variants {
+		if file.Variants[i].URL, _, err = downloads.TemporaryURL(c, file, &file.Variants[i], user, disposition); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    FileMetadataResponse{File: file, ExpiresAt: expiresAt},
+	})
+}
+
+func queryDisposition(c *fiber.Ctx) (string, error) {
+	disposition := c.Query("disposition", downloads.DispositionAttachment)
+	if disposition != downloads.DispositionAttachment && disposition != downloads.DispositionInline {
+		return "", apperror.Validation("The disposition must be attachment or inline", map[string]string{
+			"disposition": "The disposition must be attachment or inline",
+		})
+	}
+	return disposition, nil
+}
+
+// findFile loads the file of the :id parameter
+func findFile(c *fiber.Ctx) (*models.File, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, apperror.NotFound("File not found")
+	}
+
+	var file models.File
+	if err := database.Connect.WithContext(c.UserContext()).First(&file, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("File not found")
+		}
+		return nil, apperror.Internal(fmt.Errorf("find file: %w", err))
+	}
+	return &file, nil
+}
+
+// findVariant loads the variant of file named by the variant query
+// parameter, nil when there is none
+func findVariant(c *fiber.Ctx, file *models.File) (*models.FileVariant, error) {
+	name := c.Query("variant")
+	if name == "" {
+		return nil, nil
+	}
+
+	var variant models.FileVariant
+	err := database.Connect.WithContext(c.UserContext()).
+		Where("file_id = ? AND name = ?", file.ID, name).
+		First(&variant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Variant not found")
+		}
+		return nil, apperror.Internal(fmt.Errorf("find variant: %w", err))
+	}
+	return &variant, nil
+}
+
+var FileControllerInstance = NewFileController()
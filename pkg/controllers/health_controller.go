@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/health"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthController struct{}
+
+func NewHealthController() *HealthController {
+	return &HealthController{}
+}
+
+// Live reports that the process is up and serving requests. It does not
+// look at dependencies, so an orchestrator only restarts the process when
+// it is actually stuck.
+func (hc *HealthController) Live(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": health.StatusOK,
+	})
+}
+
+// Ready runs the registered health checks and answers 503 when one fails,
+// so the instance is taken out of rotation until its dependencies recover.
+// The route is public: the errors of failed checks are logged, and only
+// sent back when app.debug is enabled.
+func (hc *HealthController) Ready(c *fiber.Ctx) error {
+	report := health.Run(c.UserContext())
+
+	status := fiber.StatusOK
+	if !report.Healthy() {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	debug := configutil.Bool("app.debug", false)
+	for name, result := range report.Checks {
+		if result.Status != health.StatusFail {
+			continue
+		}
+		logging.FromCtx(c).Warn("health check failed", map[string]any{"check": name, "error": result.Error})
+		if !debug {
+			result.Error = "check failed"
+			report.Checks[name] = result
+		}
+	}
+
+	return c.Status(status).JSON(report)
+}
+
+var HealthControllerInstance = NewHealthController()
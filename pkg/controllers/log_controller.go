@@ -13,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -38,6 +40,8 @@ type LogViewerData struct {
 	PageSize    int
 	HasPrevious bool
 	HasNext     bool
+	RequestID   string
+	CSPNonce    string
 }
 
 type LogEntryWithJSON struct {
@@ -46,6 +50,7 @@ type LogEntryWithJSON struct {
 	Message            string         `json:"message"`
 	AdditionalInfo     map[string]any `json:"additional_info,omitempty"`
 	AdditionalInfoJSON string         `json:"-"`
+	RequestID          string         `json:"-"`
 }
 
 func (lvc *LogController) Export(c *fiber.Ctx) error {
@@ -53,21 +58,21 @@ func (lvc *LogController) Export(c *fiber.Ctx) error {
 
 	logFiles, err := getLogFiles(logDir)
 	if err != nil {
-		return c.Status(500).SendString("Error reading log directory")
+		return apperror.Internal(fmt.Errorf("read log directory: %w", err))
 	}
 
 	currentFile := c.Query("file")
 	if currentFile == "" || len(logFiles) == 0 {
-		return c.Status(400).SendString("No log file specified")
+		return apperror.BadRequest("No log file specified")
 	}
 
 	dateFrom := c.Query("date_from")
 	dateTo := c.Query("date_to")
 	format := c.Query("format")
 
-	logs, err := parseLogFile(filepath.Join(logDir, currentFile))
+	logs, err := loadLogs(logDir, logFiles, currentFile, c.Query("request_id"))
 	if err != nil {
-		return c.Status(500).SendString("Error reading log file")
+		return apperror.Internal(fmt.Errorf("read log file: %w", err))
 	}
 
 	filteredLogs := filterLogsByDate(logs, dateFrom, dateTo)
@@ -114,7 +119,7 @@ func (lvc *LogController) Export(c *fiber.Ctx) error {
 		return c.SendString(csvContent)
 	}
 
-	return c.Status(400).SendString("Invalid export format. Use 'json' or 'csv'")
+	return apperror.BadRequest("Invalid export format. Use 'json' or 'csv'")
 }
 
 func (lvc *LogController) CleanupLogs(c *fiber.Ctx) error {
@@ -154,10 +159,7 @@ func (lvc *LogController) CleanupLogs(c *fiber.Ctx) error {
 	})
 
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to cleanup logs",
-		})
+		return apperror.Internal(fmt.Errorf("cleanup logs: %w", err))
 	}
 
 	return c.JSON(fiber.Map{
@@ -206,10 +208,7 @@ func (lvc *LogController) GetLogStats(c *fiber.Ctx) error {
 	})
 
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to get log statistics",
-		})
+		return apperror.Internal(fmt.Errorf("log statistics: %w", err))
 	}
 
 	return c.JSON(fiber.Map{
@@ -257,7 +256,9 @@ func (lvc *LogController) Index(c *fiber.Ctx) error {
 		}
 	}
 
-	logs, err := parseLogFile(filepath.Join(logDir, currentFile))
+	requestID := c.Query("request_id")
+
+	logs, err := loadLogs(logDir, logFiles, currentFile, requestID)
 	if err != nil {
 		return c.Status(500).SendString("Error reading log file")
 	}
@@ -303,6 +304,7 @@ func (lvc *LogController) Index(c *fiber.Ctx) error {
 			Message:            log.Message,
 			AdditionalInfo:     log.AdditionalInfo,
 			AdditionalInfoJSON: string(jsonBytes),
+			RequestID:          requestIDOf(log),
 		}
 	}
 
@@ -321,6 +323,8 @@ func (lvc *LogController) Index(c *fiber.Ctx) error {
 		PageSize:    pageSize,
 		HasPrevious: page > 1,
 		HasNext:     page < totalPages,
+		RequestID:   requestID,
+		CSPNonce:    middleware.CSPNonce(c),
 	}
 
 	tmpl, err := template.ParseFiles("templates/log-viewer.html")
@@ -401,6 +405,45 @@ func parseLogFile(filePath string) ([]LogEntry, error) {
 	return logs, nil
 }
 
+// loadLogs reads the current log file, or every log file when a request ID
+// is given so that all entries written while serving that request (app and
+// access logs alike) are shown together
+func loadLogs(logDir string, logFiles []string, currentFile string, requestID string) ([]LogEntry, error) {
+	if requestID == "" {
+		return parseLogFile(filepath.Join(logDir, currentFile))
+	}
+
+	var logs []LogEntry
+	for _, file := range logFiles {
+		entries, err := parseLogFile(filepath.Join(logDir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if requestIDOf(entry) == requestID {
+				logs = append(logs, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp > logs[j].Timestamp
+	})
+
+	return logs, nil
+}
+
+func requestIDOf(log LogEntry) string {
+	if log.AdditionalInfo == nil {
+		return ""
+	}
+	if requestID, ok := log.AdditionalInfo["request_id"].(string); ok {
+		return requestID
+	}
+	return ""
+}
+
 func reverseSlice(logs []LogEntry) {
 	for i := 0; i < len(logs)/2; i++ {
 		j := len(logs) - 1 - i
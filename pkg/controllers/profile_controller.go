@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/supports"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/dto"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/middleware"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/uploads"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ProfileController struct{}
+
+type AvatarResponse struct {
+	User   *models.User `json:"user"`
+	Avatar *models.File `json:"avatar"`
+}
+
+func NewProfileController() *ProfileController {
+	return &ProfileController{}
+}
+
+func (pc *ProfileController) Show(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Profile data",
+		"data":    c.Locals("user"),
+	})
+}
+
+// Update changes the username, email and description of the authenticated
+// user. Changing the email clears email_verified_at, as the new address has
+// not been verified. Every change is recorded in the audit log.
+func (pc *ProfileController) Update(c *fiber.Ctx) error {
+	req, err := new(dto.ProfileUpdateRequest).Validate(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*models.User)
+	db := database.Connect.WithContext(c.UserContext())
+
+	changes := audit.Changes{}
+	if req.Username != nil {
+		changes.Set("username", user.Username, *req.Username)
+	}
+	if req.Email != nil {
+		changes.Set("email", user.Email, *req.Email)
+	}
+	if req.Description != nil {
+		changes.Set("description", user.Description, *req.Description)
+	}
+
+	_, emailChanged := changes["email"]
+	if len(changes) == 0 {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "Profile unchanged",
+			"data":    user,
+		})
+	}
+
+	var email, username string
+	if emailChanged {
+		email = *req.Email
+	}
+	if _, ok := changes["username"]; ok {
+		username = *req.Username
+	}
+	if err := ensureUniqueUser(db, email, username, user.ID); err != nil {
+		return err
+	}
+
+	updates := map[string]any{}
+	for field, change := range changes {
+		updates[field] = change.New
+	}
+	if emailChanged {
+		updates["email_verified_at"] = nil
+	}
+
+	if err := updateUser(c, user, "profile.updated", updates); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Profile updated successfully",
+		"data":    user,
+	})
+}
+
+// ChangePassword sets a new password after checking the current one. It
+// revokes the other tokens of the user, clears a password reset forced by
+// an administrator and returns a new token.
+func (pc *ProfileController) ChangePassword(c *fiber.Ctx) error {
+	req, err := new(dto.ProfilePasswordRequest).Validate(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*models.User)
+	bcryptService := new(supports.Bcrypt)
+	if !bcryptService.DoPasswordsMatch(user.Password, req.CurrentPassword) {
+		return apperror.Validation("The current password is incorrect", map[string]string{
+			"current_password": "The current password is incorrect",
+		})
+	}
+
+	hashedPassword, err := bcryptService.HashPassword(req.Password)
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("hash password: %w", err))
+	}
+
+	// The audit log redacts the password
+	updates := map[string]any{
+		"password":                hashedPassword,
+		"password_reset_required": false,
+		"token_version":           gorm.Expr("token_version + 1"),
+	}
+	if err := updateUser(c, user, "profile.password_changed", updates); err != nil {
+		return err
+	}
+
+	token, err := middleware.NewJWTService().GenerateToken(user)
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("generate token: %w", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password changed successfully",
+		"data": AuthResponse{
+			User:  user,
+			Token: token,
+		},
+	})
+}
+
+// UpdateAvatar replaces the avatar of the authenticated user with the image
+// sent in the multipart field "avatar". The previous avatar is deleted.
+func (pc *ProfileController) UpdateAvatar(c *fiber.Ctx) error {
+	header, err := uploads.FormFile(c, "avatar")
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*models.User)
+	avatar, err := uploads.Store(c.UserContext(), header, &user.ID, "avatars", uploads.LoadRules("avatars"))
+	if err != nil {
+		return err
+	}
+
+	previousID := user.AvatarID
+	if err := updateUser(c, user, "profile.avatar_updated", map[string]any{"avatar_id": avatar.ID}); err != nil {
+		if err := uploads.Delete(c.UserContext(), avatar); err != nil {
+			logging.FromCtx(c).Warn("could not delete the new avatar", map[string]any{"file_id": avatar.ID, "error": err.Error()})
+		}
+		return err
+	}
+
+	if previousID != nil {
+		var previous models.File
+		err := database.Connect.WithContext(c.UserContext()).First(&previous, *previousID).Error
+		if err == nil {
+			err = uploads.Delete(c.UserContext(), &previous)
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromCtx(c).Warn("could not delete the previous avatar", map[string]any{"file_id": *previousID, "error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Avatar updated",
+		"data": AvatarResponse{
+			User:   user,
+			Avatar: avatar,
+		},
+	})
+}
+
+var ProfileControllerInstance = NewProfileController()
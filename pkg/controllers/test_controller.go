@@ -25,13 +25,6 @@ func NewTestController() *TestController {
 	return &TestController{}
 }
 
-func (tc *TestController) GetHealthCheck(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
-		"status":  "ok",
-		"message": "API is working",
-	})
-}
-
 func (tc *TestController) CreateTestData(c *fiber.Ctx) error {
 	req, err := new(CreateTestRequest).Validate(c)
 	if err != nil {
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/dto"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/uploads"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// HeaderUploadOffset carries the offset of a chunk in requests and the
+// bytes received so far in responses
+const HeaderUploadOffset = "Upload-Offset"
+
+// UploadController receives files in chunks, for large files sent over
+// unreliable connections:
+//
+//	POST   /uploads              {"name", "size"} starts an upload
+//	PATCH  /uploads/:id          sends the next chunk as the raw body, at Upload-Offset
+//	GET    /uploads/:id          tells where to resume after a failed chunk
+//	POST   /uploads/:id/complete stores the file like POST /files
+//	DELETE /uploads/:id          abandons the upload
+type UploadController struct{}
+
+func NewUploadController() *UploadController {
+	return &UploadController{}
+}
+
+func (uc *UploadController) Store(c *fiber.Ctx) error {
+	req, err := new(dto.UploadCreateRequest).Validate(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("user_id").(uint)
+	upload, err := uploads.Begin(c.UserContext(), userID, "files", req.Name, req.Size, uploads.LoadRules(""))
+	if err != nil {
+		return err
+	}
+
+	c.Set(HeaderUploadOffset, "0")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Upload started",
+		"data":    upload,
+	})
+}
+
+func (uc *UploadController) Show(c *fiber.Ctx) error {
+	upload, err := findUpload(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set(HeaderUploadOffset, strconv.FormatInt(upload.Received, 10))
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    upload,
+	})
+}
+
+// Append writes the request body at the Upload-Offset header, which must
+// be the number of bytes received so far
+func (uc *UploadController) Append(c *fiber.Ctx) error {
+	offset, err := strconv.ParseInt(c.Get(HeaderUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		return apperror.Validation("The Upload-Offset header is required", map[string]string{
+			HeaderUploadOffset: "The Upload-Offset header must be the number of bytes received so far",
+		})
+	}
+
+	upload, err := findUpload(c)
+	if err != nil {
+		return err
+	}
+	if err := uploads.Append(c.UserContext(), upload, offset, c.Body()); err != nil {
+		return err
+	}
+
+	c.Set(HeaderUploadOffset, strconv.FormatInt(upload.Received, 10))
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    upload,
+	})
+}
+
+func (uc *UploadController) Complete(c *fiber.Ctx) error {
+	req, err := new(dto.UploadCompleteRequest).Validate(c)
+	if err != nil {
+		return err
+	}
+
+	upload, err := findUpload(c)
+	if err != nil {
+		return err
+	}
+	file, err := uploads.Complete(c.UserContext(), upload, req.Checksum, uploads.LoadRules(""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "File uploaded",
+		"data":    file,
+	})
+}
+
+func (uc *UploadController) Destroy(c *fiber.Ctx) error {
+	upload, err := findUpload(c)
+	if err != nil {
+		return err
+	}
+	if err := uploads.Abort(c.UserContext(), upload); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Upload cancelled",
+	})
+}
+
+// findUpload loads the upload of the :id parameter, if it belongs to the
+// authenticated user and has not expired
+func findUpload(c *fiber.Ctx) (*models.ChunkedUpload, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, apperror.NotFound("Upload not found")
+	}
+
+	var upload models.ChunkedUpload
+	err = database.Connect.WithContext(c.UserContext()).
+		Where("user_id = ? AND expires_at > ?", c.Locals("user_id"), time.Now()).
+		First(&upload, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Upload not found")
+		}
+		return nil, apperror.Internal(fmt.Errorf("find upload: %w", err))
+	}
+	return &upload, nil
+}
+
+var UploadControllerInstance = NewUploadController()
@@ -0,0 +1,235 @@
+package downloads
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// CodeInvalidSignature is sent for download URLs that were tampered with,
+// have expired or were issued to a user who lost access to the file
+const CodeInvalidSignature = "invalid_signature"
+
+// How the downloaded file is presented by browsers
+const (
+	DispositionAttachment = "attachment"
+	DispositionInline     = "inline"
+)
+
+// Ways a disk hands out temporary URLs, set with temporary_urls on the disk
+// in config/filesystems.yaml
+const (
+	// ModePresigned lets clients download straight from disks able to sign
+	// URLs of their own, such as S3
+	ModePresigned = "presigned"
+	// ModeProxy sends every download through GET /api/files/:id
+	ModeProxy = "proxy"
+)
+
+// route is the name of the route serving signed downloads
+const route = "files.show"
+
+// Authorize lets users download their own files and administrators any file
+func Authorize(user *models.User, file *models.File) error {
+	if user.IsAdmin() || (file.UserID != nil && *file.UserID == user.ID) {
+		return nil
+	}
+	return apperror.Forbidden("You may not download this file")
+}
+
+// TemporaryURL returns a URL downloading file, or its variant when not
+// nil, until the returned time. The URL is presigned by the disk of the
+// file when it can and its mode is ModePresigned; otherwise it points to
+// GET /api/files/:id, signed for user, so that it stops working as soon as
+// user loses access to the file.
+func TemporaryURL(c *fiber.Ctx, file *models.File, variant *models.FileVariant, user *models.User, disposition string) (string, time.Time, error) {
+	expiration := configutil.Duration("filesystems.downloads.expiration", 15*time.Minute)
+	expiresAt := time.Now().Add(expiration).Truncate(time.Second)
+
+	disk, err := storage.Open(file.Disk)
+	if err != nil {
+		return "", time.Time{}, apperror.Internal(err)
+	}
+	if signer, ok := disk.(storage.URLSigner); ok && mode(file.Disk) == ModePresigned {
+		object := objectOf(file, variant)
+		link, err := signer.TemporaryURL(c.UserContext(), object.path, expiration, storage.URLOptions{
+			ContentType:        object.mimeType,
+			ContentDisposition: contentDisposition(disposition, object.name),
+		})
+		if err != nil {
+			return "", time.Time{}, apperror.Internal(err)
+		}
+		return link, expiresAt, nil
+	}
+
+	secret, err := secret()
+	if err != nil {
+		return "", time.Time{}, apperror.Internal(err)
+	}
+	path, err := c.GetRouteURL(route, fiber.Map{"id": file.ID})
+	if err != nil {
+		return "", time.Time{}, apperror.Internal(fmt.Errorf("route url: %w", err))
+	}
+
+	query := url.Values{}
+	query.Set("user", strconv.FormatUint(uint64(user.ID), 10))
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("disposition", disposition)
+	variantName := ""
+	if variant != nil {
+		variantName = variant.Name
+		query.Set("variant", variantName)
+	}
+	query.Set("signature", sign(secret, file.ID, variantName, user, expiresAt.Unix(), disposition))
+	return c.BaseURL() + path + "?" + query.Encode(), expiresAt, nil
+}
+
+// Verify checks the signature and the expiry of the download URL of file
+// requested by c, and returns the user it was issued to. The user's access
+// to the file is checked again with Authorize.
+func Verify(c *fiber.Ctx, fileID uint) (*models.User, error) {
+	invalid := apperror.New(fiber.StatusForbidden, CodeInvalidSignature, "The download URL is invalid or has expired")
+
+	userID, err := strconv.ParseUint(c.Query("user"), 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return nil, invalid
+	}
+	disposition := c.Query("disposition")
+	if disposition != DispositionAttachment && disposition != DispositionInline {
+		return nil, invalid
+	}
+
+	secret, err := secret()
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	var user models.User
+	if err := database.Connect.WithContext(c.UserContext()).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, apperror.Internal(fmt.Errorf("find user: %w", err))
+	}
+
+	// The token version is signed too, so revoking the user's tokens also
+	// revokes the URLs issued to them
+	expected := sign(secret, fileID, c.Query("variant"), &user, expires, disposition)
+	if !hmac.Equal([]byte(c.Query("signature")), []byte(expected)) {
+		return nil, invalid
+	}
+	return &user, nil
+}
+
+// Serve sends the content of file, or of its variant when not nil. Files
+// of local disks are sent with support for Range and conditional requests;
+// files of other disks are streamed from the disk.
+func Serve(c *fiber.Ctx, file *models.File, variant *models.FileVariant, disposition string) error {
+	disk, err := storage.Open(file.Disk)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+
+	object := objectOf(file, variant)
+	if local, ok := disk.(*storage.LocalDisk); ok {
+		path, err := local.Path(object.path)
+		if err != nil {
+			return apperror.Internal(err)
+		}
+		if err := c.SendFile(path); err != nil {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+				return apperror.NotFound("File not found")
+			}
+			return apperror.Internal(err)
+		}
+		// SendFile guesses the type from the extension
+		setHeaders(c, object, disposition)
+		return nil
+	}
+
+	body, err := disk.Get(c.UserContext(), object.path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperror.NotFound("File not found")
+	}
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	setHeaders(c, object, disposition)
+	return c.SendStream(body, int(object.size))
+}
+
+// object is the stored content of a file or of one of its variants
+type object struct {
+	path     string
+	name     string
+	mimeType string
+	size     int64
+}
+
+// objectOf names variants after the file, e.g. photo-thumb.jpg for the
+// thumb variant of photo.png
+func objectOf(file *models.File, variant *models.FileVariant) object {
+	if variant == nil {
+		return object{path: file.Path, name: file.Name, mimeType: file.MimeType, size: file.Size}
+	}
+	base := strings.TrimSuffix(file.Name, path.Ext(file.Name))
+	return object{
+		path:     variant.Path,
+		name:     base + "-" + variant.Name + path.Ext(variant.Path),
+		mimeType: variant.MimeType,
+		size:     variant.Size,
+	}
+}
+
+func setHeaders(c *fiber.Ctx, object object, disposition string) {
+	c.Set(fiber.HeaderContentType, object.mimeType)
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(disposition, object.name))
+	c.Set(fiber.HeaderCacheControl, "private")
+}
+
+// contentDisposition encodes name as RFC 2231 requires when it is not
+// plain ASCII
+func contentDisposition(disposition, name string) string {
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": name}); header != "" {
+		return header
+	}
+	return disposition
+}
+
+func mode(disk string) string {
+	return configutil.String("filesystems.disks."+disk+".temporary_urls", ModePresigned)
+}
+
+func secret() ([]byte, error) {
+	key := configutil.String("app.key", "")
+	if key == "" {
+		return nil, errors.New("downloads: app.key is not set, set APP_SECRET")
+	}
+	return []byte(key), nil
+}
+
+func sign(secret []byte, fileID uint, variant string, user *models.User, expires int64, disposition string) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d\n%s\n%d\n%d\n%d\n%s", fileID, variant, user.ID, user.TokenVersion, expires, disposition)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccountDeleteRequest - Generated on 2026-10-18 16:12:40
+type AccountDeleteRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+func (s *AccountDeleteRequest) Validate(c *fiber.Ctx) (u *AccountDeleteRequest, err error) {
+	if err = supports.NewValidator(c).Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminUserStatusRequest - Generated on 2026-10-18 10:41:27
+type AdminUserStatusRequest struct {
+	Status *bool `json:"status" validate:"required"`
+}
+
+func (s *AdminUserStatusRequest) Validate(c *fiber.Ctx) (u *AdminUserStatusRequest, err error) {
+	if err = supports.NewValidator(c).Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
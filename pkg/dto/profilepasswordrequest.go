@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProfilePasswordRequest - Generated on 2026-10-18 10:43:02
+type ProfilePasswordRequest struct {
+	CurrentPassword      string `json:"current_password" validate:"required"`
+	Password             string `json:"password" validate:"required,min=6"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+}
+
+func (s *ProfilePasswordRequest) Validate(c *fiber.Ctx) (u *ProfilePasswordRequest, err error) {
+	if err = supports.NewValidator(c).Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
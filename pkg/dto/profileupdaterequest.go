@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProfileUpdateRequest - Generated on 2026-10-18 09:12:05
+//
+// Fields left out of the request are not changed.
+type ProfileUpdateRequest struct {
+	Username    *string `json:"username" validate:"omitempty,min=3,max=50"`
+	Email       *string `json:"email" validate:"omitempty,email,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+}
+
+func (s *ProfileUpdateRequest) Validate(c *fiber.Ctx) (u *ProfileUpdateRequest, err error) {
+	if err = supports.NewValidator(c).Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadCompleteRequest - Generated on 2026-10-18 14:03:10
+//
+// Checksum is the hex SHA-256 of the whole file; when sent, the upload is
+// only stored if it matches.
+type UploadCompleteRequest struct {
+	Checksum string `json:"checksum" validate:"omitempty,len=64,hexadecimal"`
+}
+
+func (s *UploadCompleteRequest) Validate(c *fiber.Ctx) (u *UploadCompleteRequest, err error) {
+	if err = supports.NewValidator(c).Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"github.com/galaplate/core/supports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadCreateRequest - Generated on 2026-10-18 14:02:37
+type UploadCreateRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Size int64  `json:"size" validate:"required,min=1"`
+}
+
+func (s *UploadCreateRequest) Validate(c *fiber.Ctx) (u *UploadCreateRequest, err error) {
+	if err = supports.NewValidator(c).Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
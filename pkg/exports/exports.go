@@ -0,0 +1,253 @@
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Collection is the collection of the files holding the archives
+const Collection = "exports"
+
+// Build collects the data of the user of export into a ZIP archive:
+//
+//	user.json         the account
+//	files.json        the uploaded files and their image variants
+//	files/<id>/<name> the content of each uploaded file
+//	audit_logs.json   the changes made to the account and by the user
+//
+// The archive is stored as a file of the user on the disk of
+// account.export.disk and export is completed, or marked failed when the
+// archive cannot be built.
+func Build(ctx context.Context, export *models.DataExport) error {
+	if err := build(ctx, export); err != nil {
+		expiresAt := expiry()
+		updates := map[string]any{"status": models.DataExportFailed, "expires_at": expiresAt}
+		if updateErr := database.Connect.WithContext(ctx).Model(export).Updates(updates).Error; updateErr != nil {
+			return errors.Join(err, fmt.Errorf("mark export failed: %w", updateErr))
+		}
+		return err
+	}
+	return nil
+}
+
+func build(ctx context.Context, export *models.DataExport) error {
+	db := database.Connect.WithContext(ctx)
+
+	var user models.User
+	if err := db.First(&user, export.UserID).Error; err != nil {
+		return fmt.Errorf("find user: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	if err := writeArchive(ctx, io.MultiWriter(tmp, hash), &user); err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	diskName := configutil.String("account.export.disk", storage.DefaultDisk())
+	disk, err := storage.Open(diskName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	file := &models.File{
+		UserID:     &user.ID,
+		Collection: Collection,
+		Disk:       diskName,
+		Path:       fmt.Sprintf("%s/%s/%s.zip", Collection, now.Format("2006/01"), uuid.NewString()),
+		Name:       fmt.Sprintf("data-export-%s.zip", now.Format("2006-01-02")),
+		MimeType:   "application/zip",
+		Size:       size,
+		Checksum:   hex.EncodeToString(hash.Sum(nil)),
+	}
+	if err := disk.Put(ctx, file.Path, tmp, file.MimeType); err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(file).Error; err != nil {
+			return fmt.Errorf("save archive: %w", err)
+		}
+		return tx.Model(export).Updates(map[string]any{
+			"status":     models.DataExportCompleted,
+			"file_id":    file.ID,
+			"expires_at": expiry(),
+		}).Error
+	})
+	if err != nil {
+		if err := disk.Delete(ctx, file.Path); err != nil {
+			logging.FromContext(ctx).Warn("could not delete orphaned archive", map[string]any{"path": file.Path, "error": err.Error()})
+		}
+		return err
+	}
+	return nil
+}
+
+// auditLog is an audit log with its changes decoded
+type auditLog struct {
+	models.AuditLog
+	Changes json.RawMessage `json:"changes"`
+}
+
+func writeArchive(ctx context.Context, w io.Writer, user *models.User) error {
+	db := database.Connect.WithContext(ctx)
+
+	var files []models.File
+	if err := db.Preload("Variants").Where("user_id = ? AND collection <> ?", user.ID, Collection).Order("id").Find(&files).Error; err != nil {
+		return fmt.Errorf("find files: %w", err)
+	}
+	var logs []models.AuditLog
+	if err := db.Where("(auditable_type = ? AND auditable_id = ?) OR actor_id = ?", "users", fmt.Sprint(user.ID), user.ID).Order("id").Find(&logs).Error; err != nil {
+		return fmt.Errorf("find audit logs: %w", err)
+	}
+	decoded := make([]auditLog, 0, len(logs))
+	for _, log := range logs {
+		changes := json.RawMessage("{}")
+		if json.Valid([]byte(log.Changes)) {
+			changes = json.RawMessage(log.Changes)
+		}
+		decoded = append(decoded, auditLog{AuditLog: log, Changes: changes})
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeJSON(archive, "user.json", user); err != nil {
+		return err
+	}
+	if err := writeJSON(archive, "files.json", files); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := copyFile(ctx, archive, &file); err != nil {
+			return err
+		}
+	}
+	if err := writeJSON(archive, "audit_logs.json", decoded); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	entry, err := create(archive, name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// copyFile adds the content of file under files/<id>/, so that files of
+// the same name do not collide
+func copyFile(ctx context.Context, archive *zip.Writer, file *models.File) error {
+	disk, err := storage.Open(file.Disk)
+	if err != nil {
+		return err
+	}
+	body, err := disk.Get(ctx, file.Path)
+	if errors.Is(err, storage.ErrNotFound) {
+		logging.FromContext(ctx).Warn("file missing from its disk", map[string]any{"file_id": file.ID})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	entry, err := create(archive, fmt.Sprintf("files/%d/%s", file.ID, file.Name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, body); err != nil {
+		return fmt.Errorf("copy file %d: %w", file.ID, err)
+	}
+	return nil
+}
+
+func create(archive *zip.Writer, name string) (io.Writer, error) {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("add %s: %w", name, err)
+	}
+	return entry, nil
+}
+
+// Delete removes export and its archive
+func Delete(ctx context.Context, export *models.DataExport) error {
+	db := database.Connect.WithContext(ctx)
+	if export.FileID != nil {
+		var file models.File
+		err := db.First(&file, *export.FileID).Error
+		if err == nil {
+			disk, err := storage.Open(file.Disk)
+			if err != nil {
+				return err
+			}
+			if err := disk.Delete(ctx, file.Path); err != nil {
+				return err
+			}
+			if err := db.Delete(&file).Error; err != nil {
+				return fmt.Errorf("delete archive: %w", err)
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("find archive: %w", err)
+		}
+	}
+	if err := db.Delete(export).Error; err != nil {
+		return fmt.Errorf("delete export: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes the exports past their expiry, with their archive
+func Purge(ctx context.Context) (int64, error) {
+	var expired []models.DataExport
+	if err := database.Connect.WithContext(ctx).Where("expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+		return 0, fmt.Errorf("find expired exports: %w", err)
+	}
+
+	var purged int64
+	for _, export := range expired {
+		if err := Delete(ctx, &export); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func expiry() time.Time {
+	return time.Now().Add(configutil.Duration("account.export.retention", 7*24*time.Hour))
+}
@@ -0,0 +1,137 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/storage"
+)
+
+func init() {
+	Register("database", 0, Database)
+	Register("migrations", 0, Migrations)
+	Register("storage", 0, Storage)
+	Register("queue", 0, Queue)
+	Register("disk", 0, DiskSpace)
+}
+
+func defaultTimeout() time.Duration {
+	return configutil.Duration("health.timeout", 2*time.Second)
+}
+
+// Database pings the connection pool of database.Connect
+func Database(ctx context.Context) error {
+	if database.Connect == nil {
+		return fmt.Errorf("database is not connected")
+	}
+
+	sqlDB, err := database.Connect.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Migrations fails when a registered migration has not been run
+func Migrations(ctx context.Context) error {
+	if database.Connect == nil {
+		return fmt.Errorf("database is not connected")
+	}
+
+	if !database.Connect.WithContext(ctx).Migrator().HasTable("migrations") {
+		return fmt.Errorf("migrations table is missing")
+	}
+
+	pending, err := database.NewMigrator().GetPendingMigrations()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migration(s), first is %s", len(pending), pending[0].GetName())
+	}
+	return nil
+}
+
+// Storage writes, reads back and removes a probe file on the default disk
+// of config/filesystems.yaml, whatever its driver
+func Storage(ctx context.Context) error {
+	disk, err := storage.Open(storage.DefaultDisk())
+	if err != nil {
+		return err
+	}
+
+	probe := fmt.Sprintf(".health/%d-%d", os.Getpid(), time.Now().UnixNano())
+	if err := disk.Put(ctx, probe, strings.NewReader("ok"), "text/plain"); err != nil {
+		return fmt.Errorf("storage is not writable: %w", err)
+	}
+	// A failed probe is removed even when ctx is done
+	defer disk.Delete(context.WithoutCancel(ctx), probe)
+
+	exists, err := disk.Exists(ctx, probe)
+	if err != nil {
+		return fmt.Errorf("storage is not readable: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("storage lost the probe file %s", probe)
+	}
+	if err := disk.Delete(ctx, probe); err != nil {
+		return fmt.Errorf("storage does not delete files: %w", err)
+	}
+	return nil
+}
+
+// Queue fails when the queue workers of this process have not polled the
+// jobs table for longer than health.queue.max_heartbeat_age, as recorded
+// by jobs.Heartbeat. It is skipped in processes running no worker. The
+// backlog is not checked here, since it is shared by every instance: it is
+// exported as the queue_jobs and queue_oldest_pending_job_age_seconds
+// metrics.
+func Queue(ctx context.Context) error {
+	seen, ok, err := jobs.LastSeen(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSkipped
+	}
+	if seen.IsZero() {
+		// The workers do not start without the jobs table
+		if !database.Connect.WithContext(ctx).Migrator().HasTable("jobs") {
+			return ErrSkipped
+		}
+		return fmt.Errorf("queue workers have not polled the jobs table yet")
+	}
+
+	maxAge := configutil.Duration("health.queue.max_heartbeat_age", time.Minute)
+	if age := time.Since(seen); age > maxAge {
+		return fmt.Errorf("queue workers last polled %s ago, more than %s", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+// DiskSpace fails when the filesystem holding health.disk.path has less
+// than health.disk.min_free_mb megabytes available
+func DiskSpace(ctx context.Context) error {
+	path := configutil.String("health.disk.path", "./storage/logs")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	free, err := freeBytes(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	minFree := uint64(configutil.Int("health.disk.min_free_mb", 100)) * 1024 * 1024
+	if free < minFree {
+		return fmt.Errorf("%d MB free on %s, need %d MB", free/1024/1024, path, minFree/1024/1024)
+	}
+	return nil
+}
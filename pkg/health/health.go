@@ -0,0 +1,172 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFail    = "fail"
+	StatusSkipped = "skipped"
+)
+
+// ErrSkipped can be returned by a check that does not apply to the current
+// setup (for example a local disk check while files are stored on S3). It
+// is reported as skipped and does not fail readiness.
+var ErrSkipped = errors.New("skipped")
+
+// Check reports the health of one dependency. It must return once ctx is
+// done.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check
+type Result struct {
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report aggregates the results of all registered checks
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Healthy reports whether no check failed
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+type entry struct {
+	check   Check
+	timeout time.Duration
+}
+
+// Registry holds the checks run by the readiness probe
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: map[string]entry{}}
+}
+
+// Register adds a check under name, replacing any check with the same
+// name. A zero timeout uses the default from config/health.yaml.
+func (r *Registry) Register(name string, timeout time.Duration, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = entry{check: check, timeout: timeout}
+}
+
+// Unregister removes the check registered under name
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Names returns the registered check names in alphabetical order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run executes all checks concurrently, each bounded by its timeout
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]entry, len(r.checks))
+	for name, e := range r.checks {
+		checks[name] = e
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, e := range checks {
+		wg.Add(1)
+		go func(name string, e entry) {
+			defer wg.Done()
+			result := run(ctx, e)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status == StatusFail {
+				report.Status = StatusFail
+			}
+		}(name, e)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, e entry) Result {
+	timeout := e.timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("check panicked: %v", rec)
+			}
+		}()
+		done <- e.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	result := Result{
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	switch {
+	case errors.Is(err, ErrSkipped):
+		result.Status = StatusSkipped
+	case err != nil:
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// DefaultRegistry holds the built-in checks and the ones added with Register
+var DefaultRegistry = NewRegistry()
+
+// Register adds a check to DefaultRegistry
+func Register(name string, timeout time.Duration, check Check) {
+	DefaultRegistry.Register(name, timeout, check)
+}
+
+// Run executes the checks of DefaultRegistry
+func Run(ctx context.Context) Report {
+	return DefaultRegistry.Run(ctx)
+}
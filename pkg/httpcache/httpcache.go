@@ -0,0 +1,240 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheHeader tells whether a response came from the server-side cache
+const CacheHeader = "X-Cache"
+
+const tagsLocal = "httpcache_tags"
+
+// Policy configures the caching of the routes using it
+type Policy struct {
+	Enabled bool
+	// CacheControl is sent as is in the Cache-Control header
+	CacheControl string
+	// WeakETag sends W/"..." ETags, for responses that are equivalent
+	// rather than byte for byte identical
+	WeakETag bool
+	// TTL is how long responses are kept in the server-side cache; zero
+	// only adds the ETag and Cache-Control headers
+	TTL time.Duration
+}
+
+// LoadPolicy reads the policy called name from config/cache.yaml. Keys
+// missing from the policy fall back to the values under cache.default.
+func LoadPolicy(name string) Policy {
+	def := loadPolicy("cache.default", Policy{
+		Enabled:      configutil.Bool("cache.enabled", true),
+		CacheControl: "private, no-cache",
+	})
+	return loadPolicy("cache.policies."+name, def)
+}
+
+func loadPolicy(key string, def Policy) Policy {
+	return Policy{
+		Enabled:      def.Enabled && configutil.Bool(key+".enabled", true),
+		CacheControl: configutil.String(key+".cache_control", def.CacheControl),
+		WeakETag:     configutil.String(key+".etag", etagKind(def.WeakETag)) == "weak",
+		TTL:          configutil.Duration(key+".ttl", def.TTL),
+	}
+}
+
+func etagKind(weak bool) string {
+	if weak {
+		return "weak"
+	}
+	return "strong"
+}
+
+var (
+	mu    sync.RWMutex
+	store Store = NewMemoryStore(1000)
+)
+
+// Init replaces the server-side cache with an empty store sized from the
+// config. It is called by the router on startup.
+func Init() {
+	mu.Lock()
+	defer mu.Unlock()
+	store = NewMemoryStore(configutil.Int("cache.max_entries", 1000))
+}
+
+func currentStore() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// Tag attaches tags to the response being cached so that it can be dropped
+// later with Invalidate:
+//
+//	httpcache.Tag(c, "test:"+id)
+//	...
+//	httpcache.Invalidate("test:" + id)
+func Tag(c *fiber.Ctx, tags ...string) {
+	existing, _ := c.Locals(tagsLocal).([]string)
+	c.Locals(tagsLocal, append(existing, tags...))
+}
+
+// Invalidate drops the cached responses carrying any of tags and returns
+// how many were dropped
+func Invalidate(tags ...string) int {
+	return currentStore().Invalidate(tags...)
+}
+
+// InvalidateRoute drops the cached responses of the route registered under
+// name, for every user
+func InvalidateRoute(name string) int {
+	return Invalidate(routeTag(name))
+}
+
+// InvalidateUser drops the cached responses served to the user, e.g. after
+// the user changed their profile
+func InvalidateUser(userID any) int {
+	return Invalidate(userTag(userID))
+}
+
+// Flush empties the server-side cache
+func Flush() {
+	currentStore().Flush()
+}
+
+type HTTPCacheMiddleware struct{}
+
+// Handler adds ETag and Cache-Control headers to the 200 responses of GET
+// and HEAD requests, answers If-None-Match with 304 Not Modified, and keeps
+// responses in the server-side cache for policy.TTL. Responses are cached
+// per path, query and user, so place the middleware after JWTAuth on
+// protected routes.
+func (m *HTTPCacheMiddleware) Handler(policy Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !policy.Enabled || (c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead) {
+			return c.Next()
+		}
+
+		key := cacheKey(c)
+		if policy.TTL > 0 {
+			if entry, ok := currentStore().Get(key); ok {
+				c.Set(CacheHeader, "HIT")
+				return send(c, entry)
+			}
+		}
+
+		// The error handler renders the response here, rather than after
+		// the middleware returns, so the response can be inspected
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		entry := &Entry{
+			Status:       fiber.StatusOK,
+			Body:         body,
+			ContentType:  string(c.Response().Header.ContentType()),
+			ETag:         c.GetRespHeader(fiber.HeaderETag),
+			CacheControl: policy.CacheControl,
+		}
+		if entry.ETag == "" {
+			entry.ETag = ETag(body, policy.WeakETag)
+		}
+
+		if policy.TTL > 0 {
+			tags, _ := c.Locals(tagsLocal).([]string)
+			entry.Tags = append(tags, routeTag(c.Route().Name))
+			if userID := c.Locals("user_id"); userID != nil {
+				entry.Tags = append(entry.Tags, userTag(userID))
+			}
+			entry.ExpiresAt = time.Now().Add(policy.TTL)
+			currentStore().Set(key, entry)
+			c.Set(CacheHeader, "MISS")
+		}
+		return send(c, entry)
+	}
+}
+
+func send(c *fiber.Ctx, entry *Entry) error {
+	c.Set(fiber.HeaderETag, entry.ETag)
+	if entry.CacheControl != "" {
+		c.Set(fiber.HeaderCacheControl, entry.CacheControl)
+	}
+	if c.Locals("user_id") != nil {
+		c.Vary(fiber.HeaderAuthorization)
+	}
+
+	if NotModified(c.Get(fiber.HeaderIfNoneMatch), entry.ETag) {
+		c.Response().ResetBody()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, entry.ContentType)
+	return c.Status(entry.Status).Send(entry.Body)
+}
+
+// ETag returns the entity tag of body
+func ETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// NotModified reports whether an If-None-Match header matches etag, using
+// the weak comparison RFC 9110 prescribes for If-None-Match
+func NotModified(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if opaqueTag(candidate) == opaqueTag(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+func opaqueTag(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+}
+
+func cacheKey(c *fiber.Ctx) string {
+	caller := "anonymous"
+	if userID := c.Locals("user_id"); userID != nil {
+		caller = userTag(userID)
+	}
+	return c.Path() + "?" + string(c.Request().URI().QueryString()) + " " + caller
+}
+
+func routeTag(name string) string {
+	return "route:" + name
+}
+
+func userTag(userID any) string {
+	return fmt.Sprintf("user:%v", userID)
+}
+
+var HTTPCacheMiddlewareInstance = &HTTPCacheMiddleware{}
+
+// Cache applies the named policy of config/cache.yaml
+func Cache(name string) fiber.Handler {
+	return HTTPCacheMiddlewareInstance.Handler(LoadPolicy(name))
+}
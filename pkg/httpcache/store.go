@@ -0,0 +1,104 @@
+package httpcache
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response
+type Entry struct {
+	Status       int
+	Body         []byte
+	ContentType  string
+	ETag         string
+	CacheControl string
+	Tags         []string
+	ExpiresAt    time.Time
+}
+
+// Store keeps cached responses
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	// Invalidate drops the entries carrying any of tags
+	Invalidate(tags ...string) int
+	// Flush drops every entry
+	Flush()
+}
+
+// MemoryStore keeps responses in process memory, up to maxEntries. Each
+// instance of the application caches on its own, so invalidations only
+// reach the instance they run in.
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]*Entry
+	maxEntries int
+}
+
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{entries: map[string]*Entry{}, maxEntries: maxEntries}
+}
+
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *MemoryStore) Set(key string, entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.evict()
+	}
+	s.entries[key] = entry
+}
+
+func (s *MemoryStore) Invalidate(tags ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := 0
+	for key, entry := range s.entries {
+		if slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(entry.Tags, tag) }) {
+			delete(s.entries, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+func (s *MemoryStore) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = map[string]*Entry{}
+}
+
+// evict drops the expired entries, or the one expiring first when none is
+func (s *MemoryStore) evict() {
+	now := time.Now()
+	var soonest string
+	for key, entry := range s.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(s.entries, key)
+			continue
+		}
+		if soonest == "" || entry.ExpiresAt.Before(s.entries[soonest].ExpiresAt) {
+			soonest = key
+		}
+	}
+	if len(s.entries) >= s.maxEntries && soonest != "" {
+		delete(s.entries, soonest)
+	}
+}
@@ -0,0 +1,267 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReplayedHeader is set on responses replayed from a stored key
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Error codes of the responses sent for misused keys
+const (
+	CodeKeyInvalid  = "idempotency_key_invalid"
+	CodeKeyMismatch = "idempotency_key_mismatch"
+	CodeInProgress  = "idempotency_key_in_progress"
+)
+
+const maxKeyLength = 255
+
+// Response headers stored along with the body and replayed
+var storedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation}
+
+type Config struct {
+	Enabled     bool
+	Header      string
+	TTL         time.Duration
+	LockTimeout time.Duration
+}
+
+// LoadConfig reads config/idempotency.yaml
+func LoadConfig() Config {
+	return Config{
+		Enabled:     configutil.Bool("idempotency.enabled", true),
+		Header:      configutil.String("idempotency.header", "Idempotency-Key"),
+		TTL:         configutil.Duration("idempotency.ttl", 24*time.Hour),
+		LockTimeout: configutil.Duration("idempotency.lock_timeout", 30*time.Second),
+	}
+}
+
+type IdempotencyMiddleware struct{}
+
+// Handler makes a route safe to retry. The first request carrying a given
+// Idempotency-Key runs normally and its response is stored in the
+// idempotency_keys table, the body encrypted with app.key; retries with
+// the same key get that response back with Idempotent-Replayed: true. Reusing a key with a different request is
+// answered with 422, and a retry arriving while the first request is still
+// running with 409.
+//
+// Keys are scoped to the route and the authenticated user, so place the
+// middleware after JWTAuth on protected routes. 5xx responses are not
+// stored, the key is released so the request can be retried.
+func (m *IdempotencyMiddleware) Handler(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(cfg.Header)
+		if !cfg.Enabled || key == "" {
+			return c.Next()
+		}
+		if len(key) > maxKeyLength {
+			return apperror.New(fiber.StatusBadRequest, CodeKeyInvalid, fmt.Sprintf("%s must be at most %d characters", cfg.Header, maxKeyLength))
+		}
+
+		db := database.Connect.WithContext(c.UserContext())
+		record, acquired, err := acquire(db, cfg, scope(c), key, fingerprint(c))
+		if err != nil {
+			return apperror.Internal(fmt.Errorf("acquire idempotency key: %w", err))
+		}
+
+		if !acquired {
+			switch {
+			case record.Fingerprint != fingerprint(c):
+				return apperror.New(fiber.StatusUnprocessableEntity, CodeKeyMismatch, cfg.Header+" was already used for a different request")
+			case record.State == models.IdempotencyCompleted:
+				return replay(c, record)
+			default:
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter(record)))
+				return apperror.New(fiber.StatusConflict, CodeInProgress, "A request with this "+cfg.Header+" is still being processed")
+			}
+		}
+
+		// The error handler renders the response here, rather than after
+		// the middleware returns, so the response can be stored
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		if err := complete(db, record, c); err != nil {
+			logging.FromCtx(c).Error("IdempotencyMiddleware@Handler", map[string]any{
+				"message": "failed to store the response of an idempotent request",
+				"error":   err.Error(),
+			})
+		}
+		return nil
+	}
+}
+
+// acquire creates the key, or takes over a key whose lock expired. It
+// returns the existing record and false when the key is held by another
+// request or already completed.
+func acquire(db *gorm.DB, cfg Config, scope, key, fingerprint string) (*models.IdempotencyKey, bool, error) {
+	now := time.Now()
+	lockedUntil := now.Add(cfg.LockTimeout)
+	record := &models.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		State:       models.IdempotencyProcessing,
+		LockedUntil: &lockedUntil,
+		ExpiresAt:   now.Add(cfg.TTL),
+	}
+
+	// The unique index on (scope, idempotency_key) lets exactly one of
+	// concurrent duplicates insert the row
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("scope = ? AND idempotency_key = ?", scope, key).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released by a failed request in the meantime
+			return acquire(db, cfg, scope, key, fingerprint)
+		}
+		return nil, false, err
+	}
+
+	expired := existing.ExpiresAt.Before(now)
+	abandoned := existing.State == models.IdempotencyProcessing && existing.LockedUntil != nil && existing.LockedUntil.Before(now)
+	if !expired && !abandoned {
+		return &existing, false, nil
+	}
+
+	// Only one retry may take the key over: once it did, the key is neither
+	// expired nor abandoned anymore and the condition fails for the others
+	result = db.Model(&models.IdempotencyKey{}).
+		Where("id = ? AND (expires_at < ? OR (state = ? AND locked_until < ?))", existing.ID, now, models.IdempotencyProcessing, now).
+		Updates(map[string]any{
+			"fingerprint":      fingerprint,
+			"state":            models.IdempotencyProcessing,
+			"response_status":  0,
+			"response_headers": "",
+			"response_body":    nil,
+			"locked_until":     lockedUntil,
+			"expires_at":       record.ExpiresAt,
+		})
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		existing.LockedUntil = &lockedUntil
+		existing.State = models.IdempotencyProcessing
+		return &existing, false, nil
+	}
+
+	existing.Fingerprint = fingerprint
+	return &existing, true, nil
+}
+
+// complete stores the response of the request holding record, or releases
+// the key when the response is worth retrying
+func complete(db *gorm.DB, record *models.IdempotencyKey, c *fiber.Ctx) error {
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError || status == fiber.StatusTooManyRequests || status == fiber.StatusRequestTimeout {
+		return db.Delete(&models.IdempotencyKey{}, record.ID).Error
+	}
+
+	headers := map[string]string{}
+	for _, name := range storedHeaders {
+		if value := c.Response().Header.Peek(name); len(value) > 0 {
+			headers[name] = string(value)
+		}
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	body, err := seal(c.Response().Body())
+	if err != nil {
+		return fmt.Errorf("encrypt response body: %w", err)
+	}
+
+	return db.Model(&models.IdempotencyKey{}).Where("id = ?", record.ID).Updates(map[string]any{
+		"state":            models.IdempotencyCompleted,
+		"response_status":  status,
+		"response_headers": string(encoded),
+		"response_body":    body,
+		"locked_until":     nil,
+	}).Error
+}
+
+func replay(c *fiber.Ctx, record *models.IdempotencyKey) error {
+	var headers map[string]string
+	if record.ResponseHeaders != "" {
+		if err := json.Unmarshal([]byte(record.ResponseHeaders), &headers); err != nil {
+			return apperror.Internal(fmt.Errorf("decode stored headers: %w", err))
+		}
+	}
+	for name, value := range headers {
+		c.Set(name, value)
+	}
+	body, err := open(record.ResponseBody)
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("decrypt stored body: %w", err))
+	}
+	c.Set(ReplayedHeader, "true")
+	return c.Status(record.ResponseStatus).Send(body)
+}
+
+// retryAfter is the number of seconds until the lock of record expires
+func retryAfter(record *models.IdempotencyKey) int {
+	if record.LockedUntil == nil {
+		return 1
+	}
+	return max(int(math.Ceil(time.Until(*record.LockedUntil).Seconds())), 1)
+}
+
+// scope keeps keys of different routes and users apart
+func scope(c *fiber.Ctx) string {
+	caller := "anonymous"
+	if userID := c.Locals("user_id"); userID != nil {
+		caller = fmt.Sprintf("user:%v", userID)
+	}
+	return c.Method() + " " + c.Route().Path + " " + caller
+}
+
+// fingerprint identifies the request a key was first used with
+func fingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method() + " " + c.Path() + "?" + string(c.Request().URI().QueryString()) + "\n"))
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Purge deletes the expired keys and returns how many were deleted
+func Purge(ctx context.Context) (int64, error) {
+	result := database.Connect.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+var IdempotencyMiddlewareInstance = &IdempotencyMiddleware{}
+
+func Middleware() fiber.Handler {
+	return IdempotencyMiddlewareInstance.Handler(LoadConfig())
+}
@@ -0,0 +1,55 @@
+package idempotency
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+)
+
+// Stored responses may hold secrets, such as the token answered by
+// /register, so their bodies are encrypted at rest with AES-GCM under a
+// key derived from app.key. Rotating app.key makes the stored responses
+// unreadable: retries of those keys get a 500 until they expire.
+
+func gcm() (cipher.AEAD, error) {
+	secret := configutil.String("app.key", "")
+	if secret == "" {
+		return nil, errors.New("idempotency: app.key is not set, set APP_SECRET")
+	}
+	key := sha256.Sum256([]byte("idempotency\n" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts body, prefixed with its nonce
+func seal(body []byte) ([]byte, error) {
+	aead, err := gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(body)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, body, nil), nil
+}
+
+// open decrypts a body encrypted by seal
+func open(sealed []byte) ([]byte, error) {
+	aead, err := gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("idempotency: stored body is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
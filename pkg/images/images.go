@@ -0,0 +1,286 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	// GIFs are decoded to their first frame and resized like stills
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"golang.org/x/image/draw"
+	"gorm.io/gorm/clause"
+)
+
+// Resize modes of a Variant
+const (
+	// ModeFit scales the image down to fit within the variant's size
+	ModeFit = "fit"
+	// ModeFill crops the image to the aspect ratio of the variant's size,
+	// then scales it down to that size
+	ModeFill = "fill"
+)
+
+// Output formats of a Variant
+const (
+	FormatOriginal = "original"
+	FormatJPEG     = "jpeg"
+	FormatPNG      = "png"
+)
+
+// Variant is a variant of filesystems.images.variants in
+// config/filesystems.yaml. A zero Width or Height leaves that side
+// unconstrained with ModeFit.
+type Variant struct {
+	Name    string
+	Width   int
+	Height  int
+	Mode    string
+	Format  string
+	Quality int
+}
+
+// Enabled reports whether variants are generated for files of mimeType
+func Enabled(mimeType string) bool {
+	if !configutil.Bool("filesystems.images.enabled", true) {
+		return false
+	}
+	types := configutil.Strings("filesystems.images.types", []string{"image/jpeg", "image/png", "image/gif"})
+	return slices.Contains(types, mimeType) && len(configutil.Map("filesystems.images.variants")) > 0
+}
+
+// Variants returns the configured variants sorted by name, or an error
+// naming the first invalid one
+func Variants() ([]Variant, error) {
+	names := make([]string, 0)
+	for name := range configutil.Map("filesystems.images.variants") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	quality := configutil.Int("filesystems.images.quality", 85)
+	variants := make([]Variant, 0, len(names))
+	for _, name := range names {
+		key := "filesystems.images.variants." + name
+		variant := Variant{
+			Name:    name,
+			Width:   configutil.Int(key+".width", 0),
+			Height:  configutil.Int(key+".height", 0),
+			Mode:    configutil.String(key+".mode", ModeFit),
+			Format:  configutil.String(key+".format", FormatOriginal),
+			Quality: configutil.Int(key+".quality", quality),
+		}
+		if err := variant.validate(); err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+func (v Variant) validate() error {
+	switch {
+	case v.Width < 0 || v.Height < 0 || v.Width+v.Height == 0:
+		return fmt.Errorf("images: variant %q needs a width or a height", v.Name)
+	case v.Mode != ModeFit && v.Mode != ModeFill:
+		return fmt.Errorf("images: mode %q of variant %q is not fit or fill", v.Mode, v.Name)
+	case v.Mode == ModeFill && (v.Width == 0 || v.Height == 0):
+		return fmt.Errorf("images: variant %q needs a width and a height to fill", v.Name)
+	case v.Format == "webp":
+		return fmt.Errorf("images: variant %q: webp has no pure Go encoder, use jpeg or png", v.Name)
+	case v.Format != FormatOriginal && v.Format != FormatJPEG && v.Format != FormatPNG:
+		return fmt.Errorf("images: format %q of variant %q is not original, jpeg or png", v.Format, v.Name)
+	}
+	return nil
+}
+
+// Generate creates the configured variants of file next to it on its disk
+// and records them, replacing the variants generated before. Re-encoding
+// the image strips its EXIF metadata once its orientation is applied.
+func Generate(ctx context.Context, file *models.File) ([]models.FileVariant, error) {
+	variants, err := Variants()
+	if err != nil {
+		return nil, err
+	}
+	disk, err := storage.Open(file.Disk)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := decode(ctx, disk, file)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := make([]models.FileVariant, 0, len(variants))
+	for _, variant := range variants {
+		record, err := store(ctx, disk, file, src, variant)
+		if err != nil {
+			return nil, err
+		}
+		generated = append(generated, record)
+	}
+
+	if len(generated) > 0 {
+		err = database.Connect.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "file_id"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"path", "mime_type", "width", "height", "size", "updated_at"}),
+		}).Create(&generated).Error
+		if err != nil {
+			return nil, fmt.Errorf("save variants: %w", err)
+		}
+	}
+	return generated, nil
+}
+
+// decode reads the image of file, turned upright according to its EXIF
+// orientation
+func decode(ctx context.Context, disk storage.Disk, file *models.File) (image.Image, error) {
+	body, err := disk.Get(ctx, file.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("images: read %s: %w", file.Path, err)
+	}
+
+	// Refuse images that would take too much memory once decoded
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("images: decode %s: %w", file.Path, err)
+	}
+	if maxPixels := configutil.Int("filesystems.images.max_pixels", 40_000_000); cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("images: %s has more than %d pixels", file.Path, maxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("images: decode %s: %w", file.Path, err)
+	}
+	return orient(img, orientation(content)), nil
+}
+
+func store(ctx context.Context, disk storage.Disk, file *models.File, src image.Image, variant Variant) (models.FileVariant, error) {
+	format := variant.Format
+	if format == FormatOriginal {
+		format = FormatPNG
+		if file.MimeType == "image/jpeg" {
+			format = FormatJPEG
+		}
+	}
+
+	img := resize(src, variant, format == FormatJPEG)
+	var buf bytes.Buffer
+	var err error
+	if format == FormatJPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: variant.Quality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return models.FileVariant{}, fmt.Errorf("images: encode variant %q: %w", variant.Name, err)
+	}
+
+	record := models.FileVariant{
+		FileID:   file.ID,
+		Name:     variant.Name,
+		Path:     VariantPath(file.Path, variant.Name, format),
+		MimeType: "image/" + format,
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+		Size:     int64(buf.Len()),
+	}
+	if err := disk.Put(ctx, record.Path, bytes.NewReader(buf.Bytes()), record.MimeType); err != nil {
+		return models.FileVariant{}, err
+	}
+	return record, nil
+}
+
+// VariantPath is where the variant name of the file at original is stored,
+// e.g. files/2026/10/<uuid>-thumb.jpg
+func VariantPath(original, name, format string) string {
+	ext := ".png"
+	if format == FormatJPEG {
+		ext = ".jpg"
+	}
+	return strings.TrimSuffix(original, path.Ext(original)) + "-" + name + ext
+}
+
+// resize scales src down as variant asks, never up. Images encoded as JPEG
+// are drawn over white, since JPEG has no transparency.
+func resize(src image.Image, variant Variant, opaque bool) image.Image {
+	bounds := src.Bounds()
+	crop := bounds
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if variant.Mode == ModeFill {
+		// Largest centered rectangle of the variant's aspect ratio, at least
+		// a pixel wide and high for very narrow or flat images
+		cropW, cropH := width, max(1, width*variant.Height/variant.Width)
+		if cropH > height {
+			cropW, cropH = max(1, height*variant.Width/variant.Height), height
+		}
+		x := bounds.Min.X + (width-cropW)/2
+		y := bounds.Min.Y + (height-cropH)/2
+		crop = image.Rect(x, y, x+cropW, y+cropH)
+		width, height = min(cropW, variant.Width), min(cropH, variant.Height)
+	} else {
+		scale := 1.0
+		if variant.Width > 0 && width > variant.Width {
+			scale = float64(variant.Width) / float64(width)
+		}
+		if variant.Height > 0 && float64(height)*scale > float64(variant.Height) {
+			scale = float64(variant.Height) / float64(height)
+		}
+		width = max(1, int(float64(width)*scale+0.5))
+		height = max(1, int(float64(height)*scale+0.5))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if opaque {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	return dst
+}
+
+// Delete removes the variants of file from its disk and from the
+// file_variants table
+func Delete(ctx context.Context, file *models.File) error {
+	var variants []models.FileVariant
+	db := database.Connect.WithContext(ctx)
+	if err := db.Where("file_id = ?", file.ID).Find(&variants).Error; err != nil {
+		return fmt.Errorf("find variants: %w", err)
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+
+	disk, err := storage.Open(file.Disk)
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if err := disk.Delete(ctx, variant.Path); err != nil {
+			return err
+		}
+	}
+	if err := db.Where("file_id = ?", file.ID).Delete(&models.FileVariant{}).Error; err != nil {
+		return fmt.Errorf("delete variants: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/galaplate/core/queue"
+	"github.com/galaplate/galaplate/pkg/requestctx"
+	"github.com/galaplate/galaplate/pkg/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Payload is the envelope stored in jobs.payload by Dispatch. Meta carries
+// values propagated from the dispatching request (request ID, trace
+// context, ...) and
+// Params holds the arguments given to Dispatch.
+type Payload struct {
+	Meta   map[string]string `json:"meta,omitempty"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// Dispatch queues job like queue.Dispatch does, but wraps the params in a
+// Payload that records metadata taken from ctx
+func Dispatch(ctx context.Context, job queue.Job, params ...any) error {
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		raw, err := json.Marshal(param)
+		if err != nil {
+			return fmt.Errorf("failed to encode job params: %w", err)
+		}
+		rawParams = append(rawParams, raw)
+	}
+
+	_, err := queue.SaveJobToDB(queue.JobEnqueueRequest{
+		Type: job.Type(),
+		Payload: Payload{
+			Meta:   metadataFromContext(ctx),
+			Params: rawParams,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save job to DB: %w", err)
+	}
+
+	return nil
+}
+
+// Decode unpacks a payload written by Dispatch. The returned context carries
+// the propagated metadata so handlers can log with logging.FromContext, and
+// spans started from it continue the trace of the dispatching request.
+// Payloads written by queue.Dispatch (a bare JSON array) are accepted too.
+func Decode(payload json.RawMessage) (context.Context, Payload, error) {
+	var decoded Payload
+
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		var params []json.RawMessage
+		if arrErr := json.Unmarshal(payload, &params); arrErr != nil {
+			return context.Background(), decoded, fmt.Errorf("failed to decode job payload: %w", err)
+		}
+		decoded.Params = params
+	}
+
+	return contextFromMetadata(context.Background(), decoded.Meta), decoded, nil
+}
+
+// Handle decodes payload and runs handle within a consumer span named
+// job.<type>, a child of the span that dispatched the job. Queries run with
+// the given context become children of the job span rather than of the
+// request, which has usually ended by then. The error returned by handle
+// is recorded on the span.
+//
+//	func (j MyJob) Handle(payload json.RawMessage) error {
+//		return jobs.Handle(j.Type(), payload, func(ctx context.Context, decoded jobs.Payload) error {
+//			...
+//		})
+//	}
+func Handle(jobType string, payload json.RawMessage, handle func(context.Context, Payload) error) error {
+	ctx, decoded, err := Decode(payload)
+
+	ctx, span := telemetry.Tracer().Start(ctx, "job."+jobType,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingOperationName(jobType),
+		),
+	)
+	defer span.End()
+
+	if err == nil {
+		err = handle(ctx, decoded)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// Param decodes the param at index into v
+func (p Payload) Param(index int, v any) error {
+	if index >= len(p.Params) {
+		return fmt.Errorf("job payload has no param at index %d", index)
+	}
+	return json.Unmarshal(p.Params[index], v)
+}
+
+func metadataFromContext(ctx context.Context) map[string]string {
+	meta := map[string]string{}
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		meta["request_id"] = requestID
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(meta))
+	return meta
+}
+
+func contextFromMetadata(ctx context.Context, meta map[string]string) context.Context {
+	if requestID := meta["request_id"]; requestID != "" {
+		ctx = requestctx.WithRequestID(ctx, requestID)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(meta))
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/queue"
+	"github.com/galaplate/galaplate/pkg/exports"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/models"
+	"gorm.io/gorm"
+)
+
+// ExportUserData builds the archive of a data export requested with POST
+// /api/account/export. Its param is the ID of the export. It is not
+// retried: a failed export is marked so and the user may request another.
+type ExportUserData struct{}
+
+// MaxAttempts returns the number of times this job will be retried on failure
+func (j ExportUserData) MaxAttempts() int {
+	return 1
+}
+
+// RetryAfter returns the duration to wait before retrying a failed job
+func (j ExportUserData) RetryAfter() time.Duration {
+	return 0
+}
+
+// Type returns the job type identifier
+func (ExportUserData) Type() string {
+	return "exportuserdata"
+}
+
+// Handle processes the job with the given payload
+func (j ExportUserData) Handle(payload json.RawMessage) error {
+	return Handle(j.Type(), payload, func(ctx context.Context, decoded Payload) error {
+		var exportID uint
+		if err := decoded.Param(0, &exportID); err != nil {
+			return err
+		}
+
+		var export models.DataExport
+		if err := database.Connect.WithContext(ctx).First(&export, exportID).Error; err != nil {
+			// The account was purged before its turn came
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("find export: %w", err)
+		}
+		if export.Status != models.DataExportPending {
+			return nil
+		}
+
+		if err := exports.Build(ctx, &export); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("data export built", map[string]any{
+			"export_id": export.ID,
+			"user_id":   export.UserID,
+		})
+		return nil
+	})
+}
+
+// init registers the job in the queue system
+func init() {
+	queue.RegisterJob(ExportUserData{})
+}
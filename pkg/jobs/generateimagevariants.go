@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/queue"
+	"github.com/galaplate/galaplate/pkg/images"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/models"
+	"gorm.io/gorm"
+)
+
+// GenerateImageVariants generates the variants of filesystems.images in
+// config/filesystems.yaml for an uploaded image. Its param is the ID of
+// the file.
+type GenerateImageVariants struct{}
+
+// MaxAttempts returns the number of times this job will be retried on failure
+func (j GenerateImageVariants) MaxAttempts() int {
+	return 3
+}
+
+// RetryAfter returns the duration to wait before retrying a failed job
+func (j GenerateImageVariants) RetryAfter() time.Duration {
+	return 1 * time.Minute
+}
+
+// Type returns the job type identifier
+func (GenerateImageVariants) Type() string {
+	return "generateimagevariants"
+}
+
+// Handle processes the job with the given payload
+func (j GenerateImageVariants) Handle(payload json.RawMessage) error {
+	return Handle(j.Type(), payload, func(ctx context.Context, decoded Payload) error {
+		var fileID uint
+		if err := decoded.Param(0, &fileID); err != nil {
+			return err
+		}
+
+		var file models.File
+		if err := database.Connect.WithContext(ctx).First(&file, fileID).Error; err != nil {
+			// The file was deleted before its turn came
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("find file: %w", err)
+		}
+
+		variants, err := images.Generate(ctx, &file)
+		if err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("image variants generated", map[string]any{
+			"file_id":  file.ID,
+			"variants": len(variants),
+		})
+		return nil
+	})
+}
+
+// init registers the job in the queue system
+func init() {
+	queue.RegisterJob(GenerateImageVariants{})
+}
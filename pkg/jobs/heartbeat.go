@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/galaplate/core/database"
+	coremodels "github.com/galaplate/core/models"
+	"github.com/galaplate/galaplate/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Heartbeat records in the queue_workers table when the queue workers of
+// this process last polled the jobs table, one row per process. Register
+// it on the connection the workers use before starting them:
+//
+//	heartbeat := jobs.NewHeartbeat(10 * time.Second)
+//	database.Connect.Use(heartbeat)
+//
+// The workers of github.com/galaplate/core/queue poll for the next due
+// pending job about once a second while idle, and after every job while
+// busy; Heartbeat follows those queries of the jobs table and writes at
+// most once per interval. Workers that all stay busy longer than the
+// health threshold stop beating too.
+type Heartbeat struct {
+	worker   string
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+var current atomic.Pointer[Heartbeat]
+
+// NewHeartbeat returns the heartbeat of this process, named after its host
+// and PID
+func NewHeartbeat(interval time.Duration) *Heartbeat {
+	host, _ := os.Hostname()
+	return &Heartbeat{
+		worker:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		interval: interval,
+	}
+}
+
+func (h *Heartbeat) Name() string {
+	return "jobs:heartbeat"
+}
+
+// Initialize registers the query callback and makes h the heartbeat read
+// by LastSeen
+func (h *Heartbeat) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().After("gorm:query").Register("jobs:heartbeat", h.beat); err != nil {
+		return err
+	}
+	current.Store(h)
+	return nil
+}
+
+// Worker is the name of the row of this process
+func (h *Heartbeat) Worker() string {
+	return h.worker
+}
+
+func (h *Heartbeat) beat(db *gorm.DB) {
+	if db.Statement.Table != "jobs" {
+		return
+	}
+	// The poll loads a single job, the oldest due pending one
+	sql := db.Statement.SQL.String()
+	if _, ok := db.Statement.Dest.(*coremodels.Job); !ok || !strings.Contains(sql, "available_at <=") || !strings.Contains(sql, "ORDER BY created_at") {
+		return
+	}
+	// An idle queue answers the poll with no row
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return
+	}
+
+	now := time.Now()
+	h.mu.Lock()
+	if now.Sub(h.last) < h.interval {
+		h.mu.Unlock()
+		return
+	}
+	h.last = now
+	h.mu.Unlock()
+
+	row := models.QueueWorker{Worker: h.worker, LastSeenAt: now, CreatedAt: now}
+	db.Session(&gorm.Session{NewDB: true}).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "worker"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(&row)
+}
+
+// Stop removes the row of this process, once its workers are stopped
+func (h *Heartbeat) Stop(ctx context.Context) error {
+	current.CompareAndSwap(h, nil)
+	return database.Connect.WithContext(ctx).Where("worker = ?", h.worker).Delete(&models.QueueWorker{}).Error
+}
+
+// LastSeen returns when the workers of this process last polled the jobs
+// table. ok is false when this process runs no worker, and seen is zero
+// when they have not polled yet.
+func LastSeen(ctx context.Context) (seen time.Time, ok bool, err error) {
+	h := current.Load()
+	if h == nil {
+		return time.Time{}, false, nil
+	}
+
+	var row models.QueueWorker
+	err = database.Connect.WithContext(ctx).Where("worker = ?", h.worker).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, true, nil
+	}
+	if err != nil {
+		return time.Time{}, true, err
+	}
+	return row.LastSeenAt, true, nil
+}
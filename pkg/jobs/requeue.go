@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/models"
+	"gorm.io/gorm"
+)
+
+// Claims records the jobs claimed by the queue workers of this process, so
+// that only those are requeued when the workers are interrupted. Register
+// it on the connection the workers use before starting them:
+//
+//	claims := jobs.NewClaims()
+//	database.Connect.Use(claims)
+//
+// The workers of github.com/galaplate/core/queue claim a job by updating
+// its state to started and release it by updating it to finished, failed
+// or pending; Claims follows those updates of the jobs table.
+type Claims struct {
+	mu  sync.Mutex
+	ids map[uint]struct{}
+}
+
+func NewClaims() *Claims {
+	return &Claims{ids: map[uint]struct{}{}}
+}
+
+func (c *Claims) Name() string {
+	return "jobs:claims"
+}
+
+func (c *Claims) Initialize(db *gorm.DB) error {
+	return db.Callback().Update().After("gorm:update").Register("jobs:claims", c.track)
+}
+
+func (c *Claims) track(db *gorm.DB) {
+	if db.Error != nil || db.Statement.RowsAffected == 0 || db.Statement.Table != "jobs" {
+		return
+	}
+	job, ok := db.Statement.Model.(*models.Job)
+	if !ok || job.ID == 0 {
+		return
+	}
+
+	var state models.JobState
+	switch dest := db.Statement.Dest.(type) {
+	case models.Job:
+		state = dest.State
+	case *models.Job:
+		state = dest.State
+	case map[string]any:
+		state, _ = dest["state"].(models.JobState)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch state {
+	case models.JobStarted:
+		c.ids[job.ID] = struct{}{}
+	case models.JobFinished, models.JobFailed, models.JobPending:
+		delete(c.ids, job.ID)
+	}
+}
+
+// Requeue puts the jobs claimed by this process and still started back to
+// pending. It is used on shutdown when workers could not finish in time, so
+// the jobs they were running are picked up again by the next process; the
+// jobs other instances are running are left alone. The interrupted run
+// still counts as an attempt.
+func (c *Claims) Requeue() (int64, error) {
+	c.mu.Lock()
+	ids := make([]uint, 0, len(c.ids))
+	for id := range c.ids {
+		ids = append(ids, id)
+	}
+	c.mu.Unlock()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := database.Connect.Model(&models.Job{}).
+		Where("id IN ? AND state = ?", ids, models.JobStarted).
+		Updates(map[string]any{
+			"state":        models.JobPending,
+			"available_at": time.Now(),
+			"started_at":   nil,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,149 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/galaplate/core/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Hook is run while the application starts or stops. A shutdown hook must
+// return once ctx is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Manager runs the registered hooks around the life of the HTTP server
+type Manager struct {
+	mu       sync.Mutex
+	starting []namedHook
+	stopping []namedHook
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// OnStart registers a hook that runs, in registration order, before the
+// server starts listening. An error aborts the start.
+func (m *Manager) OnStart(name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.starting = append(m.starting, namedHook{name: name, hook: hook})
+}
+
+// OnShutdown registers a hook that runs after in-flight requests have been
+// drained. Hooks run in reverse registration order, like deferred calls, so
+// a resource registered first (the database) is released last.
+func (m *Manager) OnShutdown(name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopping = append(m.stopping, namedHook{name: name, hook: hook})
+}
+
+// Start runs the start hooks
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := append([]namedHook(nil), m.starting...)
+	m.mu.Unlock()
+
+	for _, h := range hooks {
+		if err := h.hook(ctx); err != nil {
+			return fmt.Errorf("start hook %s: %w", h.name, err)
+		}
+	}
+	return nil
+}
+
+// Shutdown runs every shutdown hook, each with its own timeout, and returns
+// the joined errors. A failing hook does not prevent the next ones from
+// running.
+func (m *Manager) Shutdown(timeout time.Duration) error {
+	m.mu.Lock()
+	hooks := append([]namedHook(nil), m.stopping...)
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := h.hook(ctx)
+		cancel()
+
+		if err != nil {
+			logger.Error("lifecycle@Shutdown", map[string]any{
+				"hook":  h.name,
+				"error": err.Error(),
+			})
+			errs = append(errs, fmt.Errorf("shutdown hook %s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Serve runs the start hooks and serves app on addr until ctx is cancelled
+// (typically by SIGINT/SIGTERM through signal.NotifyContext). It then stops
+// accepting connections, waits up to timeout for in-flight requests and runs
+// the shutdown hooks.
+func (m *Manager) Serve(ctx context.Context, app *fiber.App, addr string, timeout time.Duration) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(addr)
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-listenErr:
+		// The server failed to start or stopped by itself, the hooks
+		// still release what has been acquired
+	case <-ctx.Done():
+		logger.Info("lifecycle@Serve", map[string]any{
+			"message": "shutting down, draining in-flight requests",
+			"timeout": timeout.String(),
+		})
+		if err := app.ShutdownWithTimeout(timeout); err != nil {
+			logger.Error("lifecycle@Serve", map[string]any{
+				"error": err.Error(),
+			})
+		}
+		serveErr = <-listenErr
+	}
+
+	hookErr := m.Shutdown(timeout)
+	if hookErr == nil && serveErr == nil {
+		logger.Info("lifecycle@Serve", map[string]any{
+			"message": "shut down gracefully",
+		})
+	}
+	return errors.Join(serveErr, hookErr)
+}
+
+// DefaultManager holds the hooks registered with the package functions
+var DefaultManager = NewManager()
+
+// OnStart registers a start hook on DefaultManager
+func OnStart(name string, hook Hook) {
+	DefaultManager.OnStart(name, hook)
+}
+
+// OnShutdown registers a shutdown hook on DefaultManager
+func OnShutdown(name string, hook Hook) {
+	DefaultManager.OnShutdown(name, hook)
+}
+
+// Serve serves app with DefaultManager
+func Serve(ctx context.Context, app *fiber.App, addr string, timeout time.Duration) error {
+	return DefaultManager.Serve(ctx, app, addr, timeout)
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry mirrors the JSON lines written by the core logger, so access log
+// files can be read by the log viewer like any other log file
+type Entry struct {
+	Timestamp string         `json:"timestamp"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"additional_info,omitempty"`
+}
+
+// FileWriter appends entries to <dir>/<prefix>.YYYY-MM-DD.log and switches
+// to a new file when the date changes
+type FileWriter struct {
+	mu     sync.Mutex
+	dir    string
+	prefix string
+	date   string
+	file   *os.File
+}
+
+func NewFileWriter(dir string, prefix string) *FileWriter {
+	return &FileWriter{dir: dir, prefix: prefix}
+}
+
+func (w *FileWriter) Write(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.rotate(time.Now().Format("2006-01-02")); err != nil {
+		return err
+	}
+
+	_, err = w.file.Write(line)
+	return err
+}
+
+// Sync flushes the current file to disk
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close flushes and closes the current file. A later Write reopens it.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	w.file.Sync()
+	err := w.file.Close()
+	w.file = nil
+	w.date = ""
+	return err
+}
+
+func (w *FileWriter) rotate(date string) error {
+	if w.file != nil && w.date == date {
+		return nil
+	}
+
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	filename := filepath.Join(w.dir, fmt.Sprintf("%s.%s.log", w.prefix, date))
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.date = date
+	return nil
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"maps"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/galaplate/pkg/requestctx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Logger wraps the core logger and merges request scoped fields
+// (such as the request ID) into the additional info of every entry
+type Logger struct {
+	fields map[string]any
+}
+
+// FromContext returns a logger carrying the fields stored in ctx
+func FromContext(ctx context.Context) *Logger {
+	fields := map[string]any{}
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	return &Logger{fields: fields}
+}
+
+// FromCtx returns a logger for the request handled by c
+func FromCtx(c *fiber.Ctx) *Logger {
+	return FromContext(requestctx.FromFiber(c))
+}
+
+// With returns a copy of the logger with an extra field attached
+func (l *Logger) With(key string, value any) *Logger {
+	fields := maps.Clone(l.fields)
+	fields[key] = value
+	return &Logger{fields: fields}
+}
+
+func (l *Logger) Debug(msg string, data ...map[string]any) {
+	logger.Debug(msg, l.merge(data))
+}
+
+func (l *Logger) Info(msg string, data ...map[string]any) {
+	logger.Info(msg, l.merge(data))
+}
+
+func (l *Logger) Warn(msg string, data ...map[string]any) {
+	logger.Warn(msg, l.merge(data))
+}
+
+func (l *Logger) Error(msg string, data ...map[string]any) {
+	logger.Error(msg, l.merge(data))
+}
+
+func (l *Logger) merge(data []map[string]any) map[string]any {
+	if len(l.fields) == 0 {
+		if len(data) > 0 {
+			return data[0]
+		}
+		return nil
+	}
+
+	merged := maps.Clone(l.fields)
+	if len(data) > 0 {
+		maps.Copy(merged, data[0])
+	}
+	return merged
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// dbName is the db_name label of the connection pool stats
+const dbName = "default"
+
+// dbStatsCollector exposes the connection pool stats of database.Connect.
+// The connection is created after the metrics package is initialised, so
+// the stats collector is only built on the first scrape that finds it, and
+// again when the connection is replaced.
+type dbStatsCollector struct {
+	mu    sync.Mutex
+	db    *sql.DB
+	stats prometheus.Collector
+}
+
+// Describe sends the descriptors of the stats collector, which does not
+// use its connection to describe them
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	collectors.NewDBStatsCollector(nil, dbName).Describe(ch)
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	if database.Connect == nil {
+		return
+	}
+
+	sqlDB, err := database.Connect.DB()
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if c.db != sqlDB {
+		c.db = sqlDB
+		c.stats = collectors.NewDBStatsCollector(sqlDB, dbName)
+	}
+	stats := c.stats
+	c.mu.Unlock()
+
+	stats.Collect(ch)
+}
+
+var jobsDesc = prometheus.NewDesc(
+	"queue_jobs",
+	"Number of jobs in the jobs table, by state.",
+	[]string{"state"}, nil,
+)
+
+var oldestPendingDesc = prometheus.NewDesc(
+	"queue_oldest_pending_job_age_seconds",
+	"Seconds since the oldest due pending job became available, 0 when none is due.",
+	nil, nil,
+)
+
+// jobsCollector reports the queue depth per state and the age of the
+// backlog from the jobs table
+type jobsCollector struct{}
+
+func (c *jobsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jobsDesc
+	ch <- oldestPendingDesc
+}
+
+func (c *jobsCollector) Collect(ch chan<- prometheus.Metric) {
+	if database.Connect == nil || !database.Connect.Migrator().HasTable(&models.Job{}) {
+		return
+	}
+
+	var rows []struct {
+		State string
+		Total int64
+	}
+	if err := database.Connect.Model(&models.Job{}).
+		Select("state, COUNT(*) AS total").
+		Group("state").
+		Scan(&rows).Error; err != nil {
+		return
+	}
+
+	counts := map[string]int64{
+		string(models.JobPending):  0,
+		string(models.JobStarted):  0,
+		string(models.JobFinished): 0,
+		string(models.JobFailed):   0,
+	}
+	for _, row := range rows {
+		counts[row.State] = row.Total
+	}
+
+	for state, total := range counts {
+		ch <- prometheus.MustNewConstMetric(jobsDesc, prometheus.GaugeValue, float64(total), state)
+	}
+
+	now := time.Now()
+	var oldest []time.Time
+	err := database.Connect.Model(&models.Job{}).
+		Where("state = ? AND available_at <= ?", models.JobPending, now).
+		Order("available_at").Limit(1).Pluck("available_at", &oldest).Error
+	if err != nil {
+		return
+	}
+	age := 0.0
+	if len(oldest) > 0 {
+		age = now.Sub(oldest[0]).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(oldestPendingDesc, prometheus.GaugeValue, age)
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric exposed on /metrics. Application code can add
+// its own collectors with Register or MustRegister.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled, by method, route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by method, route pattern and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Number of login attempts, by result (success, failure, error).",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		loginAttempts,
+		&dbStatsCollector{},
+		&jobsCollector{},
+	)
+}
+
+// Register adds an application collector to the registry
+func Register(collector prometheus.Collector) error {
+	return Registry.Register(collector)
+}
+
+// MustRegister adds application collectors to the registry and panics when
+// one of them cannot be registered
+func MustRegister(collectors ...prometheus.Collector) {
+	Registry.MustRegister(collectors...)
+}
+
+// RecordLogin counts a login attempt; result is "success", "failure" or "error"
+func RecordLogin(result string) {
+	loginAttempts.WithLabelValues(result).Inc()
+}
+
+// Middleware records the request counter and latency histogram. Handler
+// errors are passed to the app ErrorHandler first so the recorded status
+// matches the response.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := strconv.Itoa(c.Response().StatusCode())
+		route := routePattern(c)
+
+		httpRequests.WithLabelValues(c.Method(), route, status).Inc()
+		httpDuration.WithLabelValues(c.Method(), route, status).Observe(time.Since(start).Seconds())
+
+		return nil
+	}
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+// When http.metrics.token is set, scrapers must send it as a bearer token.
+func Handler() fiber.Handler {
+	token := configutil.String("http.metrics.token", "")
+	serve := adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry: Registry,
+	}))
+
+	return func(c *fiber.Ctx) error {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte("Bearer "+token)) != 1 {
+			return apperror.Unauthorized("Unauthorized")
+		}
+		return serve(c)
+	}
+}
+
+// routePattern returns the registered path of the matched route. Requests
+// that matched no route only went through global middleware and are grouped
+// under "unmatched" to keep the label cardinality bounded.
+func routePattern(c *fiber.Ctx) string {
+	route := c.Route()
+	if route.Path == "/" && c.Path() != "/" {
+		return "unmatched"
+	}
+	return route.Path
+}
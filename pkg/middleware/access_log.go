@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+const redacted = "[REDACTED]"
+
+type AccessLogConfig struct {
+	Enabled bool
+	// Dir is the directory access.YYYY-MM-DD.log files are written to
+	Dir string
+	// SuccessSampleRate is the fraction (0..1) of requests answered with a
+	// status below 400 that get logged. Errors are always logged.
+	SuccessSampleRate float64
+	IncludeHeaders    bool
+	RedactHeaders     []string
+	RedactQuery       []string
+	SkipPaths         []string
+}
+
+// LoadAccessLogConfig reads the access_log section of config/http.yaml
+func LoadAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{
+		Enabled:           configutil.Bool("http.access_log.enabled", true),
+		Dir:               configutil.String("http.access_log.path", "./storage/logs"),
+		SuccessSampleRate: configutil.Float("http.access_log.success_sample_rate", 1),
+		IncludeHeaders:    configutil.Bool("http.access_log.include_headers", false),
+		RedactHeaders:     lower(configutil.Strings("http.access_log.redact_headers", []string{"authorization", "cookie", "set-cookie", "x-api-key"})),
+		RedactQuery:       lower(configutil.Strings("http.access_log.redact_query", []string{"token", "password", "signature"})),
+		SkipPaths:         configutil.Strings("http.access_log.skip_paths", nil),
+	}
+}
+
+type AccessLogMiddleware struct {
+	once   sync.Once
+	writer *logging.FileWriter
+}
+
+// Handler writes one JSON entry per request into the access log. Errors
+// returned by the handler chain are passed to the app ErrorHandler first so
+// the logged status matches what the client receives.
+func (m *AccessLogMiddleware) Handler(cfg AccessLogConfig) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	m.once.Do(func() {
+		m.writer = logging.NewFileWriter(cfg.Dir, "access")
+	})
+
+	return func(c *fiber.Ctx) error {
+		if slices.Contains(cfg.SkipPaths, c.Path()) {
+			return c.Next()
+		}
+
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusBadRequest && rand.Float64() >= cfg.SuccessSampleRate {
+			return nil
+		}
+
+		route := c.Route().Path
+		latency := time.Since(start)
+
+		info := map[string]any{
+			"method":     c.Method(),
+			"route":      route,
+			"path":       c.Path(),
+			"status":     status,
+			"latency_ms": float64(latency.Microseconds()) / 1000,
+			"bytes":      len(c.Response().Body()),
+			"ip":         ClientIP(c),
+			"user_agent": c.Get(fiber.HeaderUserAgent),
+		}
+
+		if query := redactQuery(string(c.Request().URI().QueryString()), cfg.RedactQuery); query != "" {
+			info["query"] = query
+		}
+		if requestID, ok := c.Locals("request_id").(string); ok {
+			info["request_id"] = requestID
+		}
+		if userID := c.Locals("user_id"); userID != nil {
+			info["user_id"] = userID
+		}
+		if cfg.IncludeHeaders {
+			info["headers"] = redactHeaders(c, cfg.RedactHeaders)
+		}
+
+		level := "INFO"
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = "ERROR"
+		case status >= fiber.StatusBadRequest:
+			level = "WARN"
+		}
+
+		_ = m.writer.Write(logging.Entry{
+			Timestamp: start.Format(time.RFC3339),
+			Level:     level,
+			Message:   fmt.Sprintf("%s %s %d", c.Method(), route, status),
+			Data:      info,
+		})
+
+		return nil
+	}
+}
+
+// Close flushes and closes the access log file
+func (m *AccessLogMiddleware) Close() error {
+	if m.writer == nil {
+		return nil
+	}
+	return m.writer.Close()
+}
+
+func redactQuery(rawQuery string, sensitive []string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+
+	for key := range values {
+		if slices.Contains(sensitive, strings.ToLower(key)) {
+			values[key] = []string{redacted}
+		}
+	}
+
+	return values.Encode()
+}
+
+func redactHeaders(c *fiber.Ctx, sensitive []string) map[string]string {
+	headers := map[string]string{}
+	for key, value := range c.GetReqHeaders() {
+		if slices.Contains(sensitive, strings.ToLower(key)) {
+			headers[key] = redacted
+			continue
+		}
+		headers[key] = strings.Join(value, ", ")
+	}
+	return headers
+}
+
+func lower(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strings.ToLower(value)
+	}
+	return result
+}
+
+var AccessLogMiddlewareInstance = &AccessLogMiddleware{}
+
+func AccessLog() fiber.Handler {
+	return AccessLogMiddlewareInstance.Handler(LoadAccessLogConfig())
+}
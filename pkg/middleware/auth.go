@@ -3,11 +3,13 @@ package middleware
 import (
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 
 	config "github.com/galaplate/core/env"
-	"github.com/galaplate/core/logger"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/logging"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -19,37 +21,25 @@ func (m *AuthMiddleware) BasicAuth() fiber.Handler {
 		var auth = c.Get("Authorization")
 		if auth == "" {
 			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Unauthorized",
-			})
+			return apperror.Unauthorized("Unauthorized")
 		}
 
 		if !strings.HasPrefix(auth, "Basic ") {
 			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid authorization header",
-			})
+			return apperror.Unauthorized("Invalid authorization header")
 		}
 
 		payload, err := base64.StdEncoding.DecodeString(auth[6:])
 		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to decode base64 auth: %s", err.Error()), nil)
+			logging.FromCtx(c).Error(fmt.Sprintf("Failed to decode base64 auth: %s", err.Error()), nil)
 			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid base64 encoding",
-			})
+			return apperror.Unauthorized("Invalid base64 encoding")
 		}
 
 		var pair = strings.SplitN(string(payload), ":", 2)
 		if len(pair) != 2 {
 			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid credentials format",
-			})
+			return apperror.Unauthorized("Invalid credentials format")
 		}
 
 		var username = pair[0]
@@ -59,23 +49,17 @@ func (m *AuthMiddleware) BasicAuth() fiber.Handler {
 		var expectedPassword = config.Get("BASIC_AUTH_PASSWORD")
 
 		if expectedUsername == "" || expectedPassword == "" {
-			logger.Error("Basic auth credentials not configured", nil)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"message": "Authentication not configured",
-			})
+			logging.FromCtx(c).Error("Basic auth credentials not configured", nil)
+			return apperror.Internal(errors.New("basic auth credentials not configured"))
 		}
 
 		if subtle.ConstantTimeCompare([]byte(username), []byte(expectedUsername)) != 1 ||
 			subtle.ConstantTimeCompare([]byte(password), []byte(expectedPassword)) != 1 {
-			logger.Warn("Failed basic auth attempt for username:", map[string]any{
+			logging.FromCtx(c).Warn("Failed basic auth attempt for username:", map[string]any{
 				"username": username,
 			})
 			c.Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid credentials",
-			})
+			return apperror.Unauthorized("Invalid credentials")
 		}
 
 		return c.Next()
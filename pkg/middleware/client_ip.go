@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+	proxyHeader        string
+)
+
+// ClientIP returns the address of the client that sent the request. The
+// forwarded header is only honored when the direct peer is one of the
+// proxies listed in http.trusted_proxies, in which case the right-most
+// untrusted address of the chain is used.
+func ClientIP(c *fiber.Ctx) string {
+	loadTrustedProxies()
+
+	remoteIP := c.Context().RemoteIP()
+	if remoteIP == nil {
+		return c.IP()
+	}
+
+	if !isTrustedProxy(remoteIP) {
+		return remoteIP.String()
+	}
+
+	forwarded := c.Get(proxyHeader)
+	if forwarded == "" {
+		return remoteIP.String()
+	}
+
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+
+	return remoteIP.String()
+}
+
+// IsSecure reports whether the client reached the server over HTTPS. The
+// scheme forwarded by a proxy, e.g. in X-Forwarded-Proto, is only honored
+// when the direct peer is one of the proxies listed in http.trusted_proxies.
+func IsSecure(c *fiber.Ctx) bool {
+	if c.Context().IsTLS() {
+		return true
+	}
+
+	loadTrustedProxies()
+	remoteIP := c.Context().RemoteIP()
+	if remoteIP == nil || !isTrustedProxy(remoteIP) {
+		return false
+	}
+	return c.Protocol() == "https"
+}
+
+// ResetTrustedProxies forgets the trusted proxies so that they are read
+// again from the current config. It must not be called while requests are
+// being served.
+func ResetTrustedProxies() {
+	trustedProxiesOnce = sync.Once{}
+	trustedProxies = nil
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadTrustedProxies() {
+	trustedProxiesOnce.Do(func() {
+		proxyHeader = configutil.String("http.proxy_header", fiber.HeaderXForwardedFor)
+
+		for _, entry := range configutil.Strings("http.trusted_proxies", nil) {
+			if !strings.Contains(entry, "/") {
+				if strings.Contains(entry, ":") {
+					entry += "/128"
+				} else {
+					entry += "/32"
+				}
+			}
+
+			if _, network, err := net.ParseCIDR(entry); err == nil {
+				trustedProxies = append(trustedProxies, network)
+			}
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+type CORSConfig struct {
+	Enabled          bool
+	AllowOrigins     string
+	AllowMethods     string
+	AllowHeaders     string
+	ExposeHeaders    string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+// LoadCORSConfig reads the cors section of config/http.yaml. The returned
+// map holds the per-group overrides keyed by path prefix.
+func LoadCORSConfig() (CORSConfig, map[string]CORSConfig) {
+	base := loadCORSConfig("http.cors", CORSConfig{
+		Enabled:       true,
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
+		ExposeHeaders: RequestIDHeader,
+	})
+
+	groups := map[string]CORSConfig{}
+	for prefix := range configutil.Map("http.cors.groups") {
+		groups[prefix] = loadCORSConfig("http.cors.groups."+prefix, base)
+	}
+	return base, groups
+}
+
+func loadCORSConfig(key string, def CORSConfig) CORSConfig {
+	return CORSConfig{
+		Enabled:          configutil.Bool(key+".enabled", def.Enabled),
+		AllowOrigins:     strings.Join(configutil.Strings(key+".allow_origins", strings.Split(def.AllowOrigins, ",")), ","),
+		AllowMethods:     strings.Join(configutil.Strings(key+".allow_methods", strings.Split(def.AllowMethods, ",")), ","),
+		AllowHeaders:     configutil.String(key+".allow_headers", def.AllowHeaders),
+		ExposeHeaders:    configutil.String(key+".expose_headers", def.ExposeHeaders),
+		AllowCredentials: configutil.Bool(key+".allow_credentials", def.AllowCredentials),
+		MaxAge:           configutil.Int(key+".max_age", def.MaxAge),
+	}
+}
+
+type CORSMiddleware struct{}
+
+// Handler applies the CORS settings of the longest path prefix in groups
+// matching the request, or base when none does
+func (m *CORSMiddleware) Handler(base CORSConfig, groups map[string]CORSConfig) fiber.Handler {
+	type group struct {
+		prefix  string
+		handler fiber.Handler
+	}
+
+	prefixes := make([]group, 0, len(groups))
+	for prefix, cfg := range groups {
+		prefixes = append(prefixes, group{prefix: prefix, handler: newCORSHandler(cfg)})
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i].prefix) > len(prefixes[j].prefix)
+	})
+	fallback := newCORSHandler(base)
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, g := range prefixes {
+			if path == g.prefix || strings.HasPrefix(path, strings.TrimSuffix(g.prefix, "/")+"/") {
+				return g.handler(c)
+			}
+		}
+		return fallback(c)
+	}
+}
+
+func newCORSHandler(cfg CORSConfig) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	if cfg.AllowCredentials && strings.Contains(cfg.AllowOrigins, "*") {
+		// Browsers reject credentials with a wildcard origin, and fiber
+		// refuses the combination at startup
+		logger.Warn("CORSMiddleware@Handler", map[string]any{
+			"message": "allow_credentials ignored because allow_origins contains *",
+		})
+		cfg.AllowCredentials = false
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+}
+
+var CORSMiddlewareInstance = &CORSMiddleware{}
+
+func CORS() fiber.Handler {
+	return CORSMiddlewareInstance.Handler(LoadCORSConfig())
+}
@@ -7,15 +7,27 @@ import (
 
 	"github.com/galaplate/core/config"
 	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
 	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/requestctx"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTService struct{}
 
+// CodePasswordResetRequired is sent when an administrator forced the user
+// to change their password before using the API again
+const CodePasswordResetRequired = "password_reset_required"
+
+// CodeAccountInactive is sent when an administrator deactivated the user
+const CodeAccountInactive = "account_inactive"
+
 type JWTClaims struct {
 	UserID uint `json:"user_id"`
+	// TokenVersion must match the user's token version: bumping the
+	// version revokes every token issued before
+	TokenVersion uint `json:"ver"`
 	jwt.RegisteredClaims
 }
 
@@ -23,9 +35,10 @@ func NewJWTService() *JWTService {
 	return &JWTService{}
 }
 
-func (j *JWTService) GenerateToken(userID uint) (string, error) {
+func (j *JWTService) GenerateToken(user *models.User) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -72,53 +85,70 @@ func (j *JWTService) getJWTSecret() string {
 	return secret
 }
 
+// allowPasswordResetLocal lets users who must reset their password through
+// AuthMiddleware, see PasswordChangeMiddleware
+const allowPasswordResetLocal = "jwt_allow_password_reset"
+
 func (j *JWTService) AuthMiddleware() fiber.Handler {
-	db := database.Connect
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Authorization header required",
-			})
+			return apperror.Unauthorized("Authorization header required")
 		}
 
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid authorization header format",
-			})
+			return apperror.Unauthorized("Invalid authorization header format")
 		}
 
 		tokenString := authHeader[7:]
 		claims, err := j.ValidateToken(tokenString)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid or expired token",
-				"error":   err.Error(),
-			})
+			return apperror.Unauthorized("Invalid or expired token").Wrap(err)
 		}
 
 		// Optional: Verify user still exists in database
 		var user models.User
-		if err := db.First(&user, claims.UserID).Error; err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "User not found",
-			})
+		if err := database.Connect.WithContext(c.UserContext()).First(&user, claims.UserID).Error; err != nil {
+			return apperror.Unauthorized("User not found").Wrap(err)
+		}
+
+		if claims.TokenVersion != user.TokenVersion {
+			return apperror.Unauthorized("Token has been revoked")
+		}
+		if !user.Status {
+			return apperror.New(fiber.StatusForbidden, CodeAccountInactive, "Account is inactive")
+		}
+		if allowed, _ := c.Locals(allowPasswordResetLocal).(bool); user.PasswordResetRequired && !allowed {
+			return apperror.New(fiber.StatusForbidden, CodePasswordResetRequired, "Password reset required")
 		}
 
 		// Store user information in context for use in handlers
 		c.Locals("user", &user)
 		c.Locals("user_id", claims.UserID)
+		c.SetUserContext(requestctx.WithActorID(c.UserContext(), claims.UserID))
 
 		return c.Next()
 	}
 }
 
+// PasswordChangeMiddleware authenticates like AuthMiddleware but also lets
+// through users who must reset their password, for the route changing it
+func (j *JWTService) PasswordChangeMiddleware() fiber.Handler {
+	auth := j.AuthMiddleware()
+	return func(c *fiber.Ctx) error {
+		c.Locals(allowPasswordResetLocal, true)
+		return auth(c)
+	}
+}
+
 var JWTServiceInstance = NewJWTService()
 
 func JWTAuth() fiber.Handler {
 	return JWTServiceInstance.AuthMiddleware()
 }
+
+// JWTAuthForPasswordChange is JWTAuth for the route where users forced to
+// reset their password can do so
+func JWTAuthForPasswordChange() fiber.Handler {
+	return JWTServiceInstance.PasswordChangeMiddleware()
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/galaplate/galaplate/pkg/requestctx"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+type RequestIDMiddleware struct{}
+
+// Handler reuses the X-Request-ID sent by the client (or an upstream proxy)
+// when it looks sane and generates a new one otherwise. The ID is echoed in
+// the response, stored in c.Locals("request_id") and in the user context
+// so that loggers and dispatched jobs can pick it up. The user context also
+// gets the client address and user agent, for the audit log.
+func (m *RequestIDMiddleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(RequestIDHeader, requestID)
+		c.Locals("request_id", requestID)
+		ctx := requestctx.WithRequestID(c.UserContext(), requestID)
+		ctx = requestctx.WithClient(ctx, requestctx.Client{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)})
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
+
+var RequestIDMiddlewareInstance = &RequestIDMiddleware{}
+
+func RequestID() fiber.Handler {
+	return RequestIDMiddlewareInstance.Handler()
+}
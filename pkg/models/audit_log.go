@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AuditLog records a change made to a record. Changes holds a JSON object
+// mapping each changed field to its old and new value.
+type AuditLog struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorID       *uint     `gorm:"index:audit_logs_actor_id_index" json:"actor_id"`
+	Action        string    `gorm:"size:100;not null" json:"action"`
+	AuditableType string    `gorm:"size:100;not null;index:audit_logs_auditable_index" json:"auditable_type"`
+	AuditableID   string    `gorm:"size:64;not null;index:audit_logs_auditable_index" json:"auditable_id"`
+	Changes       string    `json:"changes"`
+	RequestID     string    `gorm:"size:64" json:"request_id"`
+	IPAddress     string    `gorm:"size:45" json:"ip_address"`
+	UserAgent     string    `gorm:"size:255" json:"user_agent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ChunkedUpload is a resumable upload in progress. Received counts the
+// bytes written so far to its temporary file, the offset of the next chunk.
+type ChunkedUpload struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint      `gorm:"not null;index:chunked_uploads_user_id_index" json:"user_id"`
+	Collection string    `gorm:"size:50;not null" json:"collection"`
+	Name       string    `gorm:"size:255;not null" json:"name"`
+	Size       int64     `gorm:"not null" json:"size"`
+	Received   int64     `gorm:"not null;default:0" json:"received"`
+	ExpiresAt  time.Time `gorm:"index:chunked_uploads_expires_at_index" json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
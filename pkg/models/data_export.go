@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	DataExportPending   = "pending"
+	DataExportCompleted = "completed"
+	DataExportFailed    = "failed"
+)
+
+// DataExport is an archive of the data of a user, built in the background.
+// FileID is set once it is completed; the archive and the export are
+// deleted at ExpiresAt.
+type DataExport struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint       `gorm:"not null;index:data_exports_user_id_index" json:"user_id"`
+	Status    string     `gorm:"size:20;not null" json:"status"`
+	FileID    *uint      `json:"file_id"`
+	ExpiresAt *time.Time `gorm:"index:data_exports_expires_at_index" json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
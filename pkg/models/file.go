@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// File is an uploaded file. Path locates the content on Disk and is never
+// sent to clients; Checksum is the hex SHA-256 of the content. Variants
+// and URL are only set by the routes handing out download URLs.
+type File struct {
+	ID         uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     *uint         `gorm:"index:files_user_id_index" json:"user_id"`
+	Collection string        `gorm:"size:50;not null" json:"collection"`
+	Disk       string        `gorm:"size:50;not null" json:"disk"`
+	Path       string        `gorm:"size:255;not null" json:"-"`
+	Name       string        `gorm:"size:255;not null" json:"name"`
+	MimeType   string        `gorm:"size:100;not null" json:"mime_type"`
+	Size       int64         `gorm:"not null" json:"size"`
+	Checksum   string        `gorm:"size:64;not null" json:"checksum"`
+	Variants   []FileVariant `json:"variants,omitempty"`
+	URL        string        `gorm:"-" json:"url,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// FileVariant is a resized copy of an image File, stored next to it on the
+// same disk. URL is only set by the routes handing out download URLs.
+type FileVariant struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	FileID    uint      `gorm:"not null;uniqueIndex:file_variants_file_id_name_unique" json:"-"`
+	Name      string    `gorm:"size:50;not null;uniqueIndex:file_variants_file_id_name_unique" json:"name"`
+	Path      string    `gorm:"size:255;not null" json:"-"`
+	MimeType  string    `gorm:"size:100;not null" json:"mime_type"`
+	Width     int       `gorm:"not null" json:"width"`
+	Height    int       `gorm:"not null" json:"height"`
+	Size      int64     `gorm:"not null" json:"size"`
+	URL       string    `gorm:"-" json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
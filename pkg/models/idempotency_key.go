@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+)
+
+type IdempotencyKey struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Scope           string     `gorm:"size:255;not null;uniqueIndex:idempotency_keys_scope_key_unique" json:"scope"`
+	Key             string     `gorm:"column:idempotency_key;size:255;not null;uniqueIndex:idempotency_keys_scope_key_unique" json:"key"`
+	Fingerprint     string     `gorm:"size:64;not null" json:"fingerprint"`
+	State           string     `gorm:"size:20;not null" json:"state"`
+	ResponseStatus  int        `gorm:"default:0" json:"response_status"`
+	ResponseHeaders string     `json:"response_headers"`
+	ResponseBody    []byte     `json:"-"`
+	LockedUntil     *time.Time `json:"locked_until"`
+	ExpiresAt       time.Time  `gorm:"index" json:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// QueueWorker is the heartbeat of the queue workers of one process
+type QueueWorker struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Worker     string    `gorm:"size:255;not null;uniqueIndex:queue_workers_worker_unique" json:"worker"`
+	LastSeenAt time.Time `gorm:"index" json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type RateLimit struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Bucket      string    `gorm:"size:255;not null;uniqueIndex:rate_limits_bucket_window_unique" json:"bucket"`
+	WindowStart int64     `gorm:"not null;uniqueIndex:rate_limits_bucket_window_unique" json:"window_start"`
+	Hits        int64     `gorm:"not null;default:0" json:"hits"`
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`
+}
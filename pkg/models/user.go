@@ -5,14 +5,30 @@ import (
 	"time"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	Username    string         `gorm:"size:50;not null" json:"username"`
-	Email       string         `gorm:"size:100;uniqueIndex" json:"email"`
-	Password    string         `gorm:"size:255;not null" json:"-"`
-	Description string         `gorm:"size:255" json:"description"`
-	Status      bool           `gorm:"default:false" json:"status"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID                    uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Username              string         `gorm:"size:50;not null" json:"username"`
+	Email                 string         `gorm:"size:100;uniqueIndex" json:"email"`
+	Password              string         `gorm:"size:255;not null" json:"-"`
+	Description           string         `gorm:"size:255" json:"description"`
+	Status                bool           `gorm:"default:false" json:"status"`
+	AvatarID              *uint          `json:"avatar_id"`
+	Role                  string         `gorm:"size:20;not null;default:user" json:"role"`
+	EmailVerifiedAt       *time.Time     `json:"email_verified_at"`
+	PasswordResetRequired bool           `gorm:"not null;default:false" json:"password_reset_required"`
+	TokenVersion          uint           `gorm:"not null;default:0" json:"-"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	DeletionScheduledAt   *time.Time     `gorm:"index:users_deletion_scheduled_at_index" json:"deletion_scheduled_at"`
+}
+
+// IsAdmin reports whether the user may use the admin API
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
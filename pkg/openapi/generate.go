@@ -0,0 +1,256 @@
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/galaplate/galaplate/pkg/apiversion"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fiber path parameters: ":id", ":id?" and ":id<int>" become "{id}"
+var paramPattern = regexp.MustCompile(`:([A-Za-z0-9_]+)(?:<[^>]*>)?\??`)
+
+// LoadInfo reads the document info from config/openapi.yaml
+func LoadInfo() Info {
+	return Info{
+		Title:       configutil.String("openapi.title", "Galaplate API"),
+		Version:     configutil.String("openapi.version", "1.0.0"),
+		Description: configutil.String("openapi.description", ""),
+	}
+}
+
+// Generate builds the document for every route registered on app. Routes
+// described with Describe get their summary, schemas and responses; the
+// others are listed with a bare 200 response.
+func Generate(app *fiber.App, info Info) *Document {
+	b := newSchemaBuilder()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: b.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				BearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				BasicAuth:  {Type: "http", Scheme: "basic"},
+			},
+		},
+	}
+	for _, url := range configutil.Strings("openapi.servers", nil) {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+
+	var tags []string
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead || route.Method == fiber.MethodOptions {
+			continue
+		}
+
+		op, documented := lookup(route.Name)
+		if op.Hidden {
+			continue
+		}
+
+		path, params := convertPath(route.Path)
+		operation := &OperationObject{
+			OperationID: route.Name,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Tags:        op.Tags,
+			Parameters:  params,
+			Responses:   map[string]*Response{},
+			Deprecated:  op.Deprecated || apiversion.Deprecated(route.Name, route.Path),
+		}
+
+		if op.Query != nil {
+			operation.Parameters = append(operation.Parameters, queryParameters(b, reflect.TypeOf(op.Query))...)
+		}
+		for _, name := range slices.Sorted(maps.Keys(op.Headers)) {
+			operation.Parameters = append(operation.Parameters, &Parameter{
+				Name:        name,
+				In:          "header",
+				Description: op.Headers[name],
+				Schema:      &Schema{Type: "string"},
+			})
+		}
+		if op.Request != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					fiber.MIMEApplicationJSON: {Schema: b.Of(op.Request)},
+				},
+			}
+		}
+		if op.Form != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					fiber.MIMEMultipartForm: {Schema: b.Of(op.Form)},
+				},
+			}
+		}
+		for status, data := range op.Responses {
+			operation.Responses[strconv.Itoa(status)] = successResponse(b, status, data)
+		}
+		for _, status := range op.Errors {
+			operation.Responses[strconv.Itoa(status)] = errorResponse(b, status)
+		}
+		if !documented || len(operation.Responses) == 0 {
+			operation.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+		}
+		for _, scheme := range op.Security {
+			operation.Security = append(operation.Security, map[string][]string{scheme: {}})
+		}
+
+		for _, tag := range op.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = operation
+	}
+
+	slices.Sort(tags)
+	for _, tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+
+	return doc
+}
+
+// convertPath turns a fiber route path into an OpenAPI path template and
+// lists its path parameters
+func convertPath(route string) (string, []*Parameter) {
+	var params []*Parameter
+	path := paramPattern.ReplaceAllStringFunc(route, func(match string) string {
+		name := paramPattern.FindStringSubmatch(match)[1]
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+		return "{" + name + "}"
+	})
+
+	if strings.Contains(path, "*") {
+		path = strings.Replace(path, "*", "{wildcard}", 1)
+		params = append(params, &Parameter{
+			Name:     "wildcard",
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	return path, params
+}
+
+// queryParameters lists the `query` tagged fields of t, the tag read by
+// fiber's QueryParser
+func queryParameters(b *schemaBuilder, t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []*Parameter
+	for i := range t.NumField() {
+		field := t.Field(i)
+		// Embedded structs share their parameters, e.g. query.ListQuery
+		if field.Anonymous && field.Tag.Get("query") == "" {
+			params = append(params, queryParameters(b, field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		schema := b.schemaFor(field.Type)
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		required := applyValidateTag(schema, fieldType, field.Tag.Get("validate"))
+
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: field.Tag.Get("doc"),
+			Required:    required,
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+func successResponse(b *schemaBuilder, status int, data any) *Response {
+	response := &Response{Description: http.StatusText(status)}
+
+	if r, ok := data.(raw); ok {
+		if r.value != nil {
+			response.Content = map[string]*MediaType{
+				fiber.MIMEApplicationJSON: {Schema: b.Of(r.value)},
+			}
+		}
+		return response
+	}
+
+	envelope := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"message": {Type: "string"},
+		},
+		Required: []string{"success"},
+	}
+	if data != nil {
+		envelope.Properties["data"] = b.Of(data)
+		envelope.Required = append(envelope.Required, "data")
+	}
+
+	response.Content = map[string]*MediaType{
+		fiber.MIMEApplicationJSON: {Schema: envelope},
+	}
+	return response
+}
+
+// errorResponse documents the two bodies rendered by apperror.Handler
+func errorResponse(b *schemaBuilder, status int) *Response {
+	if _, ok := b.schemas["Error"]; !ok {
+		b.schemas["Error"] = b.structSchema(reflect.TypeOf(apperror.Envelope{}))
+		b.schemas["Problem"] = b.structSchema(reflect.TypeOf(apperror.Problem{}))
+	}
+
+	response := &Response{
+		Description: http.StatusText(status),
+		Content: map[string]*MediaType{
+			fiber.MIMEApplicationJSON: {Schema: &Schema{Ref: "#/components/schemas/Error"}},
+			apperror.MIMEProblemJSON:  {Schema: &Schema{Ref: "#/components/schemas/Problem"}},
+		},
+	}
+	if status == fiber.StatusTooManyRequests {
+		response.Headers = map[string]*Header{
+			fiber.HeaderRetryAfter: {
+				Description: "Seconds until the limit resets",
+				Schema:      &Schema{Type: "integer"},
+			},
+		}
+	}
+	return response
+}
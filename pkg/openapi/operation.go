@@ -0,0 +1,79 @@
+package openapi
+
+import "sync"
+
+// Security scheme names usable in Operation.Security
+const (
+	BearerAuth = "bearerAuth"
+	BasicAuth  = "basicAuth"
+)
+
+// Operation documents a named route. Values are only used for their type,
+// so zero values such as dto.AuthRegisterRequest{} are enough.
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Request is the JSON request body
+	Request any
+	// Form is a multipart/form-data request body, used instead of Request.
+	// Fields are named after their json tag; *multipart.FileHeader fields
+	// are files.
+	Form any
+	// Query is a struct whose `query` tagged fields are query parameters
+	Query any
+	// Headers documents optional request headers, mapping their name to a
+	// description
+	Headers map[string]string
+	// Responses maps status codes to the type sent in the "data" field of
+	// the success envelope. A nil value documents a response without data;
+	// wrap a value with Raw when the handler does not use the envelope.
+	Responses map[int]any
+	// Errors lists the statuses of the error responses the route can send
+	Errors     []int
+	Security   []string
+	Deprecated bool
+	// Hidden leaves the route out of the document
+	Hidden bool
+}
+
+type raw struct {
+	value any
+}
+
+// Raw marks a response body that is sent as is rather than wrapped in the
+// {"success": true, "data": ...} envelope
+func Raw(v any) any {
+	return raw{value: v}
+}
+
+var (
+	mu         sync.RWMutex
+	operations = map[string]Operation{}
+)
+
+// Describe documents the route registered under name, as set with
+// fiber's Name():
+//
+//	api.Post("/register", authController.Register).Name("auth.register")
+//	openapi.Describe("auth.register", openapi.Operation{...})
+//
+// Describing the same name again replaces the previous description.
+func Describe(name string, op Operation) {
+	mu.Lock()
+	defer mu.Unlock()
+	operations[name] = op
+}
+
+// Described reports whether the route registered under name was described
+func Described(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+func lookup(name string) (Operation, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	op, ok := operations[name]
+	return op, ok
+}
@@ -0,0 +1,294 @@
+package openapi
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	fileType      = reflect.TypeOf(multipart.FileHeader{})
+)
+
+// schemaBuilder converts Go types into schemas. Named structs are added to
+// the components once and referenced with $ref everywhere else.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// Of returns the schema of the type of v
+func (b *schemaBuilder) Of(v any) *Schema {
+	return b.schemaFor(reflect.TypeOf(v))
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case deletedAtType:
+		return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+	case rawJSONType:
+		return &Schema{}
+	case fileType:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + b.register(t)}
+	}
+
+	// Interfaces and anything without a JSON representation accept any value
+	return &Schema{}
+}
+
+// register adds the schema of the named struct t to the components and
+// returns its name. Types from different packages sharing a name are
+// prefixed with their package name.
+func (b *schemaBuilder) register(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+
+	name := componentName(t.Name())
+	if _, taken := b.schemas[name]; taken {
+		name = componentName(path.Base(t.PkgPath())) + name
+	}
+
+	b.names[t] = name
+	// Reserve the name before building so recursive types terminate
+	b.schemas[name] = &Schema{}
+	*b.schemas[name] = *b.structSchema(t)
+
+	return name
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(schema, t)
+	return schema
+}
+
+func (b *schemaBuilder) addFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		// Embedded structs without a JSON name are flattened, like
+		// encoding/json does
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.addFields(schema, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.schemaFor(field.Type)
+		if strings.Contains(opts, "string") && property.Type != nil {
+			property = &Schema{Type: "string"}
+		}
+		// encoding/json writes nil pointers as null; files are never JSON
+		if primitive, ok := property.Type.(string); ok && field.Type.Kind() == reflect.Pointer && fieldType != fileType && !strings.Contains(opts, "omitempty") {
+			property.Type = []string{primitive, "null"}
+		}
+		if description := field.Tag.Get("doc"); description != "" {
+			property.Description = description
+		}
+
+		if applyValidateTag(property, fieldType, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyValidateTag translates go-playground/validator rules into schema
+// constraints and reports whether the field is required. Rules after
+// "dive" apply to the elements of a collection and are ignored.
+func applyValidateTag(schema *Schema, t reflect.Type, tag string) bool {
+	required := false
+	if tag == "" || tag == "-" {
+		return required
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch key {
+		case "dive":
+			return required
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "ipv4":
+			schema.Format = "ipv4"
+		case "ipv6":
+			schema.Format = "ipv6"
+		case "alpha":
+			schema.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			schema.Pattern = `^[-+]?[0-9]+(?:\.[0-9]+)?$`
+		case "oneof":
+			schema.Enum = enumValues(t, param)
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				applyBound(schema, t, key, n)
+			}
+		}
+	}
+
+	return required
+}
+
+func applyBound(schema *Schema, t reflect.Type, rule string, n float64) {
+	switch t.Kind() {
+	case reflect.String:
+		applyLength(&schema.MinLength, &schema.MaxLength, rule, int(n))
+	case reflect.Slice, reflect.Array:
+		applyLength(&schema.MinItems, &schema.MaxItems, rule, int(n))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch rule {
+		case "min", "gte":
+			schema.Minimum = &n
+		case "max", "lte":
+			schema.Maximum = &n
+		case "gt":
+			schema.ExclusiveMinimum = &n
+		case "lt":
+			schema.ExclusiveMaximum = &n
+		case "len":
+			schema.Minimum, schema.Maximum = &n, &n
+		}
+	}
+}
+
+func applyLength(minimum, maximum **int, rule string, n int) {
+	switch rule {
+	case "min", "gte":
+		*minimum = &n
+	case "max", "lte":
+		*maximum = &n
+	case "gt":
+		n++
+		*minimum = &n
+	case "lt":
+		n--
+		*maximum = &n
+	case "len":
+		*minimum, *maximum = &n, &n
+	}
+}
+
+// enumValues splits a oneof parameter, keeping numbers typed so the enum
+// matches the JSON representation of the field
+func enumValues(t reflect.Type, param string) []any {
+	var values []any
+	for _, value := range strings.Fields(param) {
+		value = strings.Trim(value, "'")
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				values = append(values, n)
+				continue
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// componentName strips the characters OpenAPI does not allow in component
+// names, such as the brackets of generic type names, and capitalizes the
+// names of unexported types
+func componentName(name string) string {
+	// Instances of generic types are named after the type and its type
+	// arguments without their package path: Page[a/models.User] is PageUser
+	if base, args, ok := strings.Cut(name, "["); ok {
+		name = base
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			name += componentName(arg[strings.LastIndex(arg, ".")+1:])
+		}
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
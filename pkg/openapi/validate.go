@@ -0,0 +1,200 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate checks value, as decoded by encoding/json into any, against
+// schema and returns one message per violation. $refs are resolved against
+// the components of d. Only the keywords produced by Generate are checked.
+func (d *Document) Validate(schema *Schema, value any) []string {
+	var errs []string
+	d.validate(schema, value, "$", &errs)
+	return errs
+}
+
+// Operation returns the operation with the given operationId, which is the
+// route name, along with its path and method
+func (d *Document) Operation(id string) (path string, method string, op *OperationObject) {
+	for path, item := range d.Paths {
+		for method, op := range *item {
+			if op.OperationID == id {
+				return path, method, op
+			}
+		}
+	}
+	return "", "", nil
+}
+
+func (d *Document) validate(schema *Schema, value any, at string, errs *[]string) {
+	if schema == nil {
+		return
+	}
+
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if schema.Ref != "" {
+		resolved, ok := d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if !ok {
+			fail("unknown schema %s", schema.Ref)
+			return
+		}
+		d.validate(resolved, value, at, errs)
+		return
+	}
+
+	types := schemaTypes(schema.Type)
+	if len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return matchesType(t, value) }) {
+		fail("expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+		fail("%v is not one of %v", value, schema.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for name, property := range v {
+			if propertySchema, ok := schema.Properties[name]; ok {
+				d.validate(propertySchema, property, at+"."+name, errs)
+			} else if schema.AdditionalProperties != nil {
+				d.validate(schema.AdditionalProperties, property, at+"."+name, errs)
+			}
+		}
+
+	case []any:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			fail("expected at least %d items, got %d", *schema.MinItems, len(v))
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			fail("expected at most %d items, got %d", *schema.MaxItems, len(v))
+		}
+		for i, item := range v {
+			d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i), errs)
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			fail("expected at least %d characters, got %d", *schema.MinLength, length)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("expected at most %d characters, got %d", *schema.MaxLength, length)
+		}
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(v) {
+				fail("%q does not match %s", v, schema.Pattern)
+			}
+		}
+		if !matchesFormat(schema.Format, v) {
+			fail("%q is not a valid %s", v, schema.Format)
+		}
+
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			fail("%v is less than %v", v, *schema.Minimum)
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			fail("%v is greater than %v", v, *schema.Maximum)
+		}
+		if schema.ExclusiveMinimum != nil && v <= *schema.ExclusiveMinimum {
+			fail("%v is not greater than %v", v, *schema.ExclusiveMinimum)
+		}
+		if schema.ExclusiveMaximum != nil && v >= *schema.ExclusiveMaximum {
+			fail("%v is not less than %v", v, *schema.ExclusiveMaximum)
+		}
+	}
+}
+
+func schemaTypes(t any) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(t string, value any) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	}
+	return true
+}
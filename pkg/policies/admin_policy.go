@@ -0,0 +1,57 @@
+package policies
+
+import (
+	"context"
+
+	"github.com/galaplate/core/policies"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/routes"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminPolicy only lets administrators through. It relies on JWTAuth
+// having stored the user in the context.
+type AdminPolicy struct{}
+
+func NewAdminPolicy() *AdminPolicy {
+	return &AdminPolicy{}
+}
+
+func (p *AdminPolicy) Name() string {
+	return "admin"
+}
+
+func (p *AdminPolicy) Evaluate(ctx context.Context, policyCtx *policies.PolicyContext) policies.PolicyResult {
+	user, ok := policyCtx.User.(*models.User)
+	if !ok || user == nil {
+		return policies.PolicyResult{
+			Allowed: false,
+			Message: "Authentication required",
+			Code:    fiber.StatusUnauthorized,
+		}
+	}
+
+	if !user.IsAdmin() {
+		return policies.PolicyResult{
+			Allowed: false,
+			Message: "Admin access required",
+			Code:    fiber.StatusForbidden,
+		}
+	}
+
+	return policies.PolicyResult{
+		Allowed: true,
+		Message: "admin policy check passed",
+		Code:    fiber.StatusOK,
+	}
+}
+
+func init() {
+	policies.GlobalPolicyManager.RegisterPolicy(NewAdminPolicy())
+}
+
+// Admin returns a middleware only letting administrators through; place it
+// after JWTAuth
+func Admin() routes.Named {
+	return Enforce("admin")
+}
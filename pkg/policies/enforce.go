@@ -0,0 +1,20 @@
+package policies
+
+import (
+	"strings"
+
+	"github.com/galaplate/core/policies"
+	"github.com/galaplate/galaplate/pkg/routes"
+)
+
+// Enforce returns a middleware evaluating the named policies, like
+// policies.WithPolicies, named after them so that route:list tells them
+// apart: every middleware of core policies is the same closure to
+// runtime.FuncForPC. Register it through a routes.Router, or pass its
+// Handler to fiber.
+func Enforce(names ...string) routes.Named {
+	return routes.Named{
+		Name:    "policies.Enforce(" + strings.Join(names, ", ") + ")",
+		Handler: policies.WithPolicies(names...),
+	}
+}
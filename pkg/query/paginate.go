@@ -0,0 +1,318 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Page is the response of a list endpoint
+type Page[T any] struct {
+	Success bool  `json:"success"`
+	Data    []T   `json:"data"`
+	Meta    Meta  `json:"meta"`
+	Links   Links `json:"links"`
+}
+
+type Meta struct {
+	// Page is omitted for pages requested with a cursor
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Links are the relative URLs of the neighbouring pages
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// Paginate lists the T matching the query string of c. db carries the
+// conditions of the endpoint itself, e.g. a search or the current user:
+//
+//	page, err := query.Paginate[models.User](c, db.Where("status = ?", true), query.Options{
+//		Sortable:    []string{"id", "username", "created_at"},
+//		Filterable:  map[string][]string{"username": {query.OpEq, query.OpLike}},
+//		DefaultSort: "-created_at",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	return c.JSON(page)
+func Paginate[T any](c *fiber.Ctx, db *gorm.DB, opts Options) (*Page[T], error) {
+	params, err := Parse(c, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	db = db.Model(new(T))
+	fields, err := parseSchema(db)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	db, err = params.filter(db, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, apperror.Internal(fmt.Errorf("count: %w", err))
+	}
+
+	list := db.Session(&gorm.Session{})
+	for _, sort := range params.Sort {
+		list = list.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Column}, Desc: sort.Desc})
+	}
+	if params.Cursor != "" {
+		if list, err = params.after(list, fields); err != nil {
+			return nil, err
+		}
+	} else {
+		list = list.Offset((params.Page - 1) * params.PerPage)
+	}
+
+	// One more item than asked tells whether there is a next page
+	items := []T{}
+	if err := list.Limit(params.PerPage + 1).Find(&items).Error; err != nil {
+		return nil, apperror.Internal(fmt.Errorf("list: %w", err))
+	}
+	hasMore := len(items) > params.PerPage
+	if hasMore {
+		items = items[:params.PerPage]
+	}
+
+	page := &Page[T]{
+		Success: true,
+		Data:    items,
+		Meta: Meta{
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: int((total + int64(params.PerPage) - 1) / int64(params.PerPage)),
+		},
+	}
+	if hasMore {
+		last := reflect.ValueOf(&items[len(items)-1]).Elem()
+		page.Meta.NextCursor, err = params.cursorOf(c, last, fields)
+		if err != nil {
+			return nil, apperror.Internal(err)
+		}
+	}
+	if params.Cursor == "" {
+		page.Meta.Page = params.Page
+	}
+	page.Links = params.links(c, page.Meta, hasMore)
+
+	return page, nil
+}
+
+func parseSchema(db *gorm.DB) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(db.Statement.Model); err != nil {
+		return nil, fmt.Errorf("parse model: %w", err)
+	}
+	return stmt.Schema, nil
+}
+
+func (p *Params) filter(db *gorm.DB, fields *schema.Schema) (*gorm.DB, error) {
+	for _, f := range p.Filters {
+		field := fields.LookUpField(f.Column)
+		if field == nil || field.DBName == "" {
+			return nil, apperror.Internal(fmt.Errorf("filterable column %q is not a column of %s", f.Column, fields.Table))
+		}
+		column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+
+		if f.Op == OpNull {
+			isNull, err := strconv.ParseBool(f.Value)
+			if err != nil {
+				return nil, apperror.BadRequest(fmt.Sprintf("filter[%s][null] must be true or false", f.Column))
+			}
+			if isNull {
+				db = db.Where("? IS NULL", column)
+			} else {
+				db = db.Where("? IS NOT NULL", column)
+			}
+			continue
+		}
+
+		if f.Op == OpLike {
+			db = db.Where("? LIKE ?", column, "%"+f.Value+"%")
+			continue
+		}
+
+		if f.Op == OpIn {
+			var values []any
+			for _, raw := range strings.Split(f.Value, ",") {
+				value, err := convert(field, raw)
+				if err != nil {
+					return nil, apperror.BadRequest(fmt.Sprintf("Invalid value for filter[%s]: %v", f.Column, err))
+				}
+				values = append(values, value)
+			}
+			db = db.Where("? IN ?", column, values)
+			continue
+		}
+
+		value, err := convert(field, f.Value)
+		if err != nil {
+			return nil, apperror.BadRequest(fmt.Sprintf("Invalid value for filter[%s]: %v", f.Column, err))
+		}
+		operator := map[string]string{OpEq: "=", OpNe: "<>", OpLt: "<", OpLte: "<=", OpGt: ">", OpGte: ">="}[f.Op]
+		db = db.Where("? "+operator+" ?", column, value)
+	}
+	return db, nil
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+// convert parses a query string value into the Go type of field, so that it
+// is compared with the column as the column's type
+func convert(field *schema.Field, raw string) (any, error) {
+	t := field.FieldType
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType || t == deletedAtType {
+		for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+			if value, err := time.Parse(layout, raw); err == nil {
+				return value, nil
+			}
+		}
+		return nil, errors.New("expected a date or an RFC 3339 time")
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	}
+	return raw, nil
+}
+
+// after keeps the items following the cursor in the sort order
+func (p *Params) after(db *gorm.DB, fields *schema.Schema) (*gorm.DB, error) {
+	invalid := apperror.BadRequest("Invalid cursor")
+
+	encoded, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, invalid.Wrap(err)
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(encoded, &raw); err != nil || len(raw) != len(p.Sort) {
+		return nil, invalid
+	}
+
+	values := make([]any, len(raw))
+	for i, sort := range p.Sort {
+		field := fields.LookUpField(sort.Column)
+		if field == nil {
+			return nil, invalid
+		}
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw[i], value.Interface()); err != nil {
+			return nil, invalid.Wrap(err)
+		}
+		values[i] = value.Elem().Interface()
+	}
+
+	// (a > x) OR (a = x AND b > y) OR ..., with < for descending columns
+	var conditions []string
+	var args []any
+	for i, sort := range p.Sort {
+		var parts []string
+		for j := range i {
+			parts = append(parts, "? = ?")
+			args = append(args, clause.Column{Table: clause.CurrentTable, Name: p.Sort[j].Column}, values[j])
+		}
+		operator := ">"
+		if sort.Desc {
+			operator = "<"
+		}
+		parts = append(parts, "? "+operator+" ?")
+		args = append(args, clause.Column{Table: clause.CurrentTable, Name: sort.Column}, values[i])
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return db.Where(strings.Join(conditions, " OR "), args...), nil
+}
+
+// cursorOf encodes the sort columns of item, the last item of the page
+func (p *Params) cursorOf(c *fiber.Ctx, item reflect.Value, fields *schema.Schema) (string, error) {
+	values := make([]any, len(p.Sort))
+	for i, sort := range p.Sort {
+		field := fields.LookUpField(sort.Column)
+		if field == nil {
+			return "", fmt.Errorf("sortable column %q is not a column of %s", sort.Column, fields.Table)
+		}
+		values[i], _ = field.ValueOf(c.UserContext(), item)
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func (p *Params) links(c *fiber.Ctx, meta Meta, hasMore bool) Links {
+	base, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		base = &url.URL{Path: c.Path()}
+	}
+	with := func(set map[string]string) string {
+		u := *base
+		q := u.Query()
+		q.Del("page")
+		q.Del("cursor")
+		for key, value := range set {
+			q.Set(key, value)
+		}
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	links := Links{Self: base.String(), First: with(nil)}
+	if p.Cursor != "" {
+		if hasMore {
+			links.Next = with(map[string]string{"cursor": meta.NextCursor})
+		}
+		return links
+	}
+
+	if meta.TotalPages > 0 {
+		links.Last = with(map[string]string{"page": strconv.Itoa(meta.TotalPages)})
+	}
+	if p.Page > 1 {
+		links.Prev = with(map[string]string{"page": strconv.Itoa(p.Page - 1)})
+	}
+	if hasMore {
+		links.Next = with(map[string]string{"page": strconv.Itoa(p.Page + 1)})
+	}
+	return links
+}
@@ -0,0 +1,170 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Operators of filter[field][op]=value
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLike = "like"
+	OpIn   = "in"
+	OpNull = "null"
+)
+
+// primaryKey is appended to every sort so that the order is total
+const primaryKey = "id"
+
+// AllOperators is used for filterable columns listed without operators
+var AllOperators = []string{OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpLike, OpIn, OpNull}
+
+var filterKey = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// Options restricts what clients may ask for on one list endpoint. Columns
+// are database column names; anything not listed is rejected with 400.
+type Options struct {
+	// Sortable lists the columns accepted by sort
+	Sortable []string
+	// Filterable maps the columns accepted by filter to their allowed
+	// operators; a nil slice allows every operator
+	Filterable map[string][]string
+	// DefaultSort is used when the request has no sort, e.g. "-created_at"
+	DefaultSort string
+	// DefaultPerPage defaults to 20 and MaxPerPage to 100
+	DefaultPerPage int
+	MaxPerPage     int
+}
+
+// ListQuery documents the parameters read by Parse. Embed it in the Query
+// struct of an openapi.Operation, next to the filter parameters of the
+// endpoint.
+type ListQuery struct {
+	Page    int    `query:"page" validate:"omitempty,min=1"`
+	PerPage int    `query:"per_page" validate:"omitempty,min=1" doc:"Items per page, capped by the endpoint (usually 100)"`
+	Cursor  string `query:"cursor" doc:"meta.next_cursor of the previous page; replaces page"`
+	Sort    string `query:"sort" doc:"Comma separated columns, prefixed with - to sort descending, e.g. -created_at,username"`
+}
+
+// Sort orders by one column
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// Filter is one filter[column][op]=value condition
+type Filter struct {
+	Column string
+	Op     string
+	Value  string
+}
+
+// Params are the listing parameters of a request
+type Params struct {
+	Page    int
+	PerPage int
+	// Cursor continues after the last item of a previous page; Page is
+	// ignored when it is set
+	Cursor  string
+	Sort    []Sort
+	Filters []Filter
+}
+
+// Parse reads page, per_page, cursor, sort=-created_at,username and
+// filter[field][op]=value from the query string of c
+func Parse(c *fiber.Ctx, opts Options) (*Params, error) {
+	perPage := opts.DefaultPerPage
+	if perPage <= 0 {
+		perPage = 20
+	}
+	maxPerPage := opts.MaxPerPage
+	if maxPerPage <= 0 {
+		maxPerPage = 100
+	}
+
+	params := &Params{Page: 1, PerPage: perPage, Cursor: c.Query("cursor")}
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return nil, apperror.BadRequest("page must be a positive integer")
+		}
+		params.Page = page
+	}
+	if raw := c.Query("per_page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, apperror.BadRequest("per_page must be a positive integer")
+		}
+		params.PerPage = min(n, maxPerPage)
+	}
+
+	sort, err := parseSort(c.Query("sort", opts.DefaultSort), opts.Sortable)
+	if err != nil {
+		return nil, err
+	}
+	params.Sort = sort
+
+	var filterErr error
+	c.Request().URI().QueryArgs().VisitAll(func(key, value []byte) {
+		match := filterKey.FindStringSubmatch(string(key))
+		if match == nil || filterErr != nil {
+			return
+		}
+		column, op := match[1], match[2]
+		if op == "" {
+			op = OpEq
+		}
+
+		ops, ok := opts.Filterable[column]
+		if !ok {
+			filterErr = apperror.BadRequest(fmt.Sprintf("Cannot filter by %q", column))
+			return
+		}
+		if ops == nil {
+			ops = AllOperators
+		}
+		if !slices.Contains(ops, op) {
+			filterErr = apperror.BadRequest(fmt.Sprintf("Cannot filter %q with %q", column, op))
+			return
+		}
+		params.Filters = append(params.Filters, Filter{Column: column, Op: op, Value: string(value)})
+	})
+	if filterErr != nil {
+		return nil, filterErr
+	}
+
+	return params, nil
+}
+
+func parseSort(raw string, sortable []string) ([]Sort, error) {
+	var sort []Sort
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		column, desc := strings.CutPrefix(part, "-")
+		if !slices.Contains(sortable, column) {
+			return nil, apperror.BadRequest(fmt.Sprintf("Cannot sort by %q", column))
+		}
+		sort = append(sort, Sort{Column: column, Desc: desc})
+	}
+
+	// Pages and cursors need a total order
+	if !slices.ContainsFunc(sort, func(s Sort) bool { return s.Column == primaryKey }) {
+		desc := len(sort) > 0 && sort[len(sort)-1].Desc
+		sort = append(sort, Sort{Column: primaryKey, Desc: desc})
+	}
+	return sort, nil
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+)
+
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// Rule allows Limit requests per Window for every key
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    string
+}
+
+// Result describes the state of a key after a request has been counted
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// Limiter implements a sliding window: the hits of the previous window are
+// weighted by how much of it still overlaps the last Window, which smooths
+// the burst a fixed window allows at its boundary.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow counts a request for key under rule and reports whether it is
+// within the limit
+func (l *Limiter) Allow(ctx context.Context, rule Rule, key string) (Result, error) {
+	now := l.now()
+	current := now.Truncate(rule.Window)
+	previous := current.Add(-rule.Window)
+	key = fmt.Sprintf("%s:%s", rule.Name, key)
+
+	hits, err := l.store.Increment(ctx, key, current, 2*rule.Window)
+	if err != nil {
+		return Result{}, err
+	}
+	previousHits, err := l.store.Count(ctx, key, previous)
+	if err != nil {
+		return Result{}, err
+	}
+
+	overlap := 1 - float64(now.Sub(current))/float64(rule.Window)
+	estimate := int(math.Ceil(float64(previousHits)*overlap)) + int(hits)
+
+	return Result{
+		Allowed:   estimate <= rule.Limit,
+		Limit:     rule.Limit,
+		Remaining: max(rule.Limit-estimate, 0),
+		Reset:     current.Add(rule.Window).Sub(now),
+	}, nil
+}
+
+// Enabled reports whether rate limiting is switched on
+func Enabled() bool {
+	return configutil.Bool("ratelimit.enabled", true)
+}
+
+// Rules returns the limiters configured in config/ratelimit.yaml, sorted by
+// name
+func Rules() []Rule {
+	var rules []Rule
+	for name, value := range configutil.Map("ratelimit.limiters") {
+		prefix := "ratelimit.limiters." + name
+		if _, ok := value.(map[string]any); !ok {
+			continue
+		}
+
+		rule := Rule{
+			Name:   name,
+			Limit:  configutil.Int(prefix+".limit", 60),
+			Window: configutil.Duration(prefix+".window", time.Minute),
+			Key:    configutil.String(prefix+".key", KeyIP),
+		}
+		if rule.Window <= 0 {
+			rule.Window = time.Minute
+		}
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+// NewStoreFromConfig returns the store selected by ratelimit.store
+func NewStoreFromConfig() Store {
+	if configutil.String("ratelimit.store", "memory") == "database" {
+		return NewDatabaseStore()
+	}
+	return NewMemoryStore()
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store keeps the hit counters of every key and window
+type Store interface {
+	// Increment adds a hit to key for the window starting at window and
+	// returns the new count. The counter may be dropped after ttl.
+	Increment(ctx context.Context, key string, window time.Time, ttl time.Duration) (int64, error)
+	// Count returns the hits of key for the window starting at window
+	Count(ctx context.Context, key string, window time.Time) (int64, error)
+}
+
+type counter struct {
+	hits      int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. Each instance of the
+// application counts on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, window time.Time, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	id := memoryKey(key, window)
+	c, ok := s.counters[id]
+	if !ok {
+		c = &counter{expiresAt: window.Add(ttl)}
+		s.counters[id] = c
+	}
+	c.hits++
+	return c.hits, nil
+}
+
+func (s *MemoryStore) Count(ctx context.Context, key string, window time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.counters[memoryKey(key, window)]; ok {
+		return c.hits, nil
+	}
+	return 0, nil
+}
+
+// sweep drops expired counters at most once a minute
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for id, c := range s.counters {
+		if now.After(c.expiresAt) {
+			delete(s.counters, id)
+		}
+	}
+}
+
+func memoryKey(key string, window time.Time) string {
+	return key + "@" + window.UTC().Format(time.RFC3339)
+}
+
+// DatabaseStore keeps counters in the rate_limits table so that all
+// instances share them
+type DatabaseStore struct {
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+func NewDatabaseStore() *DatabaseStore {
+	return &DatabaseStore{}
+}
+
+func (s *DatabaseStore) Increment(ctx context.Context, key string, window time.Time, ttl time.Duration) (int64, error) {
+	db := database.Connect.WithContext(ctx)
+	s.purge(db)
+
+	row := models.RateLimit{
+		Bucket:      key,
+		WindowStart: window.Unix(),
+		Hits:        1,
+		ExpiresAt:   window.Add(ttl),
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bucket"}, {Name: "window_start"}},
+		DoUpdates: clause.Assignments(map[string]any{"hits": gorm.Expr("rate_limits.hits + 1")}),
+	}).Create(&row).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Count(ctx, key, window)
+}
+
+func (s *DatabaseStore) Count(ctx context.Context, key string, window time.Time) (int64, error) {
+	var hits int64
+	err := database.Connect.WithContext(ctx).
+		Model(&models.RateLimit{}).
+		Where("bucket = ? AND window_start = ?", key, window.Unix()).
+		Select("COALESCE(SUM(hits), 0)").
+		Scan(&hits).Error
+	return hits, err
+}
+
+// purge deletes expired counters at most once a minute
+func (s *DatabaseStore) purge(db *gorm.DB) {
+	s.mu.Lock()
+	if time.Since(s.lastPurge) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPurge = time.Now()
+	s.mu.Unlock()
+
+	db.Where("expires_at < ?", time.Now()).Delete(&models.RateLimit{})
+}
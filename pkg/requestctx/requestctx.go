@@ -0,0 +1,72 @@
+package requestctx
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+// WithRequestID returns a copy of ctx carrying the given request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// FromFiber returns the request scoped context of a Fiber handler
+func FromFiber(c *fiber.Ctx) context.Context {
+	return c.UserContext()
+}
+
+const (
+	actorIDKey contextKey = "actor_id"
+	clientKey  contextKey = "client"
+)
+
+// Client is the address and user agent of the client of a request
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// WithActorID returns a copy of ctx carrying the ID of the authenticated
+// user acting in the request
+func WithActorID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, actorIDKey, userID)
+}
+
+// ActorID returns the ID stored by WithActorID, if any
+func ActorID(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	userID, ok := ctx.Value(actorIDKey).(uint)
+	return userID, ok
+}
+
+// WithClient returns a copy of ctx carrying the client of the request
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey, client)
+}
+
+// ClientOf returns the client stored in ctx, or a zero Client
+func ClientOf(ctx context.Context) Client {
+	if ctx == nil {
+		return Client{}
+	}
+	client, _ := ctx.Value(clientKey).(Client)
+	return client
+}
@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/galaplate/galaplate/pkg/apiversion"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Named is a middleware along with the name List shows for it. Middleware
+// built from a shared closure, like the one evaluating core policies, all
+// get the same name from HandlerName; their constructors return a Named
+// instead, e.g. policies.Enforce.
+type Named struct {
+	Name    string
+	Handler fiber.Handler
+}
+
+// Router registers middleware and routes on a fiber.Router and records
+// them, with the name of every handler, in the registry read by List.
+// Handlers are fiber.Handler values or Named middleware; anything else
+// panics, like fiber does on invalid routes.
+//
+//	r := routes.New(app)
+//	r.Use(middleware.RequestID())
+//	v1 := r.Group(apiversion.Prefix()).Version("v1")
+//	v1.Post("/login", policies.RateLimit("login"), authController.Login).Name("auth.login")
+type Router struct {
+	router   fiber.Router
+	registry *registry
+	prefix   string
+}
+
+// registry holds the middleware and routes registered on an app through a
+// Router, in registration order
+type registry struct {
+	mu      sync.Mutex
+	entries []entry
+	// latest is the index of the latest route entry, named by Router.Name
+	latest int
+}
+
+// entry is either middleware mounted with Use or Group, or a route
+type entry struct {
+	mounted []Middleware
+	route   *Route
+}
+
+// registries maps a *fiber.App to its *registry
+var registries sync.Map
+
+// New returns a Router registering on app. The registry of app is dropped
+// when app shuts down.
+func New(app *fiber.App) *Router {
+	value, loaded := registries.LoadOrStore(app, &registry{latest: -1})
+	if !loaded {
+		app.Hooks().OnShutdown(func() error {
+			registries.Delete(app)
+			return nil
+		})
+	}
+	return &Router{router: app, registry: value.(*registry)}
+}
+
+// Use mounts middleware on the prefix of r
+func (r *Router) Use(handlers ...any) *Router {
+	fns, names := split(handlers)
+	args := make([]any, len(fns))
+	for i, fn := range fns {
+		args[i] = fn
+	}
+	r.router.Use(args...)
+	r.registry.mount(mountPath(r.prefix), names)
+	return r
+}
+
+// Group returns a Router registering under prefix, with handlers mounted on
+// it like fiber.Router.Group
+func (r *Router) Group(prefix string, handlers ...any) *Router {
+	fns, names := split(handlers)
+	group := &Router{
+		router:   r.router.Group(prefix, fns...),
+		registry: r.registry,
+		prefix:   joinPath(r.prefix, prefix),
+	}
+	if len(names) > 0 {
+		r.registry.mount(group.prefix, names)
+	}
+	return group
+}
+
+// Version returns a Router registering under the API version name, see
+// apiversion.Group
+func (r *Router) Version(name string) *Router {
+	return &Router{
+		router:   apiversion.Group(r.router, name),
+		registry: r.registry,
+		prefix:   joinPath(r.prefix, "/"+name),
+	}
+}
+
+func (r *Router) Get(path string, handlers ...any) *Router {
+	return r.add(fiber.MethodGet, path, handlers)
+}
+
+func (r *Router) Post(path string, handlers ...any) *Router {
+	return r.add(fiber.MethodPost, path, handlers)
+}
+
+func (r *Router) Put(path string, handlers ...any) *Router {
+	return r.add(fiber.MethodPut, path, handlers)
+}
+
+func (r *Router) Patch(path string, handlers ...any) *Router {
+	return r.add(fiber.MethodPatch, path, handlers)
+}
+
+func (r *Router) Delete(path string, handlers ...any) *Router {
+	return r.add(fiber.MethodDelete, path, handlers)
+}
+
+// Name names the latest route registered on the app, like
+// fiber.Router.Name
+func (r *Router) Name(name string) *Router {
+	r.router.Name(name)
+
+	r.registry.mu.Lock()
+	defer r.registry.mu.Unlock()
+	if r.registry.latest >= 0 {
+		r.registry.entries[r.registry.latest].route.Name = name
+	}
+	return r
+}
+
+func (r *Router) add(method, path string, handlers []any) *Router {
+	fns, names := split(handlers)
+	r.router.Add(method, path, fns...)
+
+	route := &Route{Method: method, Path: joinPath(r.prefix, path), Middleware: []Middleware{}}
+	if n := len(names); n > 0 {
+		for _, name := range names[:n-1] {
+			route.Middleware = append(route.Middleware, Middleware{Name: name})
+		}
+		route.Handler = names[n-1]
+	}
+
+	r.registry.mu.Lock()
+	defer r.registry.mu.Unlock()
+	r.registry.entries = append(r.registry.entries, entry{route: route})
+	r.registry.latest = len(r.registry.entries) - 1
+	return r
+}
+
+func (reg *registry) mount(prefix string, names []string) {
+	mounted := make([]Middleware, len(names))
+	for i, name := range names {
+		mounted[i] = Middleware{Name: name, Prefix: prefix}
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.entries = append(reg.entries, entry{mounted: mounted})
+}
+
+// split returns the fiber handlers among handlers along with their names
+func split(handlers []any) ([]fiber.Handler, []string) {
+	fns := make([]fiber.Handler, 0, len(handlers))
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		switch h := h.(type) {
+		case Named:
+			fns = append(fns, h.Handler)
+			names = append(names, h.Name)
+		case fiber.Handler:
+			fns = append(fns, h)
+			names = append(names, HandlerName(h))
+		default:
+			panic(fmt.Sprintf("routes: %T is not a handler", h))
+		}
+	}
+	return fns, names
+}
+
+// joinPath joins a group prefix and a path the way fiber does
+func joinPath(prefix, path string) string {
+	if path == "" {
+		return mountPath(prefix)
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	return strings.TrimRight(prefix, "/") + path
+}
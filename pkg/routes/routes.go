@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/galaplate/galaplate/pkg/apiversion"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Closures are named after the function they are declared in, and method
+// values get a -fm suffix: middleware.(*JWTService).AuthMiddleware.func1
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// Middleware is a handler running before the route handler
+type Middleware struct {
+	Name string `json:"name"`
+	// Prefix is the path the middleware was mounted on with Use or Group,
+	// "/" for app-wide middleware and empty for route middleware
+	Prefix string `json:"prefix"`
+}
+
+// Route is a route of the app with its handler and middleware chain
+type Route struct {
+	Name       string       `json:"name"`
+	Method     string       `json:"method"`
+	Path       string       `json:"path"`
+	Handler    string       `json:"handler"`
+	Middleware []Middleware `json:"middleware"`
+	// Version is empty for routes outside the versioned API groups
+	Version    string `json:"version,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+}
+
+// HasMiddleware reports whether a middleware whose name contains name, case
+// insensitively, runs before the route
+func (r Route) HasMiddleware(name string) bool {
+	name = strings.ToLower(name)
+	for _, m := range r.Middleware {
+		if strings.Contains(strings.ToLower(m.Name), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the routes registered on app through a Router, in
+// registration order. The middleware chain of a route holds the Use and
+// Group middleware registered before it on a matching prefix, followed by
+// the handlers passed along with the route.
+func List(app *fiber.App) []Route {
+	value, ok := registries.Load(app)
+	if !ok {
+		return nil
+	}
+	reg := value.(*registry)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	var (
+		list    []Route
+		mounted []Middleware
+	)
+	for _, e := range reg.entries {
+		if e.route == nil {
+			mounted = append(mounted, e.mounted...)
+			continue
+		}
+		list = append(list, newRoute(*e.route, mounted))
+	}
+	return list
+}
+
+func newRoute(route Route, mounted []Middleware) Route {
+	var chain []Middleware
+	for _, m := range mounted {
+		if matchesPrefix(route.Path, m.Prefix) {
+			chain = append(chain, m)
+		}
+	}
+	route.Middleware = append(chain, route.Middleware...)
+	if route.Middleware == nil {
+		route.Middleware = []Middleware{}
+	}
+
+	route.Deprecated = apiversion.Deprecated(route.Name, route.Path)
+	if v, ok := apiversion.VersionOf(route.Path); ok {
+		route.Version = v.Name
+	}
+	return route
+}
+
+// HandlerName returns a readable name for h, e.g.
+// controllers.AuthController.Login or middleware.JWTService.AuthMiddleware
+func HandlerName(h fiber.Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+func mountPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// matchesPrefix mirrors fiber's Use matching: the prefix must be the whole
+// path or be followed by a slash
+func matchesPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/core/scheduler"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/uploads"
+)
+
+// PurgeChunkedUploads deletes the chunked uploads abandoned by their client
+// along with the chunks received
+type PurgeChunkedUploads struct{}
+
+func (PurgeChunkedUploads) Handle() (string, func()) {
+	return configutil.String("filesystems.chunked.purge_schedule", "@every 1h"), func() {
+		purged, err := uploads.PurgeExpired(context.Background())
+		if err != nil {
+			logger.Error("PurgeChunkedUploads@Handle", map[string]any{
+				"message": "failed to purge expired chunked uploads",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if purged > 0 {
+			logger.Info("PurgeChunkedUploads@Handle", map[string]any{
+				"purged": purged,
+			})
+		}
+	}
+}
+
+func init() {
+	scheduler.RegisterScheduler("purgechunkeduploads", PurgeChunkedUploads{})
+}
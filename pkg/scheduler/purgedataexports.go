@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/core/scheduler"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/exports"
+)
+
+// PurgeDataExports deletes the data exports past account.export.retention
+// along with their archive
+type PurgeDataExports struct{}
+
+func (PurgeDataExports) Handle() (string, func()) {
+	return configutil.String("account.export.purge_schedule", "@every 1h"), func() {
+		purged, err := exports.Purge(context.Background())
+		if err != nil {
+			logger.Error("PurgeDataExports@Handle", map[string]any{
+				"message": "failed to purge expired data exports",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if purged > 0 {
+			logger.Info("PurgeDataExports@Handle", map[string]any{
+				"purged": purged,
+			})
+		}
+	}
+}
+
+func init() {
+	scheduler.RegisterScheduler("purgedataexports", PurgeDataExports{})
+}
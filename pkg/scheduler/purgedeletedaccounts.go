@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/core/scheduler"
+	"github.com/galaplate/galaplate/pkg/account"
+	"github.com/galaplate/galaplate/pkg/configutil"
+)
+
+// PurgeDeletedAccounts purges the accounts deleted by their user once
+// account.deletion.grace_period has passed
+type PurgeDeletedAccounts struct{}
+
+func (PurgeDeletedAccounts) Handle() (string, func()) {
+	return configutil.String("account.deletion.purge_schedule", "@every 1h"), func() {
+		purged, err := account.Purge(context.Background())
+		if err != nil {
+			logger.Error("PurgeDeletedAccounts@Handle", map[string]any{
+				"message": "failed to purge deleted accounts",
+				"error":   err.Error(),
+			})
+		}
+		if purged > 0 {
+			logger.Info("PurgeDeletedAccounts@Handle", map[string]any{
+				"purged": purged,
+			})
+		}
+	}
+}
+
+func init() {
+	scheduler.RegisterScheduler("purgedeletedaccounts", PurgeDeletedAccounts{})
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/core/scheduler"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/idempotency"
+)
+
+// PurgeIdempotencyKeys deletes the expired rows of the idempotency_keys table
+type PurgeIdempotencyKeys struct{}
+
+func (PurgeIdempotencyKeys) Handle() (string, func()) {
+	return configutil.String("idempotency.purge_schedule", "@every 1h"), func() {
+		deleted, err := idempotency.Purge(context.Background())
+		if err != nil {
+			logger.Error("PurgeIdempotencyKeys@Handle", map[string]any{
+				"message": "failed to purge expired idempotency keys",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if deleted > 0 {
+			logger.Info("PurgeIdempotencyKeys@Handle", map[string]any{
+				"deleted": deleted,
+			})
+		}
+	}
+}
+
+func init() {
+	scheduler.RegisterScheduler("purgeidempotencykeys", PurgeIdempotencyKeys{})
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// GoogleDriveConfig configures a GoogleDriveDisk. Files go to the folder
+// FolderID, which must be shared with the service account, or to the
+// drive of the service account when it is empty.
+type GoogleDriveConfig struct {
+	ServiceAccountFile string
+	FolderID           string
+	Endpoint           string
+}
+
+// GoogleDriveDisk stores files in a Google Drive folder. Drive addresses
+// files by ID, so every file is named after its whole path, slashes
+// included, directly in the folder. Drive allows several files of the same
+// name: two concurrent Puts of a new path may both create one, and the
+// disk then reads the most recently modified. It hands out no temporary
+// URLs: downloads go through the app.
+type GoogleDriveDisk struct {
+	service *drive.Service
+	folder  string
+}
+
+func NewGoogleDriveDisk(cfg GoogleDriveConfig) (*GoogleDriveDisk, error) {
+	var options []option.ClientOption
+	// Without a service account requests are sent unauthenticated, e.g. to
+	// a stub
+	if cfg.ServiceAccountFile != "" {
+		options = append(options,
+			option.WithAuthCredentialsFile(option.ServiceAccount, cfg.ServiceAccountFile),
+			option.WithScopes(drive.DriveScope),
+		)
+	} else {
+		options = append(options, option.WithoutAuthentication())
+	}
+	if cfg.Endpoint != "" {
+		options = append(options, option.WithEndpoint(strings.TrimSuffix(cfg.Endpoint, "/")+"/drive/v3/"))
+	}
+
+	service, err := drive.NewService(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: google drive client: %w", err)
+	}
+
+	folder := cfg.FolderID
+	if folder == "" {
+		folder = "root"
+	}
+	return &GoogleDriveDisk{service: service, folder: folder}, nil
+}
+
+// quote quotes s for a Drive search query
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// find returns the file stored under path, or nil
+func (d *GoogleDriveDisk) find(ctx context.Context, path string) (*drive.File, error) {
+	list, err := d.service.Files.List().
+		Q(fmt.Sprintf("name = %s and %s in parents and trashed = false", quote(path), quote(d.folder))).
+		OrderBy("modifiedTime desc").
+		PageSize(1).
+		Fields("files(id)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Files) == 0 {
+		return nil, nil
+	}
+	return list.Files[0], nil
+}
+
+func (d *GoogleDriveDisk) Put(ctx context.Context, path string, body io.ReadSeeker, contentType string) error {
+	file, err := d.find(ctx, path)
+	if err == nil && file != nil {
+		_, err = d.service.Files.Update(file.Id, &drive.File{MimeType: contentType}).
+			Media(body, googleapi.ContentType(contentType)).
+			SupportsAllDrives(true).
+			Context(ctx).Do()
+	} else if err == nil {
+		_, err = d.service.Files.Create(&drive.File{Name: path, Parents: []string{d.folder}, MimeType: contentType}).
+			Media(body, googleapi.ContentType(contentType)).
+			SupportsAllDrives(true).
+			Context(ctx).Do()
+	}
+	if err != nil {
+		return fmt.Errorf("storage: put %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *GoogleDriveDisk) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	file, err := d.find(ctx, path)
+	if err == nil && file == nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get %s: %w", path, err)
+	}
+
+	resp, err := d.service.Files.Get(file.Id).SupportsAllDrives(true).Context(ctx).Download()
+	if isGoogleNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get %s: %w", path, err)
+	}
+	return resp.Body, nil
+}
+
+func (d *GoogleDriveDisk) Delete(ctx context.Context, path string) error {
+	file, err := d.find(ctx, path)
+	if err == nil && file == nil {
+		return nil
+	}
+	if err == nil {
+		err = d.service.Files.Delete(file.Id).SupportsAllDrives(true).Context(ctx).Do()
+	}
+	if err != nil && !isGoogleNotFound(err) {
+		return fmt.Errorf("storage: delete %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *GoogleDriveDisk) Exists(ctx context.Context, path string) (bool, error) {
+	file, err := d.find(ctx, path)
+	if err != nil {
+		return false, fmt.Errorf("storage: head %s: %w", path, err)
+	}
+	return file != nil, nil
+}
+
+// List reads every file of the folder: Drive cannot search names by prefix
+func (d *GoogleDriveDisk) List(ctx context.Context, prefix string) ([]Entry, error) {
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix += "/"
+	}
+
+	entries := make([]Entry, 0)
+	call := d.service.Files.List().
+		Q(fmt.Sprintf("%s in parents and trashed = false", quote(d.folder))).
+		Fields("nextPageToken", "files(name,size,modifiedTime)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	err := call.Pages(ctx, func(page *drive.FileList) error {
+		for _, file := range page.Files {
+			if !strings.HasPrefix(file.Name, prefix) {
+				continue
+			}
+			modified, _ := time.Parse(time.RFC3339, file.ModifiedTime)
+			entries = append(entries, Entry{Path: file.Name, Size: file.Size, LastModified: modified})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: list %s: %w", prefix, err)
+	}
+	// Duplicates of a path are listed once, as the one Get reads
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if n := strings.Compare(a.Path, b.Path); n != 0 {
+			return n
+		}
+		return b.LastModified.Compare(a.LastModified)
+	})
+	return slices.CompactFunc(entries, func(a, b Entry) bool {
+		return a.Path == b.Path
+	}), nil
+}
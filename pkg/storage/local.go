@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LocalDisk stores files in a directory of the local filesystem
+type LocalDisk struct {
+	root string
+}
+
+func NewLocalDisk(root string) *LocalDisk {
+	return &LocalDisk{root: root}
+}
+
+// Root returns the directory holding the files of the disk
+func (d *LocalDisk) Root() string {
+	return d.root
+}
+
+// Path returns the filesystem path of path, e.g. to serve it with
+// fiber's SendFile
+func (d *LocalDisk) Path(path string) (string, error) {
+	return d.resolve(path)
+}
+
+// resolve maps path to the filesystem, refusing paths that would leave the
+// root such as "../.env"
+func (d *LocalDisk) resolve(path string) (string, error) {
+	local := filepath.FromSlash(path)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("storage: invalid path %q", path)
+	}
+	return filepath.Join(d.root, local), nil
+}
+
+func (d *LocalDisk) Put(ctx context.Context, path string, body io.ReadSeeker, contentType string) error {
+	target, err := d.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("storage: create directory: %w", err)
+	}
+
+	// Write next to the target and rename, so that readers never see a
+	// partial file
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("storage: create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("storage: write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("storage: write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("storage: write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *LocalDisk) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	target, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (d *LocalDisk) Delete(ctx context.Context, path string) error {
+	target, err := d.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("storage: delete %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *LocalDisk) Exists(ctx context.Context, path string) (bool, error) {
+	target, err := d.resolve(path)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (d *LocalDisk) List(ctx context.Context, prefix string) ([]Entry, error) {
+	dir := d.root
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		var err error
+		if dir, err = d.resolve(prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]Entry, 0)
+	err := filepath.WalkDir(dir, func(target string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// A missing prefix lists nothing, like an S3 prefix
+			if target == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		// Skip directories and the temporary files of Put in progress
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d.root, target)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{Path: filepath.ToSlash(rel), Size: info.Size(), LastModified: info.ModTime()})
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: list %s: %w", prefix, err)
+	}
+	// WalkDir visits "a/b" before "a.txt"
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return entries, nil
+}
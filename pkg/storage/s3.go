@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Config configures an S3Disk. Endpoint and PathStyle point the disk at
+// S3 compatible services such as MinIO.
+type S3Config struct {
+	Region       string
+	Bucket       string
+	Key          string
+	Secret       string
+	Endpoint     string
+	PathStyle    bool
+	PathPrefix   string
+	ACL          string
+	StorageClass string
+}
+
+// S3Disk stores files as objects of an S3 bucket
+type S3Disk struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	config  S3Config
+}
+
+func NewS3Disk(cfg S3Config) (*S3Disk, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage: s3 bucket is not configured")
+	}
+
+	options := s3.Options{
+		Region:       cfg.Region,
+		UsePathStyle: cfg.PathStyle,
+		BaseEndpoint: optional(cfg.Endpoint),
+		// Checksums are only sent when the operation requires them, since
+		// not every S3 compatible service accepts them
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		ResponseChecksumValidation: aws.ResponseChecksumValidationWhenRequired,
+	}
+	// Without a key requests are sent unsigned, e.g. to a public bucket
+	if cfg.Key != "" {
+		options.Credentials = credentials.NewStaticCredentialsProvider(cfg.Key, cfg.Secret, "")
+	}
+	client := s3.New(options)
+	return &S3Disk{client: client, presign: s3.NewPresignClient(client), config: cfg}, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+func (d *S3Disk) key(path string) string {
+	if d.config.PathPrefix == "" {
+		return path
+	}
+	return strings.TrimSuffix(d.config.PathPrefix, "/") + "/" + path
+}
+
+func (d *S3Disk) Put(ctx context.Context, path string, body io.ReadSeeker, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(d.config.Bucket),
+		Key:         aws.String(d.key(path)),
+		Body:        body,
+		ContentType: optional(contentType),
+	}
+	if d.config.ACL != "" {
+		input.ACL = types.ObjectCannedACL(d.config.ACL)
+	}
+	if d.config.StorageClass != "" {
+		input.StorageClass = types.StorageClass(d.config.StorageClass)
+	}
+
+	if _, err := d.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("storage: put %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *S3Disk) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	out, err := d.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(d.config.Bucket),
+		Key:    aws.String(d.key(path)),
+	})
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get %s: %w", path, err)
+	}
+	return out.Body, nil
+}
+
+func (d *S3Disk) Delete(ctx context.Context, path string) error {
+	_, err := d.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.config.Bucket),
+		Key:    aws.String(d.key(path)),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("storage: delete %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *S3Disk) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := d.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(d.config.Bucket),
+		Key:    aws.String(d.key(path)),
+	})
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("storage: head %s: %w", path, err)
+	}
+	return true, nil
+}
+
+func (d *S3Disk) List(ctx context.Context, prefix string) ([]Entry, error) {
+	root := d.key("")
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		root = d.key(prefix) + "/"
+	}
+
+	entries := make([]Entry, 0)
+	pages := s3.NewListObjectsV2Paginator(d.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.config.Bucket),
+		Prefix: optional(root),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("storage: list %s: %w", prefix, err)
+		}
+		for _, object := range page.Contents {
+			entries = append(entries, Entry{
+				Path:         strings.TrimPrefix(aws.ToString(object.Key), d.key("")),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// TemporaryURL presigns a GET of path valid for expires
+func (d *S3Disk) TemporaryURL(ctx context.Context, path string, expires time.Duration, opts URLOptions) (string, error) {
+	req, err := d.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String(d.config.Bucket),
+		Key:                        aws.String(d.key(path)),
+		ResponseContentType:        optional(opts.ContentType),
+		ResponseContentDisposition: optional(opts.ContentDisposition),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("storage: presign %s: %w", path, err)
+	}
+	return req.URL, nil
+}
+
+func isNotFound(err error) bool {
+	var response *awshttp.ResponseError
+	return errors.As(err, &response) && response.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/configutil"
+)
+
+// ErrNotFound is returned by Get when the path does not exist on the disk
+var ErrNotFound = errors.New("storage: file not found")
+
+// Disk stores files under slash separated paths relative to its root
+type Disk interface {
+	// Put writes body to path, replacing any file already there. body is
+	// seekable so that drivers may read it more than once, e.g. to sign it.
+	Put(ctx context.Context, path string, body io.ReadSeeker, contentType string) error
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
+	// Delete removes path; deleting a missing file is not an error
+	Delete(ctx context.Context, path string) error
+	Exists(ctx context.Context, path string) (bool, error)
+}
+
+// URLSigner is implemented by disks able to hand out temporary URLs of
+// their own, which clients download from without going through the app
+type URLSigner interface {
+	TemporaryURL(ctx context.Context, path string, expires time.Duration, opts URLOptions) (string, error)
+}
+
+// Lister is implemented by disks able to enumerate their files, which the
+// storage console commands need
+type Lister interface {
+	// List returns the files under the directory prefix, sorted by path;
+	// an empty prefix lists the whole disk
+	List(ctx context.Context, prefix string) ([]Entry, error)
+}
+
+// Entry is a file listed by a Lister
+type Entry struct {
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// URLOptions are the response headers the URL of a URLSigner makes the
+// download use
+type URLOptions struct {
+	ContentType        string
+	ContentDisposition string
+}
+
+var (
+	mu    sync.Mutex
+	disks = map[string]Disk{}
+)
+
+// DefaultDisk returns the name of the disk used when none is given
+func DefaultDisk() string {
+	return configutil.String("filesystems.default", "local")
+}
+
+// Open returns the disk configured under filesystems.disks.<name> in
+// config/filesystems.yaml; an empty name opens the default disk. Disks are
+// created on first use and shared afterwards.
+func Open(name string) (Disk, error) {
+	if name == "" {
+		name = DefaultDisk()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if disk, ok := disks[name]; ok {
+		return disk, nil
+	}
+
+	disk, err := newDisk(name)
+	if err != nil {
+		return nil, err
+	}
+	disks[name] = disk
+	return disk, nil
+}
+
+// Reset forgets the opened disks so that they are created again from the
+// current config
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	disks = map[string]Disk{}
+}
+
+func newDisk(name string) (Disk, error) {
+	key := "filesystems.disks." + name
+	if configutil.Map(key) == nil {
+		return nil, fmt.Errorf("storage: disk %q is not configured", name)
+	}
+
+	switch driver := configutil.String(key+".driver", name); driver {
+	case "local":
+		return NewLocalDisk(configutil.String(key+".path", "storage/app/uploads")), nil
+	case "s3":
+		return NewS3Disk(S3Config{
+			Region:       configutil.String(key+".region", "us-east-1"),
+			Bucket:       configutil.String(key+".bucket", ""),
+			Key:          configutil.String(key+".key", ""),
+			Secret:       configutil.String(key+".secret", ""),
+			Endpoint:     configutil.String(key+".endpoint", ""),
+			PathStyle:    configutil.Bool(key+".use_path_style_endpoint", false),
+			PathPrefix:   configutil.String(key+".path_prefix", ""),
+			ACL:          configutil.String(key+".acl", ""),
+			StorageClass: configutil.String(key+".storage_class", ""),
+		})
+	case "gcs":
+		return NewGCSDisk(GCSConfig{
+			Bucket:     configutil.String(key+".bucket", ""),
+			KeyFile:    configutil.String(key+".key_file", ""),
+			Endpoint:   configutil.String(key+".endpoint", ""),
+			PathPrefix: configutil.String(key+".path_prefix", ""),
+		})
+	case "google_drive":
+		return NewGoogleDriveDisk(GoogleDriveConfig{
+			ServiceAccountFile: configutil.String(key+".service_account_file", ""),
+			FolderID:           configutil.String(key+".folder_id", ""),
+			Endpoint:           configutil.String(key+".endpoint", ""),
+		})
+	default:
+		return nil, fmt.Errorf("storage: driver %q of disk %q is not supported", driver, name)
+	}
+}
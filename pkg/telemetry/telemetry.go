@@ -0,0 +1,133 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/galaplate/core/logger"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/galaplate/galaplate/pkg/telemetry"
+
+var (
+	initOnce sync.Once
+	provider *sdktrace.TracerProvider
+	output   io.Closer
+)
+
+// Init configures the global tracer provider from config/telemetry.yaml.
+// It is safe to call more than once; only the first call has an effect.
+// When telemetry is disabled the OpenTelemetry no-op provider stays in
+// place, so instrumented code costs next to nothing.
+func Init() {
+	initOnce.Do(func() {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		))
+
+		if !configutil.Bool("telemetry.enabled", false) {
+			return
+		}
+
+		exporter, err := newExporter(configutil.String("telemetry.exporter", "stdout"))
+		if err != nil {
+			logger.Error("telemetry@Init", map[string]any{
+				"error": err.Error(),
+			})
+			return
+		}
+		if exporter == nil {
+			return
+		}
+
+		res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(configutil.String("telemetry.service_name", "galaplate")),
+		))
+		if err != nil {
+			res = resource.Default()
+		}
+
+		provider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(
+				sdktrace.TraceIDRatioBased(configutil.Float("telemetry.sample_ratio", 1)),
+			)),
+		)
+		otel.SetTracerProvider(provider)
+	})
+}
+
+// Shutdown flushes pending spans and stops the exporter
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+
+	err := provider.Shutdown(ctx)
+	if output != nil {
+		output.Close()
+	}
+	return err
+}
+
+// Tracer returns the tracer used by the instrumentation of this package.
+// Application code can use it to create its own spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+func newExporter(name string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "otlp":
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(configutil.String("telemetry.otlp.endpoint", "localhost:4318")),
+		}
+		if configutil.Bool("telemetry.otlp.insecure", true) {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if headers := configutil.Map("telemetry.otlp.headers"); len(headers) > 0 {
+			values := make(map[string]string, len(headers))
+			for key, value := range headers {
+				values[key] = fmt.Sprintf("%v", value)
+			}
+			opts = append(opts, otlptracehttp.WithHeaders(values))
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	case "file":
+		path := configutil.String("telemetry.file.path", "./storage/traces/traces.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create traces directory: %w", err)
+		}
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open traces file: %w", err)
+		}
+		output = file
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+
+	case "none", "":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown telemetry exporter %q", name)
+}
@@ -0,0 +1,229 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error codes of rejected chunks
+const (
+	CodeChunkTooLarge    = "chunk_too_large"
+	CodeOffsetMismatch   = "upload_offset_mismatch"
+	CodeUploadIncomplete = "upload_incomplete"
+)
+
+// Begin starts a chunked upload of size bytes for userID. Its chunks are
+// sent with Append and the file is stored by Complete, unless the upload
+// is left idle for longer than filesystems.chunked.expiration.
+func Begin(ctx context.Context, userID uint, collection, name string, size int64, rules Rules) (*models.ChunkedUpload, error) {
+	if err := checkSize(size, rules); err != nil {
+		return nil, err
+	}
+
+	upload := &models.ChunkedUpload{
+		UserID:     userID,
+		Collection: collection,
+		Name:       cleanName(name),
+		Size:       size,
+		ExpiresAt:  chunkExpiry(),
+	}
+	if err := database.Connect.WithContext(ctx).Create(upload).Error; err != nil {
+		return nil, apperror.Internal(fmt.Errorf("create upload: %w", err))
+	}
+	return upload, nil
+}
+
+// Append writes chunk at offset, which must be the number of bytes
+// received so far. A client that lost track of the offset after a failed
+// request gets it back from the upload.
+func Append(ctx context.Context, upload *models.ChunkedUpload, offset int64, chunk []byte) error {
+	if offset != upload.Received {
+		return offsetMismatch(upload.Received)
+	}
+	if len(chunk) == 0 {
+		return apperror.Validation("The chunk is empty", map[string]string{
+			"body": "The chunk is empty",
+		})
+	}
+	if maxChunk := configutil.Int("filesystems.chunked.max_chunk_size", 5<<20); maxChunk > 0 && len(chunk) > maxChunk {
+		return apperror.New(fiber.StatusRequestEntityTooLarge, CodeChunkTooLarge,
+			fmt.Sprintf("Chunks must not be larger than %d bytes", maxChunk))
+	}
+	end := offset + int64(len(chunk))
+	if end > upload.Size {
+		return apperror.New(fiber.StatusRequestEntityTooLarge, CodeFileTooLarge,
+			fmt.Sprintf("The chunk goes past the size of the upload, %d bytes", upload.Size))
+	}
+
+	unlock := lockUpload(upload.ID)
+	defer unlock()
+
+	db := database.Connect.WithContext(ctx)
+	var current models.ChunkedUpload
+	if err := db.Select("received").First(&current, upload.ID).Error; err != nil {
+		return apperror.NotFound("Upload not found")
+	}
+	if current.Received != offset {
+		return offsetMismatch(current.Received)
+	}
+
+	// The range is written before received moves past it, so that a
+	// concurrent Complete never reads bytes that are not there yet. The
+	// update only succeeds from offset, in case another process appended
+	// meanwhile.
+	if err := writeChunk(upload, offset, chunk); err != nil {
+		return apperror.Internal(err)
+	}
+	expiresAt := chunkExpiry()
+	result := db.Model(&models.ChunkedUpload{}).
+		Where("id = ? AND received = ?", upload.ID, offset).
+		Updates(map[string]any{"received": end, "expires_at": expiresAt})
+	if result.Error != nil {
+		return apperror.Internal(fmt.Errorf("update upload: %w", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		if err := db.First(&current, upload.ID).Error; err != nil {
+			return apperror.NotFound("Upload not found")
+		}
+		return offsetMismatch(current.Received)
+	}
+
+	upload.Received = end
+	upload.ExpiresAt = expiresAt
+	return nil
+}
+
+// appending holds a mutex per upload being appended to, so that the
+// requests of this process do not write over a range another one is
+// writing
+var appending sync.Map
+
+func lockUpload(id uint) func() {
+	mu, _ := appending.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func writeChunk(upload *models.ChunkedUpload, offset int64, chunk []byte) error {
+	path := chunkPath(upload)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create chunk directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open chunk file: %w", err)
+	}
+	if _, err := file.WriteAt(chunk, offset); err != nil {
+		file.Close()
+		return fmt.Errorf("write chunk: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write chunk: %w", err)
+	}
+	return nil
+}
+
+// Complete checks the received upload against rules and the checksum sent
+// by the client, if any, then stores it like Store and ends the upload
+func Complete(ctx context.Context, upload *models.ChunkedUpload, checksum string, rules Rules) (*models.File, error) {
+	if upload.Received != upload.Size {
+		return nil, apperror.New(fiber.StatusConflict, CodeUploadIncomplete,
+			fmt.Sprintf("Only %d of %d bytes were received", upload.Received, upload.Size))
+	}
+
+	content, err := os.Open(chunkPath(upload))
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("open chunk file: %w", err))
+	}
+	file, err := Save(ctx, Source{Content: content, Size: upload.Size, Name: upload.Name, Checksum: checksum}, &upload.UserID, upload.Collection, rules)
+	content.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// When another request completed the upload meanwhile, only its file
+	// is kept
+	finished, finishErr := finish(ctx, upload)
+	if finishErr != nil || !finished {
+		if err := Delete(ctx, file); err != nil {
+			logging.FromContext(ctx).Warn("could not delete duplicate upload", map[string]any{"file_id": file.ID, "error": err.Error()})
+		}
+		if finishErr != nil {
+			return nil, apperror.Internal(finishErr)
+		}
+		return nil, apperror.NotFound("Upload not found")
+	}
+	return file, nil
+}
+
+// Abort ends the upload and deletes what was received
+func Abort(ctx context.Context, upload *models.ChunkedUpload) error {
+	if _, err := finish(ctx, upload); err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// PurgeExpired aborts the uploads idle for longer than
+// filesystems.chunked.expiration and returns how many were aborted
+func PurgeExpired(ctx context.Context) (int64, error) {
+	var expired []models.ChunkedUpload
+	if err := database.Connect.WithContext(ctx).Where("expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+		return 0, fmt.Errorf("find expired uploads: %w", err)
+	}
+
+	var purged int64
+	for i := range expired {
+		finished, err := finish(ctx, &expired[i])
+		if err != nil {
+			return purged, err
+		}
+		if finished {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// finish deletes the upload and its temporary file, and reports whether
+// this call deleted it
+func finish(ctx context.Context, upload *models.ChunkedUpload) (bool, error) {
+	result := database.Connect.WithContext(ctx).Delete(&models.ChunkedUpload{}, upload.ID)
+	if result.Error != nil {
+		return false, fmt.Errorf("delete upload: %w", result.Error)
+	}
+	appending.Delete(upload.ID)
+	if err := os.Remove(chunkPath(upload)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logging.FromContext(ctx).Warn("could not delete chunk file", map[string]any{"upload_id": upload.ID, "error": err.Error()})
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func offsetMismatch(received int64) error {
+	return apperror.New(fiber.StatusConflict, CodeOffsetMismatch,
+		fmt.Sprintf("The upload continues at offset %d", received))
+}
+
+func chunkExpiry() time.Time {
+	return time.Now().Add(configutil.Duration("filesystems.chunked.expiration", 24*time.Hour))
+}
+
+// chunkPath is the temporary file holding the bytes received for upload
+func chunkPath(upload *models.ChunkedUpload) string {
+	dir := configutil.String("filesystems.chunked.path", "storage/app/chunks")
+	return filepath.Join(dir, strconv.FormatUint(uint64(upload.ID), 10)+".part")
+}
@@ -0,0 +1,231 @@
+package uploads
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/configutil"
+	"github.com/galaplate/galaplate/pkg/images"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/logging"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Error codes of rejected uploads
+const (
+	CodeFileTooLarge        = "file_too_large"
+	CodeUnsupportedFileType = "unsupported_file_type"
+	CodeChecksumMismatch    = "checksum_mismatch"
+)
+
+// Rules limit what an upload may contain and where it is stored
+type Rules struct {
+	// Disk is a disk of config/filesystems.yaml, the default disk if empty
+	Disk    string
+	MaxSize int64
+	// AllowedTypes lists the MIME types accepted, as detected from the
+	// content of the file rather than from its name or Content-Type
+	AllowedTypes []string
+}
+
+// LoadRules reads the rules under key in config/filesystems.yaml, e.g.
+// "avatars". Keys missing there fall back to the top level max_size and
+// allowed_types; an empty key reads the top level rules alone.
+func LoadRules(key string) Rules {
+	rules := Rules{
+		Disk:         storage.DefaultDisk(),
+		MaxSize:      int64(configutil.Int("filesystems.max_size", 10<<20)),
+		AllowedTypes: configutil.Strings("filesystems.allowed_types", nil),
+	}
+	if key == "" {
+		return rules
+	}
+
+	prefix := "filesystems." + key
+	rules.Disk = configutil.String(prefix+".disk", rules.Disk)
+	rules.MaxSize = int64(configutil.Int(prefix+".max_size", int(rules.MaxSize)))
+	rules.AllowedTypes = configutil.Strings(prefix+".allowed_types", rules.AllowedTypes)
+	return rules
+}
+
+// FormFile returns the file sent in the multipart field name, or a 422 error
+// when it is missing
+func FormFile(c *fiber.Ctx, name string) (*multipart.FileHeader, error) {
+	header, err := c.FormFile(name)
+	if err != nil {
+		return nil, apperror.Validation("The "+name+" field is required", map[string]string{
+			name: "The " + name + " field is required",
+		})
+	}
+	return header, nil
+}
+
+// Source is the content of an upload, however it was received
+type Source struct {
+	Content io.ReadSeeker
+	Size    int64
+	// Name is the file name sent by the client
+	Name string
+	// Checksum is the SHA-256 of the content as computed by the client,
+	// checked when not empty
+	Checksum string
+}
+
+// Store checks header against rules, writes it to the disk of rules under
+// collection/ and records it in the files table
+func Store(ctx context.Context, header *multipart.FileHeader, userID *uint, collection string, rules Rules) (*models.File, error) {
+	src, err := header.Open()
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("open upload: %w", err))
+	}
+	defer src.Close()
+
+	return Save(ctx, Source{Content: src, Size: header.Size, Name: header.Filename}, userID, collection, rules)
+}
+
+// Save is Store for content received by other means than a multipart
+// form, such as chunked uploads
+func Save(ctx context.Context, src Source, userID *uint, collection string, rules Rules) (*models.File, error) {
+	if err := checkSize(src.Size, rules); err != nil {
+		return nil, err
+	}
+
+	detected, err := mimetype.DetectReader(src.Content)
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("detect type: %w", err))
+	}
+	if !slices.ContainsFunc(rules.AllowedTypes, detected.Is) {
+		mimeType, _, _ := strings.Cut(detected.String(), ";")
+		return nil, apperror.New(fiber.StatusUnsupportedMediaType, CodeUnsupportedFileType,
+			fmt.Sprintf("Files of type %s are not allowed", mimeType))
+	}
+
+	hash := sha256.New()
+	if _, err := src.Content.Seek(0, io.SeekStart); err != nil {
+		return nil, apperror.Internal(fmt.Errorf("read upload: %w", err))
+	}
+	if _, err := io.Copy(hash, src.Content); err != nil {
+		return nil, apperror.Internal(fmt.Errorf("read upload: %w", err))
+	}
+	if _, err := src.Content.Seek(0, io.SeekStart); err != nil {
+		return nil, apperror.Internal(fmt.Errorf("read upload: %w", err))
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if src.Checksum != "" && !strings.EqualFold(src.Checksum, checksum) {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity, CodeChecksumMismatch,
+			"The checksum of the file does not match the checksum sent")
+	}
+
+	disk, err := storage.Open(rules.Disk)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	mimeType, _, _ := strings.Cut(detected.String(), ";")
+	file := &models.File{
+		UserID:     userID,
+		Collection: collection,
+		Disk:       rules.Disk,
+		// Named after the detected type so that a file never gets an
+		// extension it does not match
+		Path:     fmt.Sprintf("%s/%s/%s%s", collection, time.Now().Format("2006/01"), uuid.NewString(), detected.Extension()),
+		Name:     cleanName(src.Name),
+		MimeType: mimeType,
+		Size:     src.Size,
+		Checksum: checksum,
+	}
+	if err := disk.Put(ctx, file.Path, src.Content, file.MimeType); err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	if err := database.Connect.WithContext(ctx).Create(file).Error; err != nil {
+		if err := disk.Delete(ctx, file.Path); err != nil {
+			logging.FromContext(ctx).Warn("could not delete orphaned upload", map[string]any{"path": file.Path, "error": err.Error()})
+		}
+		return nil, apperror.Internal(fmt.Errorf("save file: %w", err))
+	}
+
+	if images.Enabled(file.MimeType) {
+		if err := jobs.Dispatch(ctx, jobs.GenerateImageVariants{}, file.ID); err != nil {
+			logging.FromContext(ctx).Warn("could not queue image variants", map[string]any{"file_id": file.ID, "error": err.Error()})
+		}
+	}
+	return file, nil
+}
+
+func checkSize(size int64, rules Rules) error {
+	if rules.MaxSize > 0 && size > rules.MaxSize {
+		return apperror.New(fiber.StatusRequestEntityTooLarge, CodeFileTooLarge,
+			fmt.Sprintf("The file must not be larger than %d bytes", rules.MaxSize))
+	}
+	return nil
+}
+
+// Delete removes the file and its image variants from its disk and from
+// the files table
+func Delete(ctx context.Context, file *models.File) error {
+	if err := images.Delete(ctx, file); err != nil {
+		return err
+	}
+	disk, err := storage.Open(file.Disk)
+	if err != nil {
+		return err
+	}
+	if err := disk.Delete(ctx, file.Path); err != nil {
+		return err
+	}
+	if err := database.Connect.WithContext(ctx).Delete(&models.File{}, file.ID).Error; err != nil {
+		return fmt.Errorf("delete file: %w", err)
+	}
+	return nil
+}
+
+// cleanName keeps the base name of the client's file name, which is only
+// ever displayed
+func cleanName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+	// Keep the end of long names, where the extension is
+	for len(name) > 255 {
+		_, size := utf8.DecodeRuneInString(name)
+		name = name[size:]
+	}
+	return name
+}
+
+// BodyLimit is the request body size fiber must accept for uploads of up
+// to filesystems.max_size. The fiber config is built before the config
+// files are loaded, so FILESYSTEM_MAX_SIZE is read from the environment.
+func BodyLimit() int {
+	maxSize, err := strconv.Atoi(os.Getenv("FILESYSTEM_MAX_SIZE"))
+	if err != nil || maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+	// Leaves room for the multipart boundaries and the other fields
+	return max(maxSize+1<<20, fiber.DefaultBodyLimit)
+}
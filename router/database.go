@@ -0,0 +1,25 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/telemetry"
+)
+
+// SetupDatabase registers the GORM plugins on database.Connect and opts
+// the audited models in. It runs before SetupRouter, and the app must not
+// start without it: a missing plugin silently turns tracing or auditing
+// off.
+func SetupDatabase() error {
+	if err := database.Connect.Use(&telemetry.GormPlugin{}); err != nil {
+		return fmt.Errorf("register telemetry plugin: %w", err)
+	}
+	if err := database.Connect.Use(&audit.GormPlugin{}); err != nil {
+		return fmt.Errorf("register audit plugin: %w", err)
+	}
+	audit.Track(&models.User{}, audit.Options{})
+	return nil
+}
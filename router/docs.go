@@ -0,0 +1,474 @@
+package router
+
+import (
+	"mime/multipart"
+
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/dto"
+	"github.com/galaplate/galaplate/pkg/health"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/galaplate/galaplate/pkg/query"
+	"github.com/gofiber/fiber/v2"
+)
+
+type logExportQuery struct {
+	File      string `query:"file" validate:"required" doc:"Log file name, e.g. app.2025-01-31.log"`
+	Format    string `query:"format" validate:"required,oneof=json csv"`
+	DateFrom  string `query:"date_from" doc:"Only entries on or after this date (YYYY-MM-DD)"`
+	DateTo    string `query:"date_to" doc:"Only entries on or before this date (YYYY-MM-DD)"`
+	RequestID string `query:"request_id" doc:"Only entries of this request, across all files"`
+}
+
+type logCleanupQuery struct {
+	Days int `query:"days" validate:"min=1" doc:"Delete files older than this many days (default 30)"`
+}
+
+type logCleanupResult struct {
+	Success       bool    `json:"success"`
+	DeletedCount  int     `json:"deleted_count"`
+	TotalSizeMB   float64 `json:"total_size_mb"`
+	CutoffDate    string  `json:"cutoff_date"`
+	RetentionDays int     `json:"retention_days"`
+}
+
+type logStatsResult struct {
+	Success     bool    `json:"success"`
+	TotalFiles  int     `json:"total_files"`
+	TotalSizeMB float64 `json:"total_size_mb"`
+	OldestDate  string  `json:"oldest_date"`
+	NewestDate  string  `json:"newest_date"`
+}
+
+type adminUserQuery struct {
+	Search  string `query:"search" doc:"Matches the username and the email"`
+	Trashed string `query:"trashed" validate:"omitempty,oneof=with only" doc:"with includes deleted users, only lists them alone"`
+	query.ListQuery
+	Status          string `query:"filter[status]" validate:"omitempty,oneof=true false"`
+	Role            string `query:"filter[role]" validate:"omitempty,oneof=user admin"`
+	RoleIn          string `query:"filter[role][in]" doc:"Comma separated roles"`
+	CreatedAfter    string `query:"filter[created_at][gte]" doc:"RFC 3339 time or date"`
+	CreatedBefore   string `query:"filter[created_at][lt]" doc:"RFC 3339 time or date"`
+	EmailUnverified string `query:"filter[email_verified_at][null]" validate:"omitempty,oneof=true false"`
+}
+
+type auditLogQuery struct {
+	query.ListQuery
+	Action        string `query:"filter[action]"`
+	ActionIn      string `query:"filter[action][in]" doc:"Comma separated actions"`
+	ActorID       string `query:"filter[actor_id]"`
+	CreatedAfter  string `query:"filter[created_at][gte]" doc:"RFC 3339 time or date"`
+	CreatedBefore string `query:"filter[created_at][lt]" doc:"RFC 3339 time or date"`
+}
+
+type fileURLQuery struct {
+	Disposition string `query:"disposition" validate:"omitempty,oneof=attachment inline" doc:"attachment (default) makes browsers save the file, inline lets them display it"`
+	Variant     string `query:"variant" doc:"Name of an image variant, such as thumb, to download instead of the file"`
+}
+
+type fileMetadataQuery struct {
+	Disposition string `query:"disposition" validate:"omitempty,oneof=attachment inline" doc:"Disposition of the returned URLs, attachment (default) or inline"`
+}
+
+type fileDownloadQuery struct {
+	User        string `query:"user" validate:"required" doc:"User the URL was issued to"`
+	Expires     string `query:"expires" validate:"required" doc:"Unix time the URL expires at"`
+	Disposition string `query:"disposition" validate:"required,oneof=attachment inline"`
+	Variant     string `query:"variant" doc:"Image variant the URL was issued for"`
+	Signature   string `query:"signature" validate:"required"`
+}
+
+var uploadOffsetHeader = map[string]string{
+	controllers.HeaderUploadOffset: "Offset of the chunk, the number of bytes received so far; also sent in responses",
+}
+
+var idempotencyHeader = map[string]string{
+	"Idempotency-Key": "Unique key making the request safe to retry; retries with the same key get the first response back",
+}
+
+// describeRoutes documents the named routes for the OpenAPI document served
+// at /openapi.json. Undescribed routes are still listed, without schemas.
+func describeRoutes() {
+	openapi.Describe("home", openapi.Operation{Hidden: true})
+	openapi.Describe("openapi.spec", openapi.Operation{Hidden: true})
+	openapi.Describe("openapi.docs", openapi.Operation{Hidden: true})
+
+	openapi.Describe("health.live", openapi.Operation{
+		Summary:   "Liveness probe",
+		Tags:      []string{"Health"},
+		Responses: map[int]any{fiber.StatusOK: openapi.Raw(map[string]string{})},
+	})
+	openapi.Describe("health.ready", openapi.Operation{
+		Summary: "Readiness probe",
+		Tags:    []string{"Health"},
+		Responses: map[int]any{
+			fiber.StatusOK:                 openapi.Raw(health.Report{}),
+			fiber.StatusServiceUnavailable: openapi.Raw(health.Report{}),
+		},
+	})
+	openapi.Describe("metrics", openapi.Operation{
+		Summary:     "Prometheus metrics",
+		Description: "Text exposition format. Requires a bearer token when http.metrics.token is set.",
+		Tags:        []string{"Monitoring"},
+		Responses:   map[int]any{fiber.StatusOK: openapi.Raw(nil)},
+		Errors:      []int{fiber.StatusUnauthorized},
+		Security:    []string{openapi.BearerAuth},
+	})
+
+	openapi.Describe("auth.register", openapi.Operation{
+		Summary:   "Register a user",
+		Tags:      []string{"Auth"},
+		Request:   dto.AuthRegisterRequest{},
+		Headers:   idempotencyHeader,
+		Responses: map[int]any{fiber.StatusCreated: controllers.AuthResponse{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusConflict,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+	})
+	openapi.Describe("auth.login", openapi.Operation{
+		Summary:   "Log in and get a JWT",
+		Tags:      []string{"Auth"},
+		Request:   dto.AuthLoginRequest{},
+		Responses: map[int]any{fiber.StatusOK: controllers.AuthResponse{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+	})
+	openapi.Describe("profile.show", openapi.Operation{
+		Summary: "Current user",
+		Tags:    []string{"Auth"},
+		Responses: map[int]any{
+			fiber.StatusOK:          models.User{},
+			fiber.StatusNotModified: openapi.Raw(nil),
+		},
+		Errors:   []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusTooManyRequests},
+		Security: []string{openapi.BearerAuth},
+	})
+	openapi.Describe("profile.update", openapi.Operation{
+		Summary:     "Update the current user",
+		Description: "Fields left out are not changed. Changing the email clears email_verified_at.",
+		Tags:        []string{"Auth"},
+		Request:     dto.ProfileUpdateRequest{},
+		Responses:   map[int]any{fiber.StatusOK: models.User{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusConflict,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+		Security: []string{openapi.BearerAuth},
+	})
+
+	openapi.Describe("profile.password", openapi.Operation{
+		Summary:     "Change the password of the current user",
+		Description: "Revokes the other tokens of the user and returns a new one. Also allowed when an administrator forced a password reset.",
+		Tags:        []string{"Auth"},
+		Request:     dto.ProfilePasswordRequest{},
+		Responses:   map[int]any{fiber.StatusOK: controllers.AuthResponse{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+		Security: []string{openapi.BearerAuth},
+	})
+
+	uploadErrors := []int{
+		fiber.StatusBadRequest,
+		fiber.StatusUnauthorized,
+		fiber.StatusForbidden,
+		fiber.StatusRequestEntityTooLarge,
+		fiber.StatusUnsupportedMediaType,
+		fiber.StatusUnprocessableEntity,
+		fiber.StatusTooManyRequests,
+	}
+	openapi.Describe("profile.avatar", openapi.Operation{
+		Summary:     "Replace the avatar of the current user",
+		Description: "Accepts the images allowed by filesystems.avatars in config/filesystems.yaml, detected from their content. The previous avatar is deleted.",
+		Tags:        []string{"Auth"},
+		Form: struct {
+			Avatar *multipart.FileHeader `json:"avatar" validate:"required"`
+		}{},
+		Responses: map[int]any{fiber.StatusOK: controllers.AvatarResponse{}},
+		Errors:    uploadErrors,
+		Security:  []string{openapi.BearerAuth},
+	})
+
+	openapi.Describe("files.store", openapi.Operation{
+		Summary:     "Upload a file",
+		Description: "Accepts files up to filesystems.max_size whose type, detected from their content, is listed in filesystems.allowed_types.",
+		Tags:        []string{"Files"},
+		Form: struct {
+			File *multipart.FileHeader `json:"file" validate:"required"`
+		}{},
+		Responses: map[int]any{fiber.StatusCreated: models.File{}},
+		Errors:    uploadErrors,
+		Security:  []string{openapi.BearerAuth},
+	})
+	openapi.Describe("files.url", openapi.Operation{
+		Summary:     "Get a temporary download URL",
+		Description: "Only the owner of the file and administrators get one. The URL expires after filesystems.downloads.expiration; it is presigned by disks that can, such as S3, and otherwise served by GET /files/{id}.",
+		Tags:        []string{"Files"},
+		Query:       fileURLQuery{},
+		Responses:   map[int]any{fiber.StatusOK: controllers.FileURLResponse{}},
+		Errors:      []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusTooManyRequests},
+		Security:    []string{openapi.BearerAuth},
+	})
+	openapi.Describe("files.metadata", openapi.Operation{
+		Summary:     "Get a file with its image variants",
+		Description: "Lists the variants of filesystems.images generated for uploaded images, in the background, with temporary download URLs for the file and each variant.",
+		Tags:        []string{"Files"},
+		Query:       fileMetadataQuery{},
+		Responses:   map[int]any{fiber.StatusOK: controllers.FileMetadataResponse{}},
+		Errors:      []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusTooManyRequests},
+		Security:    []string{openapi.BearerAuth},
+	})
+	openapi.Describe("files.show", openapi.Operation{
+		Summary:     "Download a file",
+		Description: "Takes the signed URL returned by GET /files/{id}/url rather than a token. Files of local disks support Range and conditional requests.",
+		Tags:        []string{"Files"},
+		Query:       fileDownloadQuery{},
+		Responses: map[int]any{
+			fiber.StatusOK:             openapi.Raw(nil),
+			fiber.StatusPartialContent: openapi.Raw(nil),
+			fiber.StatusNotModified:    openapi.Raw(nil),
+		},
+		Errors: []int{fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusRequestedRangeNotSatisfiable},
+	})
+
+	describeUploadRoutes()
+	describeAccountRoutes()
+	describeAdminRoutes()
+
+	openapi.Describe("test.store", openapi.Operation{
+		Summary:   "Create test data",
+		Tags:      []string{"Test"},
+		Request:   controllers.CreateTestRequest{},
+		Headers:   idempotencyHeader,
+		Responses: map[int]any{fiber.StatusCreated: map[string]any{}},
+		Errors:    []int{fiber.StatusBadRequest, fiber.StatusConflict, fiber.StatusUnprocessableEntity},
+	})
+	openapi.Describe("test.show", openapi.Operation{
+		Summary: "Show test data",
+		Tags:    []string{"Test"},
+		Responses: map[int]any{
+			fiber.StatusOK:          map[string]any{},
+			fiber.StatusNotModified: openapi.Raw(nil),
+		},
+	})
+
+	openapi.Describe("logs.index", openapi.Operation{
+		Summary:   "Log viewer page",
+		Tags:      []string{"Logs"},
+		Responses: map[int]any{fiber.StatusOK: openapi.Raw(nil)},
+	})
+	openapi.Describe("logs.export", openapi.Operation{
+		Summary:   "Export a log file as JSON or CSV",
+		Tags:      []string{"Logs"},
+		Query:     logExportQuery{},
+		Responses: map[int]any{fiber.StatusOK: openapi.Raw(nil)},
+		Errors:    []int{fiber.StatusBadRequest},
+	})
+	openapi.Describe("logs.cleanup", openapi.Operation{
+		Summary:   "Delete old log files",
+		Tags:      []string{"Logs"},
+		Query:     logCleanupQuery{},
+		Responses: map[int]any{fiber.StatusOK: openapi.Raw(logCleanupResult{})},
+	})
+	openapi.Describe("logs.stats", openapi.Operation{
+		Summary:   "Log directory statistics",
+		Tags:      []string{"Logs"},
+		Responses: map[int]any{fiber.StatusOK: openapi.Raw(logStatsResult{})},
+	})
+}
+
+func describeAdminRoutes() {
+	adminErrors := []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusTooManyRequests}
+	security := []string{openapi.BearerAuth}
+	tags := []string{"Admin"}
+
+	openapi.Describe("admin.users.index", openapi.Operation{
+		Summary:   "List users",
+		Tags:      tags,
+		Query:     adminUserQuery{},
+		Responses: map[int]any{fiber.StatusOK: openapi.Raw(query.Page[models.User]{})},
+		Errors:    []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusTooManyRequests},
+		Security:  security,
+	})
+	openapi.Describe("admin.users.show", openapi.Operation{
+		Summary:   "Show a user, deleted or not",
+		Tags:      tags,
+		Responses: map[int]any{fiber.StatusOK: models.User{}},
+		Errors:    adminErrors,
+		Security:  security,
+	})
+	openapi.Describe("admin.users.status", openapi.Operation{
+		Summary:     "Activate or deactivate a user",
+		Description: "Deactivating revokes the tokens of the user, who cannot log in until activated again.",
+		Tags:        tags,
+		Request:     dto.AdminUserStatusRequest{},
+		Responses:   map[int]any{fiber.StatusOK: models.User{}},
+		Errors:      append([]int{fiber.StatusBadRequest, fiber.StatusUnprocessableEntity}, adminErrors...),
+		Security:    security,
+	})
+	openapi.Describe("admin.users.destroy", openapi.Operation{
+		Summary:     "Soft delete a user",
+		Description: "Also revokes the tokens of the user.",
+		Tags:        tags,
+		Responses:   map[int]any{fiber.StatusOK: nil},
+		Errors:      append([]int{fiber.StatusConflict}, adminErrors...),
+		Security:    security,
+	})
+	openapi.Describe("admin.users.restore", openapi.Operation{
+		Summary:   "Restore a deleted user",
+		Tags:      tags,
+		Responses: map[int]any{fiber.StatusOK: models.User{}},
+		Errors:    append([]int{fiber.StatusConflict}, adminErrors...),
+		Security:  security,
+	})
+	openapi.Describe("admin.users.password_reset", openapi.Operation{
+		Summary:     "Force a password reset",
+		Description: "Revokes the tokens of the user, who can only change their password until they do.",
+		Tags:        tags,
+		Responses:   map[int]any{fiber.StatusOK: models.User{}},
+		Errors:      adminErrors,
+		Security:    security,
+	})
+	openapi.Describe("admin.users.revoke_tokens", openapi.Operation{
+		Summary:   "Revoke every token of a user",
+		Tags:      tags,
+		Responses: map[int]any{fiber.StatusOK: models.User{}},
+		Errors:    adminErrors,
+		Security:  security,
+	})
+	openapi.Describe("admin.audit_logs.history", openapi.Operation{
+		Summary:     "List the audit logs of a record",
+		Description: "type is the table of the record, e.g. users. Changes map each changed column to its old and new value, with secrets redacted.",
+		Tags:        tags,
+		Query:       auditLogQuery{},
+		Responses:   map[int]any{fiber.StatusOK: openapi.Raw(query.Page[controllers.AuditLogResponse]{})},
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusTooManyRequests},
+		Security:    security,
+	})
+}
+
+// describeAccountRoutes documents the data export and account deletion
+// routes
+func describeAccountRoutes() {
+	security := []string{openapi.BearerAuth}
+	tags := []string{"Account"}
+
+	openapi.Describe("account.export", openapi.Operation{
+		Summary:     "Export the data of the current user",
+		Description: "Queues a ZIP archive of the account, the uploaded files and the audit logs of the user, or returns the export still being built. Poll the export given by the Location header until its status is completed to get its download URL.",
+		Tags:        tags,
+		Responses:   map[int]any{fiber.StatusAccepted: controllers.DataExportResponse{}},
+		Errors:      []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusTooManyRequests},
+		Security:    security,
+	})
+	openapi.Describe("account.exports.show", openapi.Operation{
+		Summary:     "Show a data export",
+		Description: "Completed exports come with a temporary download URL of their archive, which is deleted after account.export.retention.",
+		Tags:        tags,
+		Responses:   map[int]any{fiber.StatusOK: controllers.DataExportResponse{}},
+		Errors:      []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusTooManyRequests},
+		Security:    security,
+	})
+	openapi.Describe("account.destroy", openapi.Operation{
+		Summary:     "Delete the account of the current user",
+		Description: "Requires the password of the user. The account is deleted and its tokens revoked at once; it is purged, with its files, after account.deletion.grace_period, until when an administrator can restore it.",
+		Tags:        tags,
+		Request:     dto.AccountDeleteRequest{},
+		Responses:   map[int]any{fiber.StatusOK: models.User{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+		Security: security,
+	})
+}
+
+// describeUploadRoutes documents the chunked upload routes
+func describeUploadRoutes() {
+	openapi.Describe("uploads.store", openapi.Operation{
+		Summary:     "Start a chunked upload",
+		Description: "For large files: send the chunks with PATCH /uploads/{id}, then store the file with POST /uploads/{id}/complete. Uploads receiving no chunk for filesystems.chunked.expiration are deleted.",
+		Tags:        []string{"Uploads"},
+		Request:     dto.UploadCreateRequest{},
+		Responses:   map[int]any{fiber.StatusCreated: models.ChunkedUpload{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusRequestEntityTooLarge,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+		Security: []string{openapi.BearerAuth},
+	})
+	openapi.Describe("uploads.show", openapi.Operation{
+		Summary:     "Show a chunked upload",
+		Description: "received is the offset to resume from after a failed chunk.",
+		Tags:        []string{"Uploads"},
+		Responses:   map[int]any{fiber.StatusOK: models.ChunkedUpload{}},
+		Errors:      []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusTooManyRequests},
+		Security:    []string{openapi.BearerAuth},
+	})
+	openapi.Describe("uploads.append", openapi.Operation{
+		Summary:     "Send the next chunk",
+		Description: "The request body is the raw chunk, of at most filesystems.chunked.max_chunk_size bytes. A chunk sent at another offset than the bytes received so far is answered with 409.",
+		Tags:        []string{"Uploads"},
+		Headers:     uploadOffsetHeader,
+		Responses:   map[int]any{fiber.StatusOK: models.ChunkedUpload{}},
+		Errors: []int{
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusNotFound,
+			fiber.StatusConflict,
+			fiber.StatusRequestEntityTooLarge,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+		Security: []string{openapi.BearerAuth},
+	})
+	openapi.Describe("uploads.complete", openapi.Operation{
+		Summary:     "Store a chunked upload",
+		Description: "Checks the file like POST /files, and against checksum when sent, then stores it and ends the upload.",
+		Tags:        []string{"Uploads"},
+		Request:     dto.UploadCompleteRequest{},
+		Responses:   map[int]any{fiber.StatusCreated: models.File{}},
+		Errors: []int{
+			fiber.StatusBadRequest,
+			fiber.StatusUnauthorized,
+			fiber.StatusForbidden,
+			fiber.StatusNotFound,
+			fiber.StatusConflict,
+			fiber.StatusRequestEntityTooLarge,
+			fiber.StatusUnsupportedMediaType,
+			fiber.StatusUnprocessableEntity,
+			fiber.StatusTooManyRequests,
+		},
+		Security: []string{openapi.BearerAuth},
+	})
+	openapi.Describe("uploads.destroy", openapi.Operation{
+		Summary:   "Cancel a chunked upload",
+		Tags:      []string{"Uploads"},
+		Responses: map[int]any{fiber.StatusOK: nil},
+		Errors:    []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusTooManyRequests},
+		Security:  []string{openapi.BearerAuth},
+	})
+}
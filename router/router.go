@@ -1,48 +1,116 @@
 package router
 
 import (
+	"github.com/galaplate/galaplate/pkg/apiversion"
+	"github.com/galaplate/galaplate/pkg/configutil"
 	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/httpcache"
+	"github.com/galaplate/galaplate/pkg/idempotency"
+	"github.com/galaplate/galaplate/pkg/metrics"
 	"github.com/galaplate/galaplate/pkg/middleware"
+	"github.com/galaplate/galaplate/pkg/policies"
+	"github.com/galaplate/galaplate/pkg/routes"
+	"github.com/galaplate/galaplate/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
 func SetupRouter(app *fiber.App) {
+	telemetry.Init()
+	policies.RegisterRateLimitPolicies()
+	apiversion.LoadVersions()
+	httpcache.Init()
 
-	app.Use(cors.New())
+	r := routes.New(app)
+	r.Use(middleware.RequestID())
+	r.Use(telemetry.Middleware())
+	r.Use(middleware.AccessLog())
+	r.Use(metrics.Middleware())
+	r.Use(middleware.SecurityHeaders())
+	r.Use(middleware.CORS())
+	r.Use(apiversion.Middleware())
 
-	app.Get("/", func(c *fiber.Ctx) error {
+	r.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Hello world")
-	})
+	}).Name("home")
 
-	// Example routes with different policy combinations
-	api := app.Group("/api")
+	var healthController = controllers.HealthControllerInstance
+	r.Get("/health/live", healthController.Live).Name("health.live")
+	r.Get("/health/ready", healthController.Ready).Name("health.ready")
 
-	logViewer := app.Group("/admin/logs")
+	if configutil.Bool("http.metrics.enabled", true) {
+		r.Get(configutil.String("http.metrics.path", "/metrics"), metrics.Handler()).Name("metrics")
+	}
+
+	if configutil.Bool("openapi.enabled", true) {
+		var docsController = controllers.DocsControllerInstance
+		r.Get(configutil.String("openapi.path", "/openapi.json"), docsController.Spec).Name("openapi.spec")
+		r.Get(configutil.String("openapi.docs_path", "/docs"), docsController.UI).Name("openapi.docs")
+	}
+
+	// Versioned API: /api/v1/... or /api/... with an Accept-Version header
+	api := r.Group(apiversion.Prefix())
+	v1 := api.Version("v1")
+
+	logViewer := r.Group("/admin/logs")
 	var logController = controllers.LogController{}
-	logViewer.Get("/", logController.Index)
-	logViewer.Get("/export", logController.Export)
-	logViewer.Post("/cleanup", logController.CleanupLogs)
-	logViewer.Get("/stats", logController.GetLogStats)
+	logViewer.Get("/", logController.Index).Name("logs.index")
+	logViewer.Get("/export", logController.Export).Name("logs.export")
+	logViewer.Post("/cleanup", logController.CleanupLogs).Name("logs.cleanup")
+	logViewer.Get("/stats", logController.GetLogStats).Name("logs.stats")
 
 	// Auth routes
 	var authController = controllers.AuthControllerInstance
-	api.Post("/register", authController.Register)
-	api.Post("/login", authController.Login)
+	v1.Post("/register", policies.RateLimit("register"), idempotency.Middleware(), authController.Register).Name("auth.register")
+	v1.Post("/login", policies.RateLimit("login"), authController.Login).Name("auth.login")
 
 	// Test routes for testing framework
 	var testController = controllers.TestControllerInstance
-	api.Get("/health", testController.GetHealthCheck)
-	api.Post("/test", testController.CreateTestData)
-	api.Get("/test/:id", testController.GetTestData)
-
-	// Protected routes (require JWT authentication)
-	api.Get("/profile", middleware.JWTAuth(), func(c *fiber.Ctx) error {
-		user := c.Locals("user")
-		return c.JSON(fiber.Map{
-			"success": true,
-			"message": "Profile data",
-			"data":    user,
-		})
-	})
+	v1.Post("/test", idempotency.Middleware(), testController.CreateTestData).Name("test.store")
+	v1.Get("/test/:id", httpcache.Cache("test"), testController.GetTestData).Name("test.show")
+
+	// Protected routes (require JWT authentication), limited per user by the
+	// api limiter
+	apiLimit := policies.RateLimit("api")
+	var profileController = controllers.ProfileControllerInstance
+	v1.Get("/profile", middleware.JWTAuth(), apiLimit, httpcache.Cache("profile"), profileController.Show).Name("profile.show")
+	v1.Patch("/profile", middleware.JWTAuth(), apiLimit, profileController.Update).Name("profile.update")
+	v1.Put("/profile/password", middleware.JWTAuthForPasswordChange(), apiLimit, profileController.ChangePassword).Name("profile.password")
+	v1.Post("/profile/avatar", middleware.JWTAuth(), apiLimit, profileController.UpdateAvatar).Name("profile.avatar")
+
+	var accountController = controllers.AccountControllerInstance
+	v1.Post("/account/export", middleware.JWTAuth(), apiLimit, accountController.Export).Name("account.export")
+	v1.Get("/account/exports/:id", middleware.JWTAuth(), apiLimit, accountController.ShowExport).Name("account.exports.show")
+	v1.Delete("/account", middleware.JWTAuth(), apiLimit, accountController.Destroy).Name("account.destroy")
+
+	var fileController = controllers.FileControllerInstance
+	v1.Post("/files", middleware.JWTAuth(), apiLimit, fileController.Store).Name("files.store")
+	v1.Get("/files/:id/url", middleware.JWTAuth(), apiLimit, fileController.URL).Name("files.url")
+	v1.Get("/files/:id/metadata", middleware.JWTAuth(), apiLimit, fileController.Metadata).Name("files.metadata")
+	// Authenticated by the signature of the URL handed out by files.url
+	v1.Get("/files/:id", fileController.Show).Name("files.show")
+
+	var uploadController = controllers.UploadControllerInstance
+	uploadsGroup := v1.Group("/uploads", middleware.JWTAuth(), apiLimit)
+	uploadsGroup.Post("/", uploadController.Store).Name("uploads.store")
+	uploadsGroup.Get("/:id", uploadController.Show).Name("uploads.show")
+	uploadsGroup.Patch("/:id", uploadController.Append).Name("uploads.append")
+	uploadsGroup.Post("/:id/complete", uploadController.Complete).Name("uploads.complete")
+	uploadsGroup.Delete("/:id", uploadController.Destroy).Name("uploads.destroy")
+
+	// Admin routes
+	var adminUserController = controllers.AdminUserControllerInstance
+	adminUsers := v1.Group("/admin/users", middleware.JWTAuth(), apiLimit, policies.Admin())
+	adminUsers.Get("/", adminUserController.Index).Name("admin.users.index")
+	adminUsers.Get("/:id", adminUserController.Show).Name("admin.users.show")
+	adminUsers.Patch("/:id/status", adminUserController.UpdateStatus).Name("admin.users.status")
+	adminUsers.Delete("/:id", adminUserController.Destroy).Name("admin.users.destroy")
+	adminUsers.Post("/:id/restore", adminUserController.Restore).Name("admin.users.restore")
+	adminUsers.Post("/:id/password-reset", adminUserController.ForcePasswordReset).Name("admin.users.password_reset")
+	adminUsers.Post("/:id/revoke-tokens", adminUserController.RevokeTokens).Name("admin.users.revoke_tokens")
+
+	var auditLogController = controllers.AuditLogControllerInstance
+	adminAuditLogs := v1.Group("/admin/audit-logs", middleware.JWTAuth(), apiLimit, policies.Admin())
+	adminAuditLogs.Get("/:type/:id", auditLogController.History).Name("admin.audit_logs.history")
+
+	describeRoutes()
 }
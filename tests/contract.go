@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Contract checks every request made through suite.App.Test against the
+// OpenAPI document of the app and fails the running test when a described
+// route drifts from its description:
+//
+//   - a successful request whose body or required query parameters do not
+//     match the documented request
+//   - a response status that is not documented
+//   - a JSON response body that does not match the documented schema
+//
+// Routes without an openapi.Describe entry are not checked.
+type Contract struct {
+	t        func() testing.TB
+	mu       sync.Mutex
+	doc      *openapi.Document
+	disabled bool
+}
+
+// Disable turns the checks off until the next test starts, for tests that
+// deliberately send responses outside the contract
+func (c *Contract) Disable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = true
+}
+
+// reset is called before each test, when a new app is created
+func (c *Contract) reset(t func() testing.TB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+	c.doc = nil
+	c.disabled = false
+}
+
+// middleware is registered before every other handler by the test suites
+func (c *Contract) middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if err := ctx.Next(); err != nil {
+			if handlerErr := ctx.App().ErrorHandler(ctx, err); handlerErr != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		c.mu.Lock()
+		if c.disabled || c.t == nil {
+			c.mu.Unlock()
+			return nil
+		}
+		// Routes are all registered by the time the first request is served
+		if c.doc == nil {
+			c.doc = openapi.Generate(ctx.App(), openapi.LoadInfo())
+		}
+		doc, t := c.doc, c.t()
+		c.mu.Unlock()
+
+		for _, violation := range check(ctx, doc) {
+			t.Errorf("OpenAPI contract: %s %s: %s", ctx.Method(), ctx.Route().Path, violation)
+		}
+		return nil
+	}
+}
+
+func check(ctx *fiber.Ctx, doc *openapi.Document) []string {
+	name := ctx.Route().Name
+	if name == "" || !openapi.Described(name) {
+		return nil
+	}
+	_, _, op := doc.Operation(name)
+	if op == nil {
+		return nil
+	}
+
+	var violations []string
+	status := ctx.Response().StatusCode()
+
+	// Only requests the app accepted have to match the documented request,
+	// so tests can still send invalid payloads to exercise validation
+	if status < fiber.StatusBadRequest {
+		for _, param := range op.Parameters {
+			if param.In == "query" && param.Required && ctx.Query(param.Name) == "" {
+				violations = append(violations, "missing required query parameter "+param.Name)
+			}
+		}
+		// Multipart bodies are not JSON; their files are checked by the handler
+		if op.RequestBody != nil && op.RequestBody.Content[fiber.MIMEMultipartForm] == nil {
+			violations = append(violations, checkBody("request", doc, op.RequestBody.Content, fiber.MIMEApplicationJSON, ctx.Body())...)
+		}
+	}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return append(violations, "undocumented response status "+strconv.Itoa(status))
+	}
+
+	contentType, _, _ := strings.Cut(string(ctx.Response().Header.ContentType()), ";")
+	return append(violations, checkBody("response", doc, response.Content, contentType, ctx.Response().Body())...)
+}
+
+func checkBody(kind string, doc *openapi.Document, content map[string]*openapi.MediaType, contentType string, body []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	media, ok := content[contentType]
+	if !ok {
+		if contentType != fiber.MIMEApplicationJSON && contentType != apperror.MIMEProblemJSON {
+			return nil
+		}
+		return []string{kind + " content type " + contentType + " is not documented"}
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{kind + " body is not valid JSON: " + err.Error()}
+	}
+
+	var violations []string
+	for _, violation := range doc.Validate(media.Schema, value) {
+		violations = append(violations, kind+" "+violation)
+	}
+	return violations
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the failures reported by the contract
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type contractGadget struct {
+	Name string `json:"name" validate:"required,min=3"`
+}
+
+type contractGadgetResult struct {
+	ID   int    `json:"id" validate:"required"`
+	Name string `json:"name"`
+}
+
+func newContractApp(t *testing.T) (*fiber.App, *Contract, *recorder) {
+	rec := &recorder{TB: t}
+	contract := &Contract{}
+	contract.reset(func() testing.TB { return rec })
+
+	cfg := fiber.Config{}
+	apperror.Configure(&cfg)
+	app := fiber.New(cfg)
+	app.Use(contract.middleware())
+
+	app.Post("/gadgets", func(c *fiber.Ctx) error {
+		switch c.Query("respond") {
+		case "bad-id":
+			return c.Status(201).JSON(fiber.Map{"success": true, "data": fiber.Map{"id": "one"}})
+		case "crash":
+			return fmt.Errorf("boom")
+		case "conflict":
+			return apperror.Conflict("Gadget exists")
+		}
+		return c.Status(201).JSON(fiber.Map{"success": true, "data": fiber.Map{"id": 1, "name": "gizmo"}})
+	}).Name("contract.gadgets.store")
+	app.Get("/undescribed", func(c *fiber.Ctx) error {
+		return c.SendStatus(418)
+	}).Name("contract.undescribed")
+
+	openapi.Describe("contract.gadgets.store", openapi.Operation{
+		Request:   contractGadget{},
+		Responses: map[int]any{201: contractGadgetResult{}},
+		Errors:    []int{409},
+	})
+
+	return app, contract, rec
+}
+
+func postGadget(t *testing.T, app *fiber.App, query, body string) {
+	req, _ := http.NewRequest("POST", "/gadgets"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+}
+
+func TestContractAcceptsDocumentedExchange(t *testing.T) {
+	app, _, rec := newContractApp(t)
+
+	postGadget(t, app, "", `{"name": "gizmo"}`)
+	postGadget(t, app, "?respond=conflict", `{"name": "gizmo"}`)
+	// Invalid requests that are rejected are fine
+	postGadget(t, app, "?respond=conflict", `{}`)
+
+	req, _ := http.NewRequest("GET", "/undescribed", nil)
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Empty(t, rec.errors)
+}
+
+func TestContractReportsDrift(t *testing.T) {
+	app, _, rec := newContractApp(t)
+
+	postGadget(t, app, "", `{"name": "g"}`)
+	postGadget(t, app, "?respond=bad-id", `{"name": "gizmo"}`)
+	postGadget(t, app, "?respond=crash", `{"name": "gizmo"}`)
+
+	require.Len(t, rec.errors, 3)
+	assert.Contains(t, rec.errors[0], "request $.name: expected at least 3 characters, got 1")
+	assert.Contains(t, rec.errors[1], "response $.data.id: expected integer, got string")
+	assert.Contains(t, rec.errors[2], "undocumented response status 500")
+}
+
+func TestContractCanBeDisabled(t *testing.T) {
+	app, contract, rec := newContractApp(t)
+	contract.Disable()
+
+	postGadget(t, app, "?respond=crash", `{"name": "gizmo"}`)
+
+	assert.Empty(t, rec.errors)
+}
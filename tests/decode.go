@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/stretchr/testify/require"
+)
+
+// Envelope is the body of the success responses sent by the controllers
+type Envelope[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// DecodeJSON reads the body of resp into a T, failing the test when it is
+// not valid JSON:
+//
+//	report := tests.DecodeJSON[health.Report](suite.T(), resp)
+func DecodeJSON[T any](t testing.TB, resp *http.Response) T {
+	t.Helper()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var value T
+	require.NoError(t, json.Unmarshal(body, &value), "decoding %s", body)
+	return value
+}
+
+// DecodeEnvelope reads a {"success": true, "data": ...} response
+//
+//	res := tests.DecodeEnvelope[controllers.AuthResponse](suite.T(), resp)
+//	suite.Equal("testuser", res.Data.User.Username)
+func DecodeEnvelope[T any](t testing.TB, resp *http.Response) Envelope[T] {
+	t.Helper()
+	return DecodeJSON[Envelope[T]](t, resp)
+}
+
+// DecodeError reads an error response rendered by apperror.Handler
+func DecodeError(t testing.TB, resp *http.Response) apperror.Envelope {
+	t.Helper()
+	return DecodeJSON[apperror.Envelope](t, resp)
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DriveStub is an in-memory Google Drive server for storage tests. It
+// serves the file operations of the Drive v3 API used by
+// storage.GoogleDriveDisk, with multipart uploads only and the search
+// queries the disk sends, and does not check credentials:
+//
+//	stub := tests.NewDriveStub()
+//	defer stub.Close()
+//	config.GetGlobal().Set("filesystems.disks.google_drive.endpoint", stub.URL)
+type DriveStub struct {
+	*httptest.Server
+	mu     sync.Mutex
+	files  map[string]*DriveFile
+	nextID int
+}
+
+type DriveFile struct {
+	ID           string
+	Name         string
+	Parent       string
+	Body         []byte
+	MimeType     string
+	ModifiedTime time.Time
+}
+
+var (
+	driveNameQuery   = regexp.MustCompile(`name = '((?:[^'\\]|\\.)*)'`)
+	driveParentQuery = regexp.MustCompile(`'((?:[^'\\]|\\.)*)' in parents`)
+	driveUnquote     = strings.NewReplacer(`\'`, `'`, `\\`, `\`)
+)
+
+func NewDriveStub() *DriveStub {
+	stub := &DriveStub{files: map[string]*DriveFile{}}
+	stub.Server = httptest.NewServer(http.HandlerFunc(stub.serve))
+	return stub
+}
+
+// Files returns the stored files sorted by name
+func (s *DriveStub) Files() []DriveFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make([]DriveFile, 0, len(s.files))
+	for _, file := range s.files {
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
+
+func (s *DriveStub) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/upload/drive/v3/files"); ok {
+		s.upload(w, r, strings.TrimPrefix(rest, "/"))
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/drive/v3/files")
+	if !ok {
+		googleError(w, http.StatusNotImplemented, "operation not supported")
+		return
+	}
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			googleError(w, http.StatusMethodNotAllowed, "method not supported")
+			return
+		}
+		s.list(w, r)
+		return
+	}
+
+	id := strings.TrimPrefix(rest, "/")
+	file, exists := s.files[id]
+	if !exists {
+		googleError(w, http.StatusNotFound, "File not found: "+id)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("alt") == "media" {
+			w.Header().Set("Content-Type", file.MimeType)
+			w.Header().Set("Content-Length", strconv.Itoa(len(file.Body)))
+			w.Write(file.Body)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": file.ID, "name": file.Name})
+	case http.MethodDelete:
+		delete(s.files, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		googleError(w, http.StatusMethodNotAllowed, "method not supported")
+	}
+}
+
+// upload creates a file on POST and replaces the content of file id on
+// PATCH
+func (s *DriveStub) upload(w http.ResponseWriter, r *http.Request, id string) {
+	var metadata struct {
+		Name     string   `json:"name"`
+		Parents  []string `json:"parents"`
+		MimeType string   `json:"mimeType"`
+	}
+	body, contentType, err := readMultipartUpload(r, &metadata)
+	if err != nil {
+		googleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if metadata.MimeType == "" {
+		metadata.MimeType = contentType
+	}
+
+	var file *DriveFile
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		s.nextID++
+		file = &DriveFile{ID: fmt.Sprintf("file-%d", s.nextID), Name: metadata.Name}
+		if len(metadata.Parents) > 0 {
+			file.Parent = metadata.Parents[0]
+		}
+		s.files[file.ID] = file
+	case r.Method == http.MethodPatch && s.files[id] != nil:
+		file = s.files[id]
+	default:
+		googleError(w, http.StatusNotFound, "File not found: "+id)
+		return
+	}
+	file.Body, file.MimeType, file.ModifiedTime = body, metadata.MimeType, time.Now().UTC()
+	json.NewEncoder(w).Encode(map[string]string{"id": file.ID, "name": file.Name})
+}
+
+// list answers files.list in a single page, filtering on the name and
+// parent of the query, most recently modified first
+func (s *DriveStub) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	type item struct {
+		ID           string `json:"id"`
+		Name         string `json:"name"`
+		Size         string `json:"size"`
+		ModifiedTime string `json:"modifiedTime"`
+	}
+
+	var matches []*DriveFile
+	for _, file := range s.files {
+		if m := driveNameQuery.FindStringSubmatch(query); m != nil && driveUnquote.Replace(m[1]) != file.Name {
+			continue
+		}
+		if m := driveParentQuery.FindStringSubmatch(query); m != nil && driveUnquote.Replace(m[1]) != file.Parent {
+			continue
+		}
+		matches = append(matches, file)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ModifiedTime.After(matches[j].ModifiedTime)
+	})
+	if size, err := strconv.Atoi(r.URL.Query().Get("pageSize")); err == nil && size < len(matches) {
+		matches = matches[:size]
+	}
+
+	items := []item{}
+	for _, file := range matches {
+		items = append(items, item{
+			ID:           file.ID,
+			Name:         file.Name,
+			Size:         strconv.Itoa(len(file.Body)),
+			ModifiedTime: file.ModifiedTime.Format(time.RFC3339Nano),
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]any{"kind": "drive#fileList", "files": items})
+}
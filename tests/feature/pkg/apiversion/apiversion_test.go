@@ -0,0 +1,147 @@
+package apiversion
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/galaplate/galaplate/pkg/apiversion"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	deprecatedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset       = time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+type APIVersionSuite struct {
+	tests.TestCase
+}
+
+func (t *APIVersionSuite) SetupTest() {
+	t.TestCase.SetupTest()
+
+	// v90 plays a deprecated version, v91 the current one next to it
+	apiversion.Register(apiversion.Version{
+		Name:        "v90",
+		Deprecated:  true,
+		Deprecation: apiversion.Deprecation{At: deprecatedAt, Sunset: sunset},
+	})
+	api := t.App.Group(apiversion.Prefix())
+	apiversion.Group(api, "v90").Get("/__gadgets", func(c *fiber.Ctx) error {
+		return c.SendString(apiversion.Current(c))
+	}).Name("test.gadgets.v90")
+
+	v91 := apiversion.Group(api, "v91")
+	v91.Get("/__gadgets", func(c *fiber.Ctx) error {
+		return c.SendString(apiversion.Current(c))
+	}).Name("test.gadgets")
+	v91.Get("/__widgets", func(c *fiber.Ctx) error {
+		return c.SendString("widgets")
+	}).Name("test.widgets")
+	apiversion.Deprecate("test.widgets", apiversion.Deprecation{Link: "https://example.com/migrate"})
+}
+
+func (suite *APIVersionSuite) get(path string, headers map[string]string) *http.Response {
+	req, err := http.NewRequest("GET", path, nil)
+	suite.Require().NoError(err)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *APIVersionSuite) TestServesVersionedPaths() {
+	resp := suite.get("/api/v1/test/1", nil)
+	suite.Equal(200, resp.StatusCode)
+	suite.Equal("v1", resp.Header.Get(apiversion.VersionHeader))
+	suite.Empty(resp.Header.Get(apiversion.DeprecationHeader))
+}
+
+func (suite *APIVersionSuite) TestServesUnversionedPathsWithDefaultVersion() {
+	resp := suite.get("/api/test/1", nil)
+	suite.Equal(200, resp.StatusCode)
+	suite.Equal("v1", resp.Header.Get(apiversion.VersionHeader))
+}
+
+func (suite *APIVersionSuite) TestSelectsVersionFromAcceptVersion() {
+	resp := suite.get("/api/__gadgets", map[string]string{apiversion.AcceptVersionHeader: "v91"})
+	suite.Equal(200, resp.StatusCode)
+	suite.Equal("v91", resp.Header.Get(apiversion.VersionHeader))
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	suite.Equal("v91", string(body))
+}
+
+func (suite *APIVersionSuite) TestRejectsUnknownAcceptVersion() {
+	resp := suite.get("/api/test/1", map[string]string{apiversion.AcceptVersionHeader: "v404"})
+	suite.Equal(400, resp.StatusCode)
+
+	response := tests.DecodeError(suite.T(), resp)
+	suite.Equal(apiversion.CodeUnsupportedVersion, response.Code)
+}
+
+func (suite *APIVersionSuite) TestPathVersionWinsOverAcceptVersion() {
+	resp := suite.get("/api/v90/__gadgets", map[string]string{apiversion.AcceptVersionHeader: "v91"})
+	suite.Equal(200, resp.StatusCode)
+	suite.Equal("v90", resp.Header.Get(apiversion.VersionHeader))
+}
+
+func (suite *APIVersionSuite) TestAnnouncesDeprecatedVersion() {
+	resp := suite.get("/api/v90/__gadgets", nil)
+	suite.Equal(200, resp.StatusCode)
+	suite.Equal("@1767225600", resp.Header.Get(apiversion.DeprecationHeader))
+	suite.Equal("Thu, 31 Dec 2026 00:00:00 GMT", resp.Header.Get(apiversion.SunsetHeader))
+}
+
+func (suite *APIVersionSuite) TestAnnouncesDeprecatedRoute() {
+	resp := suite.get("/api/v91/__widgets", nil)
+	suite.Equal(200, resp.StatusCode)
+	suite.Equal("true", resp.Header.Get(apiversion.DeprecationHeader))
+	suite.Empty(resp.Header.Get(apiversion.SunsetHeader))
+	suite.Equal(`<https://example.com/migrate>; rel="deprecation"`, resp.Header.Get(fiber.HeaderLink))
+
+	resp = suite.get("/api/v91/__gadgets", nil)
+	suite.Empty(resp.Header.Get(apiversion.DeprecationHeader))
+}
+
+func (suite *APIVersionSuite) TestListsRoutesWithVersionMetadata() {
+	routes := map[string]apiversion.Route{}
+	for _, route := range apiversion.Routes(suite.App) {
+		routes[route.Name] = route
+	}
+
+	suite.Equal("v1", routes["auth.login"].Version)
+	suite.Equal("/api/v1/login", routes["auth.login"].Path)
+	suite.False(routes["auth.login"].Deprecated)
+	suite.Empty(routes["health.live"].Version)
+
+	suite.True(routes["test.gadgets.v90"].Deprecated)
+	suite.Equal(sunset, routes["test.gadgets.v90"].Sunset)
+	suite.True(routes["test.widgets"].Deprecated)
+	suite.False(routes["test.gadgets"].Deprecated)
+}
+
+func (suite *APIVersionSuite) TestMarksDeprecatedOperationsInOpenAPI() {
+	doc := openapi.Generate(suite.App, openapi.LoadInfo())
+
+	_, _, old := doc.Operation("test.gadgets.v90")
+	suite.Require().NotNil(old)
+	suite.True(old.Deprecated)
+
+	_, _, current := doc.Operation("test.gadgets")
+	suite.Require().NotNil(current)
+	suite.False(current.Deprecated)
+}
+
+func TestAPIVersionSuiteRun(t *testing.T) {
+	suite.Run(t, new(APIVersionSuite))
+}
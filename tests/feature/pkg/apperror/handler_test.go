@@ -0,0 +1,143 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/galaplate/core/supports"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type HandlerSuite struct {
+	tests.TestCase
+}
+
+func (t *HandlerSuite) SetupTest() {
+	t.TestCase.SetupTest()
+
+	t.App.Get("/__apperror/internal", func(c *fiber.Ctx) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	})
+	t.App.Get("/__apperror/conflict", func(c *fiber.Ctx) error {
+		return apperror.Conflict("Already exists")
+	})
+	t.App.Get("/__apperror/validator", func(c *fiber.Ctx) error {
+		return supports.XValidator{}.WithMessage(supports.GlobalErrorHandlerResp{
+			Status:  fiber.StatusConflict,
+			Message: "The email is taken",
+			Errors:  map[string]string{"email": "The email is taken"},
+		})
+	})
+}
+
+func (suite *HandlerSuite) do(req *http.Request) (*http.Response, map[string]any) {
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+
+	var response map[string]any
+	suite.Require().NoError(json.Unmarshal(body, &response), string(body))
+	return resp, response
+}
+
+func (suite *HandlerSuite) TestRendersEnvelopeByDefault() {
+	req, _ := http.NewRequest("GET", "/__apperror/conflict", nil)
+	req.Header.Set("X-Request-ID", "req-apperror-1")
+
+	resp, response := suite.do(req)
+	suite.Equal(409, resp.StatusCode)
+	suite.Equal(fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+	suite.Equal(false, response["success"])
+	suite.Equal("conflict", response["code"])
+	suite.Equal("Already exists", response["message"])
+	suite.Equal("req-apperror-1", response["request_id"])
+}
+
+func (suite *HandlerSuite) TestRendersProblemJSONWhenAccepted() {
+	req, _ := http.NewRequest("GET", "/__apperror/conflict", nil)
+	req.Header.Set("Accept", "application/problem+json")
+
+	resp, response := suite.do(req)
+	suite.Equal(409, resp.StatusCode)
+	suite.Equal(apperror.MIMEProblemJSON, resp.Header.Get("Content-Type"))
+	suite.Equal("about:blank", response["type"])
+	suite.Equal("Conflict", response["title"])
+	suite.Equal(float64(409), response["status"])
+	suite.Equal("Already exists", response["detail"])
+	suite.Equal("/__apperror/conflict", response["instance"])
+	suite.Equal("conflict", response["code"])
+}
+
+func (suite *HandlerSuite) TestHidesInternalErrors() {
+	tests.SetConfig(suite.T(), "app.debug", false)
+
+	req, _ := http.NewRequest("GET", "/__apperror/internal", nil)
+
+	resp, response := suite.do(req)
+	suite.Equal(500, resp.StatusCode)
+	suite.Equal("internal_error", response["code"])
+	suite.Equal("Internal Server Error", response["message"])
+	suite.NotContains(response, "debug")
+}
+
+func (suite *HandlerSuite) TestExposesInternalErrorsInDebugMode() {
+	tests.SetConfig(suite.T(), "app.debug", true)
+
+	req, _ := http.NewRequest("GET", "/__apperror/internal", nil)
+
+	resp, response := suite.do(req)
+	suite.Equal(500, resp.StatusCode)
+	suite.Equal("Internal Server Error", response["message"])
+	suite.Equal("dial tcp 10.0.0.5:5432: connection refused", response["debug"])
+}
+
+func (suite *HandlerSuite) TestValidationErrorsListFields() {
+	req, _ := http.NewRequest("POST", "/api/test", strings.NewReader(`{"name": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+
+	resp, response := suite.do(req)
+	suite.Equal(422, resp.StatusCode)
+	suite.Equal("validation_failed", response["code"])
+	suite.Contains(response["errors"], "name")
+}
+
+func (suite *HandlerSuite) TestValidatorErrorsKeepTheirStatus() {
+	req, _ := http.NewRequest("GET", "/__apperror/validator", nil)
+
+	resp, response := suite.do(req)
+	suite.Equal(409, resp.StatusCode)
+	suite.Equal("conflict", response["code"])
+	suite.Equal("The email is taken", response["message"])
+	suite.Contains(response["errors"], "email")
+}
+
+func (suite *HandlerSuite) TestMalformedBodyIsBadRequest() {
+	req, _ := http.NewRequest("POST", "/api/test", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, response := suite.do(req)
+	suite.Equal(400, resp.StatusCode)
+	suite.Equal("bad_request", response["code"])
+}
+
+func (suite *HandlerSuite) TestUnknownRouteIsNotFound() {
+	req, _ := http.NewRequest("GET", "/does-not-exist", nil)
+
+	resp, response := suite.do(req)
+	suite.Equal(404, resp.StatusCode)
+	suite.Equal("not_found", response["code"])
+}
+
+func TestHandlerSuiteRun(t *testing.T) {
+	suite.Run(t, new(HandlerSuite))
+}
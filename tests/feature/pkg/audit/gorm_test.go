@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/requestctx"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type GormPluginSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (suite *GormPluginSuite) create(name string) *models.User {
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "hashed"}
+	suite.Require().NoError(database.Connect.Create(user).Error)
+	return user
+}
+
+func (suite *GormPluginSuite) logs(user *models.User) []models.AuditLog {
+	var logs []models.AuditLog
+	suite.Require().NoError(database.Connect.Where("auditable_type = ? AND auditable_id = ?", "users", fmt.Sprint(user.ID)).Order("id").Find(&logs).Error)
+	return logs
+}
+
+func changesOf(t *testing.T, log models.AuditLog) map[string]audit.Change {
+	var changes map[string]audit.Change
+	if err := json.Unmarshal([]byte(log.Changes), &changes); err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+func (suite *GormPluginSuite) TestRecordsCreate() {
+	user := suite.create("alice")
+
+	logs := suite.logs(user)
+	suite.Require().Len(logs, 1)
+	suite.Equal("users.created", logs[0].Action)
+	suite.Nil(logs[0].ActorID)
+
+	changes := changesOf(suite.T(), logs[0])
+	suite.Equal(audit.Change{Old: nil, New: "alice"}, changes["username"])
+	suite.Equal(audit.Change{Old: nil, New: audit.Redacted}, changes["password"])
+	suite.NotContains(changes, "id")
+	suite.NotContains(changes, "created_at")
+	suite.NotContains(changes, "updated_at")
+}
+
+func (suite *GormPluginSuite) TestRecordsUpdateWithRequest() {
+	user := suite.create("alice")
+
+	ctx := requestctx.WithRequestID(context.Background(), "req-1")
+	ctx = requestctx.WithActorID(ctx, 42)
+	ctx = requestctx.WithClient(ctx, requestctx.Client{IP: "10.0.0.1", UserAgent: "test-agent"})
+	ctx = audit.WithAction(ctx, "profile.updated")
+	suite.Require().NoError(database.Connect.WithContext(ctx).Model(user).Updates(map[string]any{
+		"description": "Gopher",
+		"password":    "rehashed",
+		"username":    "alice",
+	}).Error)
+
+	logs := suite.logs(user)
+	suite.Require().Len(logs, 2)
+	log := logs[1]
+	suite.Equal("profile.updated", log.Action)
+	suite.Equal("req-1", log.RequestID)
+	suite.Equal("10.0.0.1", log.IPAddress)
+	suite.Equal("test-agent", log.UserAgent)
+	suite.Require().NotNil(log.ActorID)
+	suite.Equal(uint(42), *log.ActorID)
+	suite.Equal(map[string]audit.Change{
+		"description": {Old: "", New: "Gopher"},
+		"password":    {Old: audit.Redacted, New: audit.Redacted},
+	}, changesOf(suite.T(), log))
+}
+
+func (suite *GormPluginSuite) TestSkipsUpdatesChangingNothing() {
+	user := suite.create("alice")
+
+	suite.Require().NoError(database.Connect.Model(user).Update("username", "alice").Error)
+
+	suite.Len(suite.logs(user), 1)
+}
+
+func (suite *GormPluginSuite) TestRecordsEveryRowOfBulkUpdates() {
+	alice := suite.create("alice")
+	bob := suite.create("bob")
+
+	suite.Require().NoError(database.Connect.Model(&models.User{}).Where("status = ?", false).Update("status", true).Error)
+
+	for _, user := range []*models.User{alice, bob} {
+		logs := suite.logs(user)
+		suite.Require().Len(logs, 2)
+		suite.Equal("users.updated", logs[1].Action)
+		suite.Equal(map[string]audit.Change{"status": {Old: false, New: true}}, changesOf(suite.T(), logs[1]))
+	}
+}
+
+func (suite *GormPluginSuite) TestRecordsDeletes() {
+	user := suite.create("alice")
+
+	suite.Require().NoError(database.Connect.Delete(user).Error)
+	logs := suite.logs(user)
+	suite.Require().Len(logs, 2)
+	suite.Equal("users.deleted", logs[1].Action)
+	changes := changesOf(suite.T(), logs[1])
+	suite.Len(changes, 1)
+	suite.Nil(changes["deleted_at"].Old)
+	suite.NotNil(changes["deleted_at"].New)
+
+	suite.Require().NoError(database.Connect.Unscoped().Delete(user).Error)
+	logs = suite.logs(user)
+	suite.Require().Len(logs, 3)
+	changes = changesOf(suite.T(), logs[2])
+	suite.Equal(audit.Change{Old: "alice", New: nil}, changes["username"])
+	suite.Equal(audit.Change{Old: audit.Redacted, New: nil}, changes["password"])
+}
+
+func (suite *GormPluginSuite) TestIgnoresUntrackedModels() {
+	user := suite.create("alice")
+	file := &models.File{UserID: &user.ID, Collection: "files", Disk: "local", Path: "a.txt", Name: "a.txt", MimeType: "text/plain", Size: 1}
+	suite.Require().NoError(database.Connect.Create(file).Error)
+
+	var count int64
+	database.Connect.Model(&models.AuditLog{}).Where("auditable_type <> ?", "users").Count(&count)
+	suite.Zero(count)
+}
+
+func TestGormPluginSuiteRun(t *testing.T) {
+	suite.Run(t, new(GormPluginSuite))
+}
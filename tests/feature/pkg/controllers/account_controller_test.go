@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	coremodels "github.com/galaplate/core/models"
+	"github.com/galaplate/galaplate/pkg/account"
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/exports"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AccountControllerSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	dir   string
+	user  *models.User
+	token string
+}
+
+func (suite *AccountControllerSuite) SetupTest() {
+	suite.RefreshDatabaseBeforeEachTest.SetupTest()
+	suite.dir = useLocalDisk(suite.T())
+
+	suite.user, suite.token = tests.RegisterUser(suite.T(), suite.App, "alice")
+}
+
+func (suite *AccountControllerSuite) upload() *models.File {
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "report.pdf", pdfContent), "")
+	suite.Require().Equal(201, resp.StatusCode)
+	return tests.DecodeEnvelope[*models.File](suite.T(), resp).Data
+}
+
+func (suite *AccountControllerSuite) requestExport(token string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest("POST", "/api/account/export", ""), token)
+}
+
+func (suite *AccountControllerSuite) showExport(id uint, token string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest("GET", fmt.Sprintf("/api/account/exports/%d", id), ""), token)
+}
+
+func (suite *AccountControllerSuite) deleteAccount(token, password string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest("DELETE", "/api/account", fmt.Sprintf(`{"password": %q}`, password)), token)
+}
+
+// export requests an export and builds it like the queue worker would
+func (suite *AccountControllerSuite) export() controllers.DataExportResponse {
+	resp := suite.requestExport(suite.token)
+	suite.Require().Equal(202, resp.StatusCode)
+	export := tests.DecodeEnvelope[controllers.DataExportResponse](suite.T(), resp).Data
+
+	var job coremodels.Job
+	err := database.Connect.Where("type = ?", jobs.ExportUserData{}.Type()).Order("id desc").First(&job).Error
+	suite.Require().NoError(err)
+	suite.Require().NoError(jobs.ExportUserData{}.Handle(job.Payload))
+
+	resp = suite.showExport(export.ID, suite.token)
+	suite.Require().Equal(200, resp.StatusCode)
+	return tests.DecodeEnvelope[controllers.DataExportResponse](suite.T(), resp).Data
+}
+
+func (suite *AccountControllerSuite) TestQueuesOneExportAtATime() {
+	resp := suite.requestExport(suite.token)
+	suite.Require().Equal(202, resp.StatusCode)
+	export := tests.DecodeEnvelope[controllers.DataExportResponse](suite.T(), resp).Data
+	suite.Equal(models.DataExportPending, export.Status)
+	suite.Equal(fmt.Sprintf("/api/v1/account/exports/%d", export.ID), resp.Header.Get("Location"))
+
+	resp = suite.requestExport(suite.token)
+	suite.Require().Equal(202, resp.StatusCode)
+	suite.Equal(export.ID, tests.DecodeEnvelope[controllers.DataExportResponse](suite.T(), resp).Data.ID)
+
+	var queued int64
+	database.Connect.Model(&coremodels.Job{}).Where("type = ?", jobs.ExportUserData{}.Type()).Count(&queued)
+	suite.Equal(int64(1), queued)
+
+	resp = suite.showExport(export.ID, suite.token)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Empty(tests.DecodeEnvelope[controllers.DataExportResponse](suite.T(), resp).Data.URL)
+
+	_, bob := tests.RegisterUser(suite.T(), suite.App, "bob")
+	suite.Equal(404, suite.showExport(export.ID, bob).StatusCode)
+	suite.Equal(401, suite.requestExport("").StatusCode)
+}
+
+func (suite *AccountControllerSuite) TestExportsData() {
+	file := suite.upload()
+
+	export := suite.export()
+	suite.Equal(models.DataExportCompleted, export.Status)
+	suite.NotNil(export.ExpiresAt)
+	suite.Require().NotEmpty(export.URL)
+
+	parsed, err := url.Parse(export.URL)
+	suite.Require().NoError(err)
+	resp := tests.Send(suite.T(), suite.App, tests.JSONRequest("GET", parsed.RequestURI(), ""), "")
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal("application/zip", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	suite.Require().NoError(err)
+	entries := map[string][]byte{}
+	for _, entry := range archive.File {
+		r, err := entry.Open()
+		suite.Require().NoError(err)
+		entries[entry.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+
+	var user map[string]any
+	suite.Require().NoError(json.Unmarshal(entries["user.json"], &user))
+	suite.Equal("alice", user["username"])
+	suite.NotContains(user, "password")
+
+	var files []models.File
+	suite.Require().NoError(json.Unmarshal(entries["files.json"], &files))
+	suite.Require().Len(files, 1)
+	suite.Equal("report.pdf", files[0].Name)
+	suite.Equal(pdfContent, entries[fmt.Sprintf("files/%d/report.pdf", file.ID)])
+
+	var logs []struct {
+		Action  string                    `json:"action"`
+		Changes map[string]map[string]any `json:"changes"`
+	}
+	suite.Require().NoError(json.Unmarshal(entries["audit_logs.json"], &logs))
+	suite.Require().NotEmpty(logs)
+	suite.Equal("users.created", logs[0].Action)
+	suite.Equal("[redacted]", logs[0].Changes["password"]["new"])
+}
+
+func (suite *AccountControllerSuite) TestPurgesExpiredExports() {
+	tests.SetConfig(suite.T(), "account.export.retention", "-1m")
+	export := suite.export()
+	var archive models.File
+	suite.Require().NoError(database.Connect.First(&archive, *export.FileID).Error)
+
+	purged, err := exports.Purge(context.Background())
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), purged)
+
+	suite.Equal(404, suite.showExport(export.ID, suite.token).StatusCode)
+	suite.ErrorIs(database.Connect.First(&models.File{}, archive.ID).Error, gorm.ErrRecordNotFound)
+	_, err = os.Stat(filepath.Join(suite.dir, archive.Path))
+	suite.True(os.IsNotExist(err))
+}
+
+func (suite *AccountControllerSuite) TestDeletesAccount() {
+	resp := suite.deleteAccount(suite.token, "wrong")
+	suite.Equal(422, resp.StatusCode)
+	suite.Contains(tests.DecodeError(suite.T(), resp).Errors, "password")
+
+	resp = suite.deleteAccount(suite.token, "password123")
+	suite.Require().Equal(200, resp.StatusCode)
+	user := tests.DecodeEnvelope[models.User](suite.T(), resp).Data
+	suite.True(user.DeletedAt.Valid)
+	suite.Require().NotNil(user.DeletionScheduledAt)
+	suite.WithinDuration(time.Now().Add(account.GracePeriod()), *user.DeletionScheduledAt, time.Minute)
+
+	suite.Equal(401, suite.requestExport(suite.token).StatusCode)
+	resp = tests.Send(suite.T(), suite.App, tests.JSONRequest("POST", "/api/login", `{"email": "alice@example.com", "password": "password123"}`), "")
+	suite.Equal(401, resp.StatusCode)
+
+	var actions []string
+	database.Connect.Model(&models.AuditLog{}).Where("auditable_id = ?", fmt.Sprint(user.ID)).Order("id").Pluck("action", &actions)
+	suite.Equal([]string{"users.created", "account.deleted"}, actions)
+}
+
+func (suite *AccountControllerSuite) TestPurgesDeletedAccounts() {
+	file := suite.upload()
+	suite.Require().NoError(database.Connect.First(file, file.ID).Error)
+	export := suite.export()
+	suite.Require().Equal(200, suite.deleteAccount(suite.token, "password123").StatusCode)
+
+	// Accounts deleted by an administrator are not purged
+	bob, _ := tests.RegisterUser(suite.T(), suite.App, "bob")
+	suite.Require().NoError(database.Connect.Delete(bob).Error)
+
+	purged, err := account.Purge(context.Background())
+	suite.Require().NoError(err)
+	suite.Zero(purged)
+
+	past := time.Now().Add(-time.Minute)
+	database.Connect.Unscoped().Model(&models.User{}).Where("id = ?", suite.user.ID).Update("deletion_scheduled_at", past)
+	purged, err = account.Purge(context.Background())
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), purged)
+
+	suite.ErrorIs(database.Connect.Unscoped().First(&models.User{}, suite.user.ID).Error, gorm.ErrRecordNotFound)
+	suite.NoError(database.Connect.Unscoped().First(&models.User{}, bob.ID).Error)
+	suite.ErrorIs(database.Connect.First(&models.DataExport{}, export.ID).Error, gorm.ErrRecordNotFound)
+	var files int64
+	database.Connect.Model(&models.File{}).Where("user_id = ?", suite.user.ID).Count(&files)
+	suite.Zero(files)
+	_, err = os.Stat(filepath.Join(suite.dir, file.Path))
+	suite.True(os.IsNotExist(err))
+
+	var logs []models.AuditLog
+	database.Connect.Where("auditable_type = ? AND auditable_id = ?", "users", fmt.Sprint(suite.user.ID)).Order("id").Find(&logs)
+	suite.Require().NotEmpty(logs)
+	suite.Equal("account.purged", logs[len(logs)-1].Action)
+	for _, log := range logs {
+		suite.Equal("{}", log.Changes)
+		suite.Empty(log.IPAddress)
+		suite.Empty(log.UserAgent)
+	}
+}
+
+func TestAccountControllerSuiteRun(t *testing.T) {
+	suite.Run(t, new(AccountControllerSuite))
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/console/commands"
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/middleware"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/query"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminUserControllerSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	admin string
+}
+
+func (suite *AdminUserControllerSuite) SetupTest() {
+	suite.RefreshDatabaseBeforeEachTest.SetupTest()
+
+	user, _ := tests.RegisterUser(suite.T(), suite.App, "admin")
+	database.Connect.Model(&models.User{}).Where("id = ?", user.ID).Update("role", models.RoleAdmin)
+	suite.admin = tests.LoginUser(suite.T(), suite.App, "admin")
+}
+
+func (suite *AdminUserControllerSuite) request(method, path, token, body string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest(method, path, body), token)
+}
+
+func (suite *AdminUserControllerSuite) list(params string) query.Page[models.User] {
+	resp := suite.request("GET", "/api/admin/users?"+params, suite.admin, "")
+	suite.Require().Equal(200, resp.StatusCode)
+	return tests.DecodeJSON[query.Page[models.User]](suite.T(), resp)
+}
+
+func usernames(users []models.User) []string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
+
+func (suite *AdminUserControllerSuite) TestRequiresAdmin() {
+	_, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	suite.Equal(401, suite.request("GET", "/api/admin/users", "", "").StatusCode)
+	suite.Equal(403, suite.request("GET", "/api/admin/users", token, "").StatusCode)
+	suite.Equal(403, suite.request("POST", "/api/admin/users/1/revoke-tokens", token, "").StatusCode)
+}
+
+func (suite *AdminUserControllerSuite) TestListsSearchesAndSortsUsers() {
+	tests.RegisterUser(suite.T(), suite.App, "carol")
+	tests.RegisterUser(suite.T(), suite.App, "bob")
+	tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	list := suite.list("sort=username")
+	suite.Equal([]string{"admin", "alice", "bob", "carol"}, usernames(list.Data))
+	suite.Equal(int64(4), list.Meta.Total)
+
+	list = suite.list("sort=-username&page=2&per_page=3")
+	suite.Equal([]string{"admin"}, usernames(list.Data))
+	suite.Equal(2, list.Meta.TotalPages)
+
+	list = suite.list("search=bob@")
+	suite.Equal([]string{"bob"}, usernames(list.Data))
+
+	list = suite.list("filter[role]=admin")
+	suite.Equal([]string{"admin"}, usernames(list.Data))
+
+	suite.Equal(400, suite.request("GET", "/api/admin/users?sort=password", suite.admin, "").StatusCode)
+	suite.Equal(400, suite.request("GET", "/api/admin/users?filter[password]=secret", suite.admin, "").StatusCode)
+}
+
+func (suite *AdminUserControllerSuite) TestUpdatesStatus() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+	suite.True(user.Status, "users are active when they register")
+	path := fmt.Sprintf("/api/admin/users/%d/status", user.ID)
+
+	resp := suite.request("PATCH", path, suite.admin, `{"status": false}`)
+	suite.Equal(200, resp.StatusCode)
+	suite.False(tests.DecodeEnvelope[models.User](suite.T(), resp).Data.Status)
+	suite.Equal(401, suite.request("GET", "/api/profile", token, "").StatusCode, "tokens are revoked")
+
+	resp = suite.request("POST", "/api/login", "", `{"email": "alice@example.com", "password": "password123"}`)
+	suite.Equal(403, resp.StatusCode)
+	suite.Equal(middleware.CodeAccountInactive, tests.DecodeError(suite.T(), resp).Code)
+
+	resp = suite.request("PATCH", path, suite.admin, `{"status": true}`)
+	suite.Equal(200, resp.StatusCode)
+	suite.True(tests.DecodeEnvelope[models.User](suite.T(), resp).Data.Status)
+	suite.Equal(200, suite.request("GET", "/api/profile", tests.LoginUser(suite.T(), suite.App, "alice"), "").StatusCode)
+
+	resp = suite.request("PATCH", path, suite.admin, `{}`)
+	suite.Equal(422, resp.StatusCode)
+
+	suite.Equal(404, suite.request("GET", "/api/admin/users/999", suite.admin, "").StatusCode)
+}
+
+func (suite *AdminUserControllerSuite) TestActivateCommandSkipsUsersDeactivatedByAdmins() {
+	legacy, _ := tests.RegisterUser(suite.T(), suite.App, "legacy")
+	banned, _ := tests.RegisterUser(suite.T(), suite.App, "banned")
+	database.Connect.Model(&models.User{}).Where("id = ?", legacy.ID).Update("status", false)
+	suite.Equal(200, suite.request("PATCH", fmt.Sprintf("/api/admin/users/%d/status", banned.ID), suite.admin, `{"status": false}`).StatusCode)
+
+	status := func(user *models.User) bool {
+		var found models.User
+		suite.Require().NoError(database.Connect.First(&found, user.ID).Error)
+		return found.Status
+	}
+
+	var out bytes.Buffer
+	command := &commands.UserActivateCommand{Output: &out}
+	suite.Require().NoError(command.Execute([]string{"--dry-run"}))
+	suite.Contains(out.String(), "legacy@example.com")
+	suite.NotContains(out.String(), "banned@example.com")
+	suite.False(status(legacy), "a dry run changes nothing")
+
+	suite.Require().NoError(command.Execute(nil))
+	suite.True(status(legacy))
+	suite.False(status(banned))
+
+	var logs int64
+	database.Connect.Model(&models.AuditLog{}).Where("action = ? AND auditable_id = ?", "console.user.activated", fmt.Sprint(legacy.ID)).Count(&logs)
+	suite.Equal(int64(1), logs)
+
+	suite.Require().NoError(command.Execute([]string{"banned@example.com"}))
+	suite.True(status(banned), "named users are activated")
+	suite.Error(command.Execute([]string{"--force"}))
+}
+
+func (suite *AdminUserControllerSuite) TestRejectsTokensOfInactiveUsers() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+	database.Connect.Model(&models.User{}).Where("id = ?", user.ID).Update("status", false)
+
+	resp := suite.request("GET", "/api/profile", token, "")
+	suite.Equal(403, resp.StatusCode)
+	suite.Equal(middleware.CodeAccountInactive, tests.DecodeError(suite.T(), resp).Code)
+}
+
+func (suite *AdminUserControllerSuite) TestSoftDeletesAndRestores() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+	path := fmt.Sprintf("/api/admin/users/%d", user.ID)
+
+	suite.Equal(200, suite.request("DELETE", path, suite.admin, "").StatusCode)
+	suite.Equal(401, suite.request("GET", "/api/profile", token, "").StatusCode)
+	suite.Equal(409, suite.request("DELETE", path, suite.admin, "").StatusCode)
+
+	suite.Equal([]string{"admin"}, usernames(suite.list("").Data))
+	suite.Equal([]string{"alice"}, usernames(suite.list("trashed=only").Data))
+
+	resp := suite.request("GET", path, suite.admin, "")
+	suite.Equal(200, resp.StatusCode)
+	suite.NotNil(tests.DecodeEnvelope[models.User](suite.T(), resp).Data.DeletedAt)
+
+	suite.Equal(200, suite.request("POST", path+"/restore", suite.admin, "").StatusCode)
+	suite.Equal(409, suite.request("POST", path+"/restore", suite.admin, "").StatusCode)
+	suite.Equal(401, suite.request("GET", "/api/profile", token, "").StatusCode, "tokens stay revoked")
+	suite.Equal(200, suite.request("GET", "/api/profile", tests.LoginUser(suite.T(), suite.App, "alice"), "").StatusCode)
+
+	var admin models.User
+	database.Connect.Where("username = ?", "admin").First(&admin)
+	suite.Equal(409, suite.request("DELETE", fmt.Sprintf("/api/admin/users/%d", admin.ID), suite.admin, "").StatusCode)
+}
+
+func (suite *AdminUserControllerSuite) TestRevokesTokens() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	suite.Equal(200, suite.request("POST", fmt.Sprintf("/api/admin/users/%d/revoke-tokens", user.ID), suite.admin, "").StatusCode)
+	suite.Equal(401, suite.request("GET", "/api/profile", token, "").StatusCode)
+	suite.Equal(200, suite.request("GET", "/api/profile", tests.LoginUser(suite.T(), suite.App, "alice"), "").StatusCode)
+}
+
+func (suite *AdminUserControllerSuite) TestForcesPasswordReset() {
+	user, _ := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	suite.Equal(200, suite.request("POST", fmt.Sprintf("/api/admin/users/%d/password-reset", user.ID), suite.admin, "").StatusCode)
+
+	token := tests.LoginUser(suite.T(), suite.App, "alice")
+	resp := suite.request("GET", "/api/profile", token, "")
+	suite.Equal(403, resp.StatusCode)
+	suite.Equal(middleware.CodePasswordResetRequired, tests.DecodeError(suite.T(), resp).Code)
+
+	resp = suite.request("PUT", "/api/profile/password", token, `{"current_password": "wrong-password", "password": "new-password", "password_confirmation": "new-password"}`)
+	suite.Equal(422, resp.StatusCode)
+
+	resp = suite.request("PUT", "/api/profile/password", token, `{"current_password": "password123", "password": "new-password", "password_confirmation": "new-password"}`)
+	suite.Require().Equal(200, resp.StatusCode)
+	auth := tests.DecodeEnvelope[controllers.AuthResponse](suite.T(), resp).Data
+	suite.False(auth.User.PasswordResetRequired)
+
+	suite.Equal(401, suite.request("GET", "/api/profile", token, "").StatusCode, "the old token is revoked")
+	suite.Equal(200, suite.request("GET", "/api/profile", "Bearer "+auth.Token, "").StatusCode)
+}
+
+func (suite *AdminUserControllerSuite) TestAuditsAdminActions() {
+	user, _ := tests.RegisterUser(suite.T(), suite.App, "alice")
+	suite.request("PATCH", fmt.Sprintf("/api/admin/users/%d/status", user.ID), suite.admin, `{"status": false}`)
+	suite.request("DELETE", fmt.Sprintf("/api/admin/users/%d", user.ID), suite.admin, "")
+
+	var actions []string
+	database.Connect.Model(&models.AuditLog{}).Where("auditable_id = ?", fmt.Sprint(user.ID)).Order("id").Pluck("action", &actions)
+	suite.Equal([]string{"users.created", "admin.user.status_updated", "admin.user.tokens_revoked", "admin.user.deleted"}, actions)
+}
+
+func (suite *AdminUserControllerSuite) TestListsAuditHistory() {
+	user, _ := tests.RegisterUser(suite.T(), suite.App, "alice")
+	suite.request("PATCH", fmt.Sprintf("/api/admin/users/%d/status", user.ID), suite.admin, `{"status": false}`)
+	suite.request("PATCH", fmt.Sprintf("/api/admin/users/%d/status", user.ID), suite.admin, `{"status": true}`)
+
+	path := fmt.Sprintf("/api/admin/audit-logs/users/%d", user.ID)
+	suite.Equal(403, suite.request("GET", path, tests.LoginUser(suite.T(), suite.App, "alice"), "").StatusCode)
+
+	resp := suite.request("GET", path+"?filter[action]=admin.user.status_updated", suite.admin, "")
+	suite.Require().Equal(200, resp.StatusCode)
+	page := tests.DecodeJSON[query.Page[controllers.AuditLogResponse]](suite.T(), resp)
+	suite.Equal(int64(2), page.Meta.Total)
+	suite.Require().Len(page.Data, 2)
+	suite.JSONEq(`{"status": {"old": false, "new": true}}`, string(page.Data[0].Changes))
+	suite.JSONEq(`{"status": {"old": true, "new": false}, "token_version": {"old": 0, "new": 1}}`, string(page.Data[1].Changes))
+	suite.NotEmpty(page.Data[0].RequestID)
+	suite.Require().NotNil(page.Data[0].ActorID)
+
+	resp = suite.request("GET", path+"?sort=id&per_page=1", suite.admin, "")
+	suite.Require().Equal(200, resp.StatusCode)
+	page = tests.DecodeJSON[query.Page[controllers.AuditLogResponse]](suite.T(), resp)
+	suite.Equal(int64(3), page.Meta.Total)
+	suite.Equal("users.created", page.Data[0].Action)
+	suite.Contains(string(page.Data[0].Changes), `"password":{"old":null,"new":"[redacted]"}`)
+
+	suite.Equal(400, suite.request("GET", path+"?sort=action", suite.admin, "").StatusCode)
+}
+
+func TestAdminUserControllerSuiteRun(t *testing.T) {
+	suite.Run(t, new(AdminUserControllerSuite))
+}
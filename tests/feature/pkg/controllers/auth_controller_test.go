@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/controllers"
 	"github.com/galaplate/galaplate/tests"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -177,23 +179,13 @@ func (suite *AuthControllerSuite) TestCanLoginWithValidCredentials() {
 	suite.NoError(err)
 	suite.Equal(200, loginResp.StatusCode)
 
-	body, err := io.ReadAll(loginResp.Body)
-	suite.NoError(err)
-
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
-	suite.NoError(err)
-
-	suite.Equal(true, response["success"])
-	suite.Equal("Login successful", response["message"])
-
-	data := response["data"].(map[string]interface{})
-	suite.NotNil(data["user"])
-	suite.NotEmpty(data["token"])
-
-	user := data["user"].(map[string]interface{})
-	suite.Equal("testuser", user["username"])
-	suite.Equal("test@example.com", user["email"])
+	response := tests.DecodeEnvelope[controllers.AuthResponse](t, loginResp)
+	suite.True(response.Success)
+	suite.Equal("Login successful", response.Message)
+	suite.NotEmpty(response.Data.Token)
+	suite.Require().NotNil(response.Data.User)
+	suite.Equal("testuser", response.Data.User.Username)
+	suite.Equal("test@example.com", response.Data.User.Email)
 }
 
 func (suite *AuthControllerSuite) TestLoginWithInvalidCredentials() {
@@ -219,15 +211,10 @@ func (suite *AuthControllerSuite) TestLoginWithInvalidCredentials() {
 	suite.NoError(err)
 	suite.Equal(401, loginResp.StatusCode)
 
-	body, err := io.ReadAll(loginResp.Body)
-	suite.NoError(err)
-
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
-	suite.NoError(err)
-
-	suite.Equal(false, response["success"])
-	suite.Equal("Invalid credentials", response["message"])
+	response := tests.DecodeError(t, loginResp)
+	suite.False(response.Success)
+	suite.Equal(apperror.CodeUnauthorized, response.Code)
+	suite.Equal("Invalid credentials", response.Message)
 }
 
 func (suite *AuthControllerSuite) TestLoginWithNonExistentUser() {
@@ -242,15 +229,10 @@ func (suite *AuthControllerSuite) TestLoginWithNonExistentUser() {
 	suite.NoError(err)
 	suite.Equal(401, loginResp.StatusCode)
 
-	body, err := io.ReadAll(loginResp.Body)
-	suite.NoError(err)
-
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
-	suite.NoError(err)
-
-	suite.Equal(false, response["success"])
-	suite.Equal("Invalid credentials", response["message"])
+	response := tests.DecodeError(t, loginResp)
+	suite.False(response.Success)
+	suite.Equal(apperror.CodeUnauthorized, response.Code)
+	suite.Equal("Invalid credentials", response.Message)
 }
 
 func (suite *AuthControllerSuite) TestLoginValidationErrors() {
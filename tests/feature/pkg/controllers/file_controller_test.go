@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/galaplate/core/database"
+	coremodels "github.com/galaplate/core/models"
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/downloads"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/galaplate/galaplate/pkg/uploads"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// Smallest content recognised as each type
+var (
+	pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	pdfContent = []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n")
+)
+
+// uploadRequest builds a multipart request sending content as the file
+// field, with a Content-Type the server must not trust
+func uploadRequest(path, token, field, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile(field, filename)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return req
+}
+
+// useLocalDisk stores the uploads of the running test in a temporary
+// directory and returns it
+func useLocalDisk(t testing.TB) string {
+	dir := t.TempDir()
+	tests.SetConfig(t, "filesystems.default", "local")
+	tests.SetConfig(t, "filesystems.disks.local.path", dir)
+	storage.Reset()
+	t.Cleanup(storage.Reset)
+	return dir
+}
+
+// useS3Disk makes the s3 disk, backed by a stub, the default disk of the
+// running test
+func useS3Disk(t testing.TB) *tests.S3Stub {
+	stub := tests.NewS3Stub()
+	t.Cleanup(stub.Close)
+	tests.SetConfig(t, "filesystems.default", "s3")
+	tests.SetConfig(t, "filesystems.disks.s3.bucket", "uploads")
+	tests.SetConfig(t, "filesystems.disks.s3.key", "test-key")
+	tests.SetConfig(t, "filesystems.disks.s3.secret", "test-secret")
+	tests.SetConfig(t, "filesystems.disks.s3.endpoint", stub.URL)
+	tests.SetConfig(t, "filesystems.disks.s3.use_path_style_endpoint", true)
+	tests.SetConfig(t, "filesystems.disks.s3.path_prefix", "")
+	storage.Reset()
+	return stub
+}
+
+type FileControllerSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	dir   string
+	token string
+}
+
+func (suite *FileControllerSuite) SetupTest() {
+	suite.RefreshDatabaseBeforeEachTest.SetupTest()
+	suite.dir = useLocalDisk(suite.T())
+
+	_, suite.token = tests.RegisterUser(suite.T(), suite.App, "alice")
+}
+
+func (suite *FileControllerSuite) TestStoresUpload() {
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "../report.pdf", pdfContent), "")
+	suite.Require().Equal(201, resp.StatusCode)
+
+	file := tests.DecodeEnvelope[models.File](suite.T(), resp).Data
+	suite.Equal("report.pdf", file.Name)
+	suite.Equal("application/pdf", file.MimeType)
+	suite.Equal(int64(len(pdfContent)), file.Size)
+	suite.Equal("files", file.Collection)
+	suite.Len(file.Checksum, 64)
+
+	var stored models.File
+	suite.Require().NoError(database.Connect.First(&stored, file.ID).Error)
+	suite.Equal(".pdf", filepath.Ext(stored.Path))
+	content, err := os.ReadFile(filepath.Join(suite.dir, stored.Path))
+	suite.Require().NoError(err)
+	suite.Equal(pdfContent, content)
+}
+
+func (suite *FileControllerSuite) TestRejectsInvalidUploads() {
+	suite.Equal(401, tests.Send(suite.T(), suite.App, uploadRequest("/api/files", "", "file", "a.pdf", pdfContent), "").StatusCode)
+
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "document", "a.pdf", pdfContent), "")
+	suite.Equal(422, resp.StatusCode)
+
+	// The type is sniffed from the content, not taken from the name
+	resp = tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "script.pdf", []byte("#!/bin/sh\nrm -rf /\n")), "")
+	suite.Equal(415, resp.StatusCode)
+	suite.Equal(uploads.CodeUnsupportedFileType, tests.DecodeError(suite.T(), resp).Code)
+
+	tests.SetConfig(suite.T(), "filesystems.max_size", 16)
+	resp = tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "a.pdf", pdfContent), "")
+	suite.Equal(413, resp.StatusCode)
+	suite.Equal(uploads.CodeFileTooLarge, tests.DecodeError(suite.T(), resp).Code)
+
+	var count int64
+	database.Connect.Model(&models.File{}).Count(&count)
+	suite.Zero(count)
+}
+
+func (suite *FileControllerSuite) TestStoresOnS3Disk() {
+	stub := useS3Disk(suite.T())
+
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "report.pdf", pdfContent), "")
+	suite.Require().Equal(201, resp.StatusCode)
+	file := tests.DecodeEnvelope[models.File](suite.T(), resp).Data
+	suite.Equal("s3", file.Disk)
+
+	var stored models.File
+	suite.Require().NoError(database.Connect.First(&stored, file.ID).Error)
+	object, ok := stub.Object("uploads", stored.Path)
+	suite.Require().True(ok)
+	suite.Equal(pdfContent, object.Body)
+	suite.Equal("application/pdf", object.ContentType)
+}
+
+// upload stores content as the file of the suite's user
+func (suite *FileControllerSuite) upload(content []byte) models.File {
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "report.pdf", content), "")
+	suite.Require().Equal(201, resp.StatusCode)
+	return tests.DecodeEnvelope[models.File](suite.T(), resp).Data
+}
+
+// downloadURL asks for a temporary URL of the file as token
+func (suite *FileControllerSuite) downloadURL(id uint, token, params string) *http.Response {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/files/%d/url?%s", id, params), nil)
+	req.Header.Set("Authorization", token)
+	return tests.Send(suite.T(), suite.App, req, "")
+}
+
+func (suite *FileControllerSuite) mustDownloadURL(id uint, token, params string) string {
+	resp := suite.downloadURL(id, token, params)
+	suite.Require().Equal(200, resp.StatusCode)
+	return tests.DecodeEnvelope[controllers.FileURLResponse](suite.T(), resp).Data.URL
+}
+
+// download requests link from the app, without a token
+func (suite *FileControllerSuite) download(link string, header ...string) *http.Response {
+	parsed, err := url.Parse(link)
+	suite.Require().NoError(err)
+	req, _ := http.NewRequest("GET", parsed.RequestURI(), nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	return tests.Send(suite.T(), suite.App, req, "")
+}
+
+func (suite *FileControllerSuite) TestDownloadsThroughSignedURL() {
+	file := suite.upload(pdfContent)
+
+	link := suite.mustDownloadURL(file.ID, suite.token, "")
+	resp := suite.download(link)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal("application/pdf", resp.Header.Get("Content-Type"))
+	suite.Equal("attachment; filename=report.pdf", resp.Header.Get("Content-Disposition"))
+	body, _ := io.ReadAll(resp.Body)
+	suite.Equal(pdfContent, body)
+
+	resp = suite.download(link, "Range", "bytes=0-3")
+	suite.Require().Equal(206, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	suite.Equal("%PDF", string(body))
+
+	inline := suite.mustDownloadURL(file.ID, suite.token, "disposition=inline")
+	resp = suite.download(inline)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal("inline; filename=report.pdf", resp.Header.Get("Content-Disposition"))
+
+	suite.Equal(422, suite.downloadURL(file.ID, suite.token, "disposition=embed").StatusCode)
+	suite.Equal(404, suite.downloadURL(file.ID+1, suite.token, "").StatusCode)
+}
+
+func (suite *FileControllerSuite) TestRejectsInvalidDownloadURLs() {
+	file := suite.upload(pdfContent)
+	link := suite.mustDownloadURL(file.ID, suite.token, "")
+
+	for _, invalid := range []string{
+		fmt.Sprintf("/api/files/%d", file.ID),
+		strings.Replace(link, "disposition=attachment", "disposition=inline", 1),
+		strings.Replace(link, "expires=", "expires=9", 1),
+		strings.Replace(link, fmt.Sprintf("/files/%d?", file.ID), fmt.Sprintf("/files/%d?", file.ID+1), 1),
+	} {
+		resp := suite.download(invalid)
+		suite.Equal(403, resp.StatusCode, invalid)
+		suite.Equal(downloads.CodeInvalidSignature, tests.DecodeError(suite.T(), resp).Code)
+	}
+
+	tests.SetConfig(suite.T(), "filesystems.downloads.expiration", "-1m")
+	suite.Equal(403, suite.download(suite.mustDownloadURL(file.ID, suite.token, "")).StatusCode)
+}
+
+func (suite *FileControllerSuite) TestChecksDownloadsPerUser() {
+	file := suite.upload(pdfContent)
+	link := suite.mustDownloadURL(file.ID, suite.token, "")
+
+	bob, bobToken := tests.RegisterUser(suite.T(), suite.App, "bob")
+	suite.Equal(403, suite.downloadURL(file.ID, bobToken, "").StatusCode)
+	suite.Equal(401, suite.downloadURL(file.ID, "", "").StatusCode)
+
+	// Administrators may download any file
+	database.Connect.Model(&models.User{}).Where("id = ?", bob.ID).Update("role", models.RoleAdmin)
+	suite.Equal(200, suite.download(suite.mustDownloadURL(file.ID, bobToken, "")).StatusCode)
+
+	// Revoking the owner's tokens revokes their URLs
+	suite.Equal(200, suite.download(link).StatusCode)
+	database.Connect.Model(&models.User{}).Where("email = ?", "alice@example.com").Update("token_version", gorm.Expr("token_version + 1"))
+	suite.Equal(403, suite.download(link).StatusCode)
+}
+
+func (suite *FileControllerSuite) TestDownloadsFromS3Disk() {
+	stub := useS3Disk(suite.T())
+	file := suite.upload(pdfContent)
+
+	link := suite.mustDownloadURL(file.ID, suite.token, "")
+	suite.True(strings.HasPrefix(link, stub.URL+"/uploads/"), link)
+	suite.Contains(link, "X-Amz-Signature=")
+	suite.Contains(link, "response-content-disposition=")
+	resp, err := http.Get(link)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	suite.Equal(pdfContent, body)
+
+	// Proxied downloads are streamed from the bucket by the app
+	tests.SetConfig(suite.T(), "filesystems.disks.s3.temporary_urls", downloads.ModeProxy)
+	link = suite.mustDownloadURL(file.ID, suite.token, "")
+	suite.False(strings.HasPrefix(link, stub.URL), link)
+	resp = suite.download(link)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal("application/pdf", resp.Header.Get("Content-Type"))
+	body, _ = io.ReadAll(resp.Body)
+	suite.Equal(pdfContent, body)
+}
+
+// pngImage encodes a gradient of width x height as PNG
+func pngImage(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// generateVariants runs the variants job dispatched for the last upload,
+// as the queue worker would
+func (suite *FileControllerSuite) generateVariants() {
+	var job coremodels.Job
+	err := database.Connect.Where("type = ?", jobs.GenerateImageVariants{}.Type()).Order("id desc").First(&job).Error
+	suite.Require().NoError(err)
+	suite.Require().NoError(jobs.GenerateImageVariants{}.Handle(job.Payload))
+}
+
+func (suite *FileControllerSuite) metadata(id uint, token string) *http.Response {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/files/%d/metadata", id), nil)
+	req.Header.Set("Authorization", token)
+	return tests.Send(suite.T(), suite.App, req, "")
+}
+
+func (suite *FileControllerSuite) TestGeneratesImageVariants() {
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "photo.png", pngImage(400, 300)), "")
+	suite.Require().Equal(201, resp.StatusCode)
+	file := tests.DecodeEnvelope[models.File](suite.T(), resp).Data
+
+	resp = suite.metadata(file.ID, suite.token)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Empty(tests.DecodeEnvelope[controllers.FileMetadataResponse](suite.T(), resp).Data.File.Variants)
+
+	suite.generateVariants()
+
+	resp = suite.metadata(file.ID, suite.token)
+	suite.Require().Equal(200, resp.StatusCode)
+	data := tests.DecodeEnvelope[controllers.FileMetadataResponse](suite.T(), resp).Data
+	suite.NotEmpty(data.File.URL)
+	suite.Require().Len(data.File.Variants, 2)
+
+	// Images are never scaled up
+	medium := data.File.Variants[0]
+	suite.Equal("medium", medium.Name)
+	suite.Equal("image/png", medium.MimeType)
+	suite.Equal([2]int{400, 300}, [2]int{medium.Width, medium.Height})
+
+	thumb := data.File.Variants[1]
+	suite.Equal("thumb", thumb.Name)
+	suite.Equal("image/jpeg", thumb.MimeType)
+	suite.Equal([2]int{200, 200}, [2]int{thumb.Width, thumb.Height})
+
+	resp = suite.download(thumb.URL)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal("image/jpeg", resp.Header.Get("Content-Type"))
+	suite.Equal("attachment; filename=photo-thumb.jpg", resp.Header.Get("Content-Disposition"))
+	cfg, format, err := image.DecodeConfig(resp.Body)
+	suite.Require().NoError(err)
+	suite.Equal("jpeg", format)
+	suite.Equal([2]int{200, 200}, [2]int{cfg.Width, cfg.Height})
+
+	// The signature covers the variant
+	suite.Equal(403, suite.download(strings.Replace(thumb.URL, "variant=thumb", "variant=medium", 1)).StatusCode)
+	suite.Equal(404, suite.downloadURL(file.ID, suite.token, "variant=huge").StatusCode)
+	suite.Equal(200, suite.download(suite.mustDownloadURL(file.ID, suite.token, "variant=medium")).StatusCode)
+
+	_, bobToken := tests.RegisterUser(suite.T(), suite.App, "bob")
+	suite.Equal(403, suite.metadata(file.ID, bobToken).StatusCode)
+
+	var stored models.File
+	suite.Require().NoError(database.Connect.First(&stored, file.ID).Error)
+	suite.Require().NoError(uploads.Delete(context.Background(), &stored))
+	var count int64
+	database.Connect.Model(&models.FileVariant{}).Count(&count)
+	suite.Zero(count)
+	entries, _ := os.ReadDir(filepath.Join(suite.dir, filepath.Dir(stored.Path)))
+	suite.Empty(entries)
+}
+
+func (suite *FileControllerSuite) TestSkipsVariantsOfOtherFiles() {
+	suite.upload(pdfContent)
+
+	tests.SetConfig(suite.T(), "filesystems.images.enabled", false)
+	resp := tests.Send(suite.T(), suite.App, uploadRequest("/api/files", suite.token, "file", "photo.png", pngImage(40, 30)), "")
+	suite.Require().Equal(201, resp.StatusCode)
+
+	var count int64
+	database.Connect.Model(&coremodels.Job{}).Where("type = ?", jobs.GenerateImageVariants{}.Type()).Count(&count)
+	suite.Zero(count)
+}
+
+func TestFileControllerSuiteRun(t *testing.T) {
+	suite.Run(t, new(FileControllerSuite))
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	coremodels "github.com/galaplate/core/models"
+	"github.com/galaplate/core/queue"
+	_ "github.com/galaplate/galaplate/db/migrations"
+	"github.com/galaplate/galaplate/pkg/health"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthControllerSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (t *HealthControllerSuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+}
+
+func (suite *HealthControllerSuite) ready() (int, health.Report) {
+	req, err := http.NewRequest("GET", "/health/ready", nil)
+	suite.Require().NoError(err)
+
+	resp, err := suite.App.Test(req, 10000)
+	suite.Require().NoError(err)
+
+	var report health.Report
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&report))
+	return resp.StatusCode, report
+}
+
+func (suite *HealthControllerSuite) TestLiveDoesNotRunChecks() {
+	health.Register("broken", 0, func(ctx context.Context) error {
+		return errors.New("down")
+	})
+	defer health.DefaultRegistry.Unregister("broken")
+
+	req, err := http.NewRequest("GET", "/health/live", nil)
+	suite.Require().NoError(err)
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(200, resp.StatusCode)
+}
+
+func (suite *HealthControllerSuite) TestReadyRunsBuiltInChecks() {
+	status, report := suite.ready()
+
+	suite.Equal(200, status)
+	suite.Equal(health.StatusOK, report.Status)
+	for _, name := range []string{"database", "migrations", "storage", "queue", "disk"} {
+		suite.Contains(report.Checks, name)
+		suite.NotEqual(health.StatusFail, report.Checks[name].Status, report.Checks[name].Error)
+	}
+}
+
+func (suite *HealthControllerSuite) TestReadyFailsWithCustomCheck() {
+	health.Register("payments", 0, func(ctx context.Context) error {
+		return errors.New("gateway unreachable")
+	})
+	defer health.DefaultRegistry.Unregister("payments")
+
+	tests.SetConfig(suite.T(), "app.debug", false)
+	status, report := suite.ready()
+
+	suite.Equal(503, status)
+	suite.Equal(health.StatusFail, report.Status)
+	suite.Equal("check failed", report.Checks["payments"].Error, "errors are hidden from the public")
+	suite.Equal(health.StatusOK, report.Checks["database"].Status)
+
+	tests.SetConfig(suite.T(), "app.debug", true)
+	_, report = suite.ready()
+	suite.Equal("gateway unreachable", report.Checks["payments"].Error)
+}
+
+func (suite *HealthControllerSuite) TestReadyTimesOutSlowChecks() {
+	health.Register("slow", 50*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	})
+	defer health.DefaultRegistry.Unregister("slow")
+	tests.SetConfig(suite.T(), "app.debug", true)
+
+	start := time.Now()
+	status, report := suite.ready()
+
+	suite.Less(time.Since(start), time.Second)
+	suite.Equal(503, status)
+	suite.Contains(report.Checks["slow"].Error, "timed out")
+}
+
+func (suite *HealthControllerSuite) TestReadyProbesTheDefaultDisk() {
+	s3 := tests.NewS3Stub()
+	suite.T().Cleanup(s3.Close)
+	tests.SetConfig(suite.T(), "filesystems.default", "s3")
+	tests.SetConfig(suite.T(), "filesystems.disks.s3.bucket", "uploads")
+	tests.SetConfig(suite.T(), "filesystems.disks.s3.key", "test-key")
+	tests.SetConfig(suite.T(), "filesystems.disks.s3.secret", "test-secret")
+	tests.SetConfig(suite.T(), "filesystems.disks.s3.endpoint", s3.URL)
+	tests.SetConfig(suite.T(), "filesystems.disks.s3.use_path_style_endpoint", true)
+	tests.SetConfig(suite.T(), "app.debug", true)
+	storage.Reset()
+	suite.T().Cleanup(storage.Reset)
+
+	status, report := suite.ready()
+	suite.Equal(200, status)
+	suite.Equal(health.StatusOK, report.Checks["storage"].Status)
+	suite.Empty(s3.Keys(), "the probe file is removed")
+
+	// The S3 client retries, so an unreachable disk usually times out
+	s3.Close()
+	tests.SetConfig(suite.T(), "health.timeout", "500ms")
+	status, report = suite.ready()
+	suite.Equal(503, status)
+	suite.Equal(health.StatusFail, report.Checks["storage"].Status)
+}
+
+func (suite *HealthControllerSuite) TestReadyFollowsTheQueueHeartbeat() {
+	// The backlog does not fail readiness
+	suite.Require().NoError(database.Connect.Create(&coremodels.Job{
+		Type:        "stalled_job",
+		Payload:     json.RawMessage(`[]`),
+		State:       coremodels.JobPending,
+		AvailableAt: time.Now().Add(-time.Hour),
+		CreatedAt:   time.Now().Add(-time.Hour),
+	}).Error)
+	status, report := suite.ready()
+	suite.Equal(200, status)
+	suite.Equal(health.StatusSkipped, report.Checks["queue"].Status, "no worker runs in this process")
+
+	heartbeat := jobs.NewHeartbeat(0)
+	suite.Require().NoError(database.Connect.Use(heartbeat))
+	suite.T().Cleanup(func() { heartbeat.Stop(context.Background()) })
+	tests.SetConfig(suite.T(), "app.debug", true)
+
+	status, report = suite.ready()
+	suite.Equal(503, status)
+	suite.Contains(report.Checks["queue"].Error, "not polled")
+
+	q := queue.New(1)
+	q.Start(1)
+	suite.Eventually(func() bool {
+		_, report := suite.ready()
+		return report.Checks["queue"].Status == health.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+	suite.Require().NoError(q.Shutdown(context.Background()))
+
+	var worker models.QueueWorker
+	suite.Require().NoError(database.Connect.Where("worker = ?", heartbeat.Worker()).First(&worker).Error)
+
+	tests.SetConfig(suite.T(), "health.queue.max_heartbeat_age", "1ms")
+	time.Sleep(10 * time.Millisecond)
+	status, report = suite.ready()
+	suite.Equal(503, status)
+	suite.Contains(report.Checks["queue"].Error, "last polled")
+}
+
+func TestHealthControllerSuiteRun(t *testing.T) {
+	suite.Run(t, new(HealthControllerSuite))
+}
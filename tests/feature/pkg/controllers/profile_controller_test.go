@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/audit"
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ProfileControllerSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (suite *ProfileControllerSuite) request(method, token, body string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest(method, "/api/profile", body), token)
+}
+
+func (suite *ProfileControllerSuite) TestShowsProfile() {
+	_, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	resp := suite.request("GET", token, "")
+	suite.Equal(200, resp.StatusCode)
+
+	user := tests.DecodeEnvelope[models.User](suite.T(), resp).Data
+	suite.Equal("alice", user.Username)
+}
+
+func (suite *ProfileControllerSuite) TestUpdatesOnlyGivenFields() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	resp := suite.request("PATCH", token, `{"description": "Gopher"}`)
+	suite.Equal(200, resp.StatusCode)
+
+	data := tests.DecodeEnvelope[models.User](suite.T(), resp).Data
+	suite.Equal("Gopher", data.Description)
+	suite.Equal("alice", data.Username)
+	suite.Equal("alice@example.com", data.Email)
+
+	var stored models.User
+	suite.Require().NoError(database.Connect.First(&stored, user.ID).Error)
+	suite.Equal("Gopher", stored.Description)
+}
+
+func (suite *ProfileControllerSuite) TestEmailChangeClearsVerification() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+	database.Connect.Model(&models.User{}).Where("id = ?", user.ID).Update("email_verified_at", time.Now())
+
+	resp := suite.request("PATCH", token, `{"email": "new@example.com"}`)
+	suite.Equal(200, resp.StatusCode)
+
+	data := tests.DecodeEnvelope[models.User](suite.T(), resp).Data
+	suite.Equal("new@example.com", data.Email)
+	suite.Nil(data.EmailVerifiedAt)
+}
+
+func (suite *ProfileControllerSuite) TestRejectsTakenUsernameAndEmail() {
+	tests.RegisterUser(suite.T(), suite.App, "bob")
+	_, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	resp := suite.request("PATCH", token, `{"username": "bob"}`)
+	suite.Equal(409, resp.StatusCode)
+
+	resp = suite.request("PATCH", token, `{"email": "bob@example.com"}`)
+	suite.Equal(409, resp.StatusCode)
+
+	resp = suite.request("PATCH", token, `{"username": "alice", "email": "alice@example.com"}`)
+	suite.Equal(200, resp.StatusCode, "keeping their own values is not a conflict")
+}
+
+func (suite *ProfileControllerSuite) TestValidatesFields() {
+	_, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	suite.Equal(422, suite.request("PATCH", token, `{"username": "ab"}`).StatusCode)
+	suite.Equal(422, suite.request("PATCH", token, `{"email": "not-an-email"}`).StatusCode)
+	suite.Equal(422, suite.request("PATCH", token, `{"description": "`+strings.Repeat("a", 256)+`"}`).StatusCode)
+	suite.Equal(401, suite.request("PATCH", "", `{"description": "x"}`).StatusCode)
+}
+
+func (suite *ProfileControllerSuite) TestRecordsAuditEntry() {
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	suite.request("PATCH", token, `{"username": "alicia", "description": "Gopher"}`)
+	suite.request("PATCH", token, `{"username": "alicia"}`)
+
+	var logs []models.AuditLog
+	suite.Require().NoError(database.Connect.Where("auditable_type = ? AND auditable_id = ? AND action <> ?", "users", fmt.Sprint(user.ID), "users.created").Find(&logs).Error)
+	suite.Require().Len(logs, 1, "requests changing nothing are not audited")
+
+	log := logs[0]
+	suite.Equal("profile.updated", log.Action)
+	suite.Equal(user.ID, *log.ActorID)
+	suite.NotEmpty(log.RequestID)
+
+	var changes audit.Changes
+	suite.Require().NoError(json.Unmarshal([]byte(log.Changes), &changes))
+	suite.Equal(audit.Change{Old: "alice", New: "alicia"}, changes["username"])
+	suite.Equal(audit.Change{Old: "", New: "Gopher"}, changes["description"])
+	suite.NotContains(changes, "email")
+}
+
+func (suite *ProfileControllerSuite) TestUpdateRefreshesCachedProfile() {
+	_, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	suite.request("GET", token, "")
+	suite.request("PATCH", token, `{"description": "Gopher"}`)
+
+	resp := suite.request("GET", token, "")
+	user := tests.DecodeEnvelope[models.User](suite.T(), resp).Data
+	suite.Equal("Gopher", user.Description)
+}
+
+func (suite *ProfileControllerSuite) uploadAvatar(token string, content []byte) *http.Response {
+	resp, err := suite.App.Test(uploadRequest("/api/profile/avatar", token, "avatar", "me.png", content))
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *ProfileControllerSuite) TestReplacesAvatar() {
+	dir := useLocalDisk(suite.T())
+	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	resp := suite.uploadAvatar(token, pngContent)
+	suite.Require().Equal(200, resp.StatusCode)
+	first := tests.DecodeEnvelope[controllers.AvatarResponse](suite.T(), resp).Data
+	suite.Equal(first.Avatar.ID, *first.User.AvatarID)
+	suite.Equal("image/png", first.Avatar.MimeType)
+
+	var previous models.File
+	suite.Require().NoError(database.Connect.First(&previous, first.Avatar.ID).Error)
+
+	resp = suite.uploadAvatar(token, pngContent)
+	suite.Require().Equal(200, resp.StatusCode)
+	second := tests.DecodeEnvelope[controllers.AvatarResponse](suite.T(), resp).Data
+	suite.Equal(second.Avatar.ID, *second.User.AvatarID)
+
+	suite.ErrorIs(database.Connect.First(&models.File{}, first.Avatar.ID).Error, gorm.ErrRecordNotFound)
+	suite.NoFileExists(filepath.Join(dir, previous.Path), "the previous avatar is deleted")
+
+	var actions []string
+	database.Connect.Model(&models.AuditLog{}).Where("auditable_id = ?", fmt.Sprint(user.ID)).Order("id").Pluck("action", &actions)
+	suite.Equal([]string{"users.created", "profile.avatar_updated", "profile.avatar_updated"}, actions)
+}
+
+func (suite *ProfileControllerSuite) TestAvatarMustBeAnImage() {
+	useLocalDisk(suite.T())
+	_, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+
+	resp := suite.uploadAvatar(token, pdfContent)
+	suite.Equal(415, resp.StatusCode, "PDFs are allowed for files but not for avatars")
+
+	var count int64
+	database.Connect.Model(&models.File{}).Count(&count)
+	suite.Zero(count)
+}
+
+func TestProfileControllerSuiteRun(t *testing.T) {
+	suite.Run(t, new(ProfileControllerSuite))
+}
@@ -25,7 +25,7 @@ func (t *TestControllerSuite) SetupTest() {
 func (suite *TestControllerSuite) TestCanAccessHealtCheck() {
 	t := suite.T()
 
-	req, err := http.NewRequest("GET", "/api/health", nil)
+	req, err := http.NewRequest("GET", "/health/live", nil)
 	assert.NoError(t, err)
 
 	resp, err := suite.App.Test(req)
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/policies"
+	"github.com/galaplate/galaplate/pkg/uploads"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type UploadControllerSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	dir    string
+	chunks string
+	token  string
+}
+
+func (suite *UploadControllerSuite) SetupTest() {
+	suite.RefreshDatabaseBeforeEachTest.SetupTest()
+	suite.dir = useLocalDisk(suite.T())
+	suite.chunks = suite.T().TempDir()
+	tests.SetConfig(suite.T(), "filesystems.chunked.path", suite.chunks)
+
+	_, suite.token = tests.RegisterUser(suite.T(), suite.App, "alice")
+}
+
+func (suite *UploadControllerSuite) begin(name string, size int) models.ChunkedUpload {
+	body := fmt.Sprintf(`{"name": %q, "size": %d}`, name, size)
+	resp := tests.Send(suite.T(), suite.App, tests.JSONRequest("POST", "/api/uploads", body), suite.token)
+	suite.Require().Equal(201, resp.StatusCode)
+	return tests.DecodeEnvelope[models.ChunkedUpload](suite.T(), resp).Data
+}
+
+func (suite *UploadControllerSuite) appendChunk(id uint, offset int, chunk []byte) *http.Response {
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/uploads/%d", id), bytes.NewReader(chunk))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set(controllers.HeaderUploadOffset, strconv.Itoa(offset))
+	return tests.Send(suite.T(), suite.App, req, suite.token)
+}
+
+func (suite *UploadControllerSuite) complete(id uint, body string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest("POST", fmt.Sprintf("/api/uploads/%d/complete", id), body), suite.token)
+}
+
+func (suite *UploadControllerSuite) TestUploadsInChunks() {
+	upload := suite.begin("../report.pdf", len(pdfContent))
+	suite.Equal("report.pdf", upload.Name)
+	suite.Zero(upload.Received)
+
+	resp := suite.appendChunk(upload.ID, 0, pdfContent[:10])
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal("10", resp.Header.Get(controllers.HeaderUploadOffset))
+
+	// A retried chunk is refused and the client resumes from the offset
+	resp = suite.appendChunk(upload.ID, 0, pdfContent[:10])
+	suite.Equal(409, resp.StatusCode)
+	suite.Equal(uploads.CodeOffsetMismatch, tests.DecodeError(suite.T(), resp).Code)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/uploads/%d", upload.ID), nil)
+	resp = tests.Send(suite.T(), suite.App, req, suite.token)
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Equal(int64(10), tests.DecodeEnvelope[models.ChunkedUpload](suite.T(), resp).Data.Received)
+
+	resp = suite.complete(upload.ID, "")
+	suite.Equal(409, resp.StatusCode)
+	suite.Equal(uploads.CodeUploadIncomplete, tests.DecodeError(suite.T(), resp).Code)
+
+	suite.Require().Equal(200, suite.appendChunk(upload.ID, 10, pdfContent[10:]).StatusCode)
+
+	sum := sha256.Sum256(pdfContent)
+	resp = suite.complete(upload.ID, fmt.Sprintf(`{"checksum": %q}`, hex.EncodeToString(sum[:])))
+	suite.Require().Equal(201, resp.StatusCode)
+	file := tests.DecodeEnvelope[models.File](suite.T(), resp).Data
+	suite.Equal("report.pdf", file.Name)
+	suite.Equal("application/pdf", file.MimeType)
+	suite.Equal(hex.EncodeToString(sum[:]), file.Checksum)
+
+	var stored models.File
+	suite.Require().NoError(database.Connect.First(&stored, file.ID).Error)
+	content, err := os.ReadFile(filepath.Join(suite.dir, stored.Path))
+	suite.Require().NoError(err)
+	suite.Equal(pdfContent, content)
+
+	// The upload is over and its chunks are gone
+	resp = tests.Send(suite.T(), suite.App, req.Clone(req.Context()), suite.token)
+	suite.Equal(404, resp.StatusCode)
+	entries, _ := os.ReadDir(suite.chunks)
+	suite.Empty(entries)
+}
+
+func (suite *UploadControllerSuite) TestCompletesOnlyWrittenUploads() {
+	// Complete is retried until the chunk is written
+	tests.SetConfig(suite.T(), "ratelimit.limiters.api.limit", 100000)
+	policies.RegisterRateLimitPolicies()
+	suite.T().Cleanup(policies.RegisterRateLimitPolicies)
+
+	// A large last chunk takes a while to write
+	content := append(bytes.Clone(pdfContent), bytes.Repeat([]byte{'\n'}, 4<<20)...)
+	sum := sha256.Sum256(content)
+	checksum := fmt.Sprintf(`{"checksum": %q}`, hex.EncodeToString(sum[:]))
+
+	for range 30 {
+		upload := suite.begin("report.pdf", len(content))
+		suite.Require().Equal(200, suite.appendChunk(upload.ID, 0, content[:10]).StatusCode)
+
+		// Complete runs while the last chunk is appended: it either finds
+		// the upload incomplete or every byte written
+		var wg sync.WaitGroup
+		var appended, completed *http.Response
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			appended = suite.appendChunk(upload.ID, 10, content[10:])
+		}()
+		go func() {
+			defer wg.Done()
+			for completed == nil || completed.StatusCode == 409 {
+				completed = suite.complete(upload.ID, checksum)
+			}
+		}()
+		wg.Wait()
+
+		suite.Equal(200, appended.StatusCode)
+		suite.Require().Equal(201, completed.StatusCode)
+		suite.Equal(hex.EncodeToString(sum[:]), tests.DecodeEnvelope[models.File](suite.T(), completed).Data.Checksum)
+	}
+}
+
+func (suite *UploadControllerSuite) TestRejectsInvalidChunks() {
+	tests.SetConfig(suite.T(), "filesystems.max_size", 1024)
+	resp := tests.Send(suite.T(), suite.App, tests.JSONRequest("POST", "/api/uploads", `{"name": "big.pdf", "size": 2048}`), suite.token)
+	suite.Equal(413, resp.StatusCode)
+	suite.Equal(uploads.CodeFileTooLarge, tests.DecodeError(suite.T(), resp).Code)
+
+	upload := suite.begin("report.pdf", len(pdfContent))
+
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/uploads/%d", upload.ID), bytes.NewReader(pdfContent))
+	suite.Equal(422, tests.Send(suite.T(), suite.App, req, suite.token).StatusCode)
+
+	resp = suite.appendChunk(upload.ID, 0, append(pdfContent, 'x'))
+	suite.Equal(413, resp.StatusCode)
+	suite.Equal(uploads.CodeFileTooLarge, tests.DecodeError(suite.T(), resp).Code)
+
+	tests.SetConfig(suite.T(), "filesystems.chunked.max_chunk_size", 4)
+	resp = suite.appendChunk(upload.ID, 0, pdfContent[:8])
+	suite.Equal(413, resp.StatusCode)
+	suite.Equal(uploads.CodeChunkTooLarge, tests.DecodeError(suite.T(), resp).Code)
+
+	// Uploads of other users are not found
+	_, other := tests.RegisterUser(suite.T(), suite.App, "bob")
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/uploads/%d", upload.ID), nil)
+	suite.Equal(404, tests.Send(suite.T(), suite.App, req, other).StatusCode)
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/uploads/%d", upload.ID), nil)
+	suite.Equal(404, tests.Send(suite.T(), suite.App, req, other).StatusCode)
+	suite.Equal(200, tests.Send(suite.T(), suite.App, req.Clone(req.Context()), suite.token).StatusCode)
+}
+
+func (suite *UploadControllerSuite) TestChecksCompletedUploads() {
+	upload := suite.begin("report.pdf", len(pdfContent))
+	suite.Require().Equal(200, suite.appendChunk(upload.ID, 0, pdfContent).StatusCode)
+
+	resp := suite.complete(upload.ID, fmt.Sprintf(`{"checksum": %q}`, hex.EncodeToString(make([]byte, 32))))
+	suite.Equal(422, resp.StatusCode)
+	suite.Equal(uploads.CodeChecksumMismatch, tests.DecodeError(suite.T(), resp).Code)
+
+	script := []byte("#!/bin/sh\nrm -rf /\n")
+	upload = suite.begin("script.pdf", len(script))
+	suite.Require().Equal(200, suite.appendChunk(upload.ID, 0, script).StatusCode)
+	resp = suite.complete(upload.ID, "")
+	suite.Equal(415, resp.StatusCode)
+	suite.Equal(uploads.CodeUnsupportedFileType, tests.DecodeError(suite.T(), resp).Code)
+
+	var count int64
+	database.Connect.Model(&models.File{}).Count(&count)
+	suite.Zero(count)
+}
+
+func (suite *UploadControllerSuite) TestPurgesAbandonedUploads() {
+	abandoned := suite.begin("old.pdf", len(pdfContent))
+	suite.Require().Equal(200, suite.appendChunk(abandoned.ID, 0, pdfContent[:10]).StatusCode)
+	active := suite.begin("new.pdf", len(pdfContent))
+	suite.Require().Equal(200, suite.appendChunk(active.ID, 0, pdfContent[:10]).StatusCode)
+
+	database.Connect.Model(&models.ChunkedUpload{}).Where("id = ?", abandoned.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	purged, err := uploads.PurgeExpired(context.Background())
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), purged)
+
+	var ids []uint
+	database.Connect.Model(&models.ChunkedUpload{}).Pluck("id", &ids)
+	suite.Equal([]uint{active.ID}, ids)
+	entries, _ := os.ReadDir(suite.chunks)
+	suite.Len(entries, 1)
+}
+
+func TestUploadControllerSuiteRun(t *testing.T) {
+	suite.Run(t, new(UploadControllerSuite))
+}
@@ -0,0 +1,142 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/galaplate/galaplate/db/migrations"
+	"github.com/galaplate/galaplate/pkg/httpcache"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPCacheSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	hits int
+}
+
+func (t *HTTPCacheSuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+
+	t.hits = 0
+	policy := httpcache.Policy{Enabled: true, CacheControl: "no-cache", TTL: time.Minute}
+	t.App.Get("/__cache/counter", httpcache.HTTPCacheMiddlewareInstance.Handler(policy), func(c *fiber.Ctx) error {
+		t.hits++
+		httpcache.Tag(c, "counter")
+		return c.SendString(fmt.Sprintf("hit %d", t.hits))
+	}).Name("test.counter")
+}
+
+func (suite *HTTPCacheSuite) get(path string, headers map[string]string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", path, nil)
+	suite.Require().NoError(err)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	return resp, string(body)
+}
+
+func (suite *HTTPCacheSuite) TestSendsETagAndCacheControl() {
+	first, body := suite.get("/api/test/1", nil)
+	suite.Equal(200, first.StatusCode)
+	suite.Regexp(`^"[0-9a-f]{32}"$`, first.Header.Get("ETag"))
+	suite.Equal("public, max-age=60", first.Header.Get("Cache-Control"))
+	suite.Equal("MISS", first.Header.Get(httpcache.CacheHeader))
+
+	second, secondBody := suite.get("/api/test/1", nil)
+	suite.Equal("HIT", second.Header.Get(httpcache.CacheHeader))
+	suite.Equal(first.Header.Get("ETag"), second.Header.Get("ETag"))
+	suite.Equal(body, secondBody)
+
+	other, _ := suite.get("/api/test/2", nil)
+	suite.Equal("MISS", other.Header.Get(httpcache.CacheHeader))
+	suite.NotEqual(first.Header.Get("ETag"), other.Header.Get("ETag"))
+}
+
+func (suite *HTTPCacheSuite) TestAnswersIfNoneMatchWithNotModified() {
+	first, _ := suite.get("/api/test/1", nil)
+	etag := first.Header.Get("ETag")
+
+	resp, body := suite.get("/api/test/1", map[string]string{"If-None-Match": etag})
+	suite.Equal(304, resp.StatusCode)
+	suite.Empty(body)
+	suite.Equal(etag, resp.Header.Get("ETag"))
+
+	resp, _ = suite.get("/api/test/1", map[string]string{"If-None-Match": `"stale", W/` + etag})
+	suite.Equal(304, resp.StatusCode, "If-None-Match uses the weak comparison")
+
+	resp, _ = suite.get("/api/test/1", map[string]string{"If-None-Match": `"stale"`})
+	suite.Equal(200, resp.StatusCode)
+}
+
+func (suite *HTTPCacheSuite) TestCachesPerUserWithWeakETag() {
+	aliceUser, alice := tests.RegisterUser(suite.T(), suite.App, "alice")
+	_, bob := tests.RegisterUser(suite.T(), suite.App, "bob")
+
+	resp, aliceBody := suite.get("/api/profile", map[string]string{"Authorization": alice})
+	suite.Equal(200, resp.StatusCode)
+	suite.True(strings.HasPrefix(resp.Header.Get("ETag"), `W/"`))
+	suite.Contains(resp.Header.Get("Vary"), "Authorization")
+	suite.Contains(aliceBody, "alice@example.com")
+
+	resp, bobBody := suite.get("/api/profile", map[string]string{"Authorization": bob})
+	suite.Equal("MISS", resp.Header.Get(httpcache.CacheHeader), "users do not share entries")
+	suite.Contains(bobBody, "bob@example.com")
+
+	resp, _ = suite.get("/api/profile", map[string]string{"Authorization": alice})
+	suite.Equal("HIT", resp.Header.Get(httpcache.CacheHeader))
+
+	suite.Equal(1, httpcache.InvalidateUser(aliceUser.ID))
+	resp, _ = suite.get("/api/profile", map[string]string{"Authorization": alice})
+	suite.Equal("MISS", resp.Header.Get(httpcache.CacheHeader))
+	resp, _ = suite.get("/api/profile", map[string]string{"Authorization": bob})
+	suite.Equal("HIT", resp.Header.Get(httpcache.CacheHeader))
+}
+
+func (suite *HTTPCacheSuite) TestDoesNotCacheErrors() {
+	resp, _ := suite.get("/api/profile", nil)
+	suite.Equal(401, resp.StatusCode)
+	suite.Empty(resp.Header.Get("ETag"))
+	suite.Empty(resp.Header.Get(httpcache.CacheHeader))
+}
+
+func (suite *HTTPCacheSuite) TestInvalidatesByTagAndRoute() {
+	_, body := suite.get("/__cache/counter", nil)
+	suite.Equal("hit 1", body)
+	_, body = suite.get("/__cache/counter", nil)
+	suite.Equal("hit 1", body)
+
+	suite.Equal(1, httpcache.Invalidate("counter"))
+	_, body = suite.get("/__cache/counter", nil)
+	suite.Equal("hit 2", body)
+
+	suite.Equal(1, httpcache.InvalidateRoute("test.counter"))
+	_, body = suite.get("/__cache/counter", nil)
+	suite.Equal("hit 3", body)
+
+	httpcache.Flush()
+	_, body = suite.get("/__cache/counter", nil)
+	suite.Equal("hit 4", body)
+}
+
+func (suite *HTTPCacheSuite) TestNotModified() {
+	suite.True(httpcache.NotModified("*", `"a"`))
+	suite.True(httpcache.NotModified(`W/"a"`, `"a"`))
+	suite.True(httpcache.NotModified(`"b", "a"`, `W/"a"`))
+	suite.False(httpcache.NotModified(`"b"`, `"a"`))
+	suite.False(httpcache.NotModified("", `"a"`))
+}
+
+func TestHTTPCacheSuiteRun(t *testing.T) {
+	suite.Run(t, new(HTTPCacheSuite))
+}
@@ -0,0 +1,193 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/scheduler"
+	_ "github.com/galaplate/galaplate/db/migrations"
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/idempotency"
+	"github.com/galaplate/galaplate/pkg/models"
+	_ "github.com/galaplate/galaplate/pkg/scheduler"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencySuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+
+	entered chan struct{}
+	release chan struct{}
+	calls   int
+	mu      sync.Mutex
+}
+
+func (t *IdempotencySuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+
+	t.entered = make(chan struct{}, 1)
+	t.release = make(chan struct{})
+	t.calls = 0
+
+	t.App.Post("/__idempotency/slow", idempotency.Middleware(), func(c *fiber.Ctx) error {
+		t.entered <- struct{}{}
+		<-t.release
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true})
+	})
+	t.App.Post("/__idempotency/flaky", idempotency.Middleware(), func(c *fiber.Ctx) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.calls++
+		if t.calls == 1 {
+			return errors.New("upstream unavailable")
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"call": t.calls})
+	})
+}
+
+func (suite *IdempotencySuite) post(path, key, body string) (*http.Response, string) {
+	req, err := http.NewRequest("POST", path, strings.NewReader(body))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, err := suite.App.Test(req, 5000)
+	suite.Require().NoError(err)
+	content, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	return resp, string(content)
+}
+
+const registerBody = `{"username": "retry", "email": "retry@example.com", "password": "password123"}`
+
+func (suite *IdempotencySuite) TestReplaysResponseOfRetriedRequest() {
+	first, firstBody := suite.post("/api/register", "key-1", registerBody)
+	suite.Equal(201, first.StatusCode)
+	suite.Empty(first.Header.Get(idempotency.ReplayedHeader))
+
+	retry, retryBody := suite.post("/api/register", "key-1", registerBody)
+	suite.Equal(201, retry.StatusCode)
+	suite.Equal("true", retry.Header.Get(idempotency.ReplayedHeader))
+	suite.Equal(firstBody, retryBody)
+	suite.Contains(retry.Header.Get("Content-Type"), "application/json")
+
+	var users int64
+	database.Connect.Model(&models.User{}).Where("email = ?", "retry@example.com").Count(&users)
+	suite.Equal(int64(1), users)
+}
+
+func (suite *IdempotencySuite) TestEncryptsStoredBodies() {
+	_, body := suite.post("/api/register", "key-1", registerBody)
+	var envelope struct {
+		Data controllers.AuthResponse `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal([]byte(body), &envelope))
+	token := envelope.Data.Token
+	suite.Require().NotEmpty(token)
+
+	var record models.IdempotencyKey
+	suite.Require().NoError(database.Connect.First(&record).Error)
+	suite.NotContains(string(record.ResponseBody), token)
+	suite.NotContains(string(record.ResponseBody), "retry@example.com")
+
+	// A body that does not decrypt, e.g. after app.key changed, is not sent
+	suite.Contract.Disable()
+	tests.SetConfig(suite.T(), "app.key", "rotated")
+	resp, _ := suite.post("/api/register", "key-1", registerBody)
+	suite.Equal(500, resp.StatusCode)
+}
+
+func (suite *IdempotencySuite) TestRequestsWithoutKeyAreNotStored() {
+	resp, _ := suite.post("/api/register", "", registerBody)
+	suite.Equal(201, resp.StatusCode)
+
+	resp, _ = suite.post("/api/register", "", registerBody)
+	suite.Equal(409, resp.StatusCode, "the duplicate reaches the controller")
+
+	var keys int64
+	database.Connect.Model(&models.IdempotencyKey{}).Count(&keys)
+	suite.Zero(keys)
+}
+
+func (suite *IdempotencySuite) TestRejectsKeyReusedForDifferentRequest() {
+	resp, _ := suite.post("/api/register", "key-2", registerBody)
+	suite.Equal(201, resp.StatusCode)
+
+	resp, body := suite.post("/api/register", "key-2", `{"username": "other", "email": "other@example.com", "password": "password123"}`)
+	suite.Equal(422, resp.StatusCode)
+	suite.Contains(body, idempotency.CodeKeyMismatch)
+}
+
+func (suite *IdempotencySuite) TestRejectsDuplicateWhileFirstRequestIsRunning() {
+	done := make(chan *http.Response)
+	go func() {
+		resp, _ := suite.post("/__idempotency/slow", "key-3", `{}`)
+		done <- resp
+	}()
+	<-suite.entered
+
+	duplicate, body := suite.post("/__idempotency/slow", "key-3", `{}`)
+	suite.Equal(409, duplicate.StatusCode)
+	suite.Contains(body, idempotency.CodeInProgress)
+	suite.NotEmpty(duplicate.Header.Get("Retry-After"))
+
+	close(suite.release)
+	suite.Equal(201, (<-done).StatusCode)
+
+	replayed, _ := suite.post("/__idempotency/slow", "key-3", `{}`)
+	suite.Equal(201, replayed.StatusCode)
+	suite.Equal("true", replayed.Header.Get(idempotency.ReplayedHeader))
+}
+
+func (suite *IdempotencySuite) TestReleasesKeyAfterServerError() {
+	failed, _ := suite.post("/__idempotency/flaky", "key-4", `{}`)
+	suite.Equal(500, failed.StatusCode)
+
+	retry, body := suite.post("/__idempotency/flaky", "key-4", `{}`)
+	suite.Equal(201, retry.StatusCode)
+	suite.Empty(retry.Header.Get(idempotency.ReplayedHeader))
+	suite.JSONEq(`{"call": 2}`, body)
+}
+
+func (suite *IdempotencySuite) TestTakesOverAbandonedKey() {
+	resp, _ := suite.post("/api/test", "key-5", `{"name": "first", "description": "first"}`)
+	suite.Require().Equal(201, resp.StatusCode)
+
+	// Simulate a process that crashed while holding the key
+	past := time.Now().Add(-time.Minute)
+	database.Connect.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "key-5").
+		Updates(map[string]any{"state": models.IdempotencyProcessing, "locked_until": past})
+
+	retry, _ := suite.post("/api/test", "key-5", `{"name": "first", "description": "first"}`)
+	suite.Equal(201, retry.StatusCode)
+	suite.Empty(retry.Header.Get(idempotency.ReplayedHeader), "the request runs again")
+}
+
+func (suite *IdempotencySuite) TestPurgesExpiredKeys() {
+	suite.post("/api/register", "key-6", registerBody)
+	suite.post("/api/test", "key-7", `{"name": "kept", "description": "kept"}`)
+	database.Connect.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "key-6").
+		Update("expires_at", time.Now().Add(-time.Hour))
+
+	deleted, err := idempotency.Purge(context.Background())
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), deleted)
+
+	suite.Contains(scheduler.SchedulerRegistry, "purgeidempotencykeys")
+}
+
+func TestIdempotencySuiteRun(t *testing.T) {
+	suite.Run(t, new(IdempotencySuite))
+}
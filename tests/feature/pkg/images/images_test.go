@@ -0,0 +1,147 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/galaplate/pkg/images"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type ImagesSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	dir string
+}
+
+func (suite *ImagesSuite) SetupTest() {
+	suite.RefreshDatabaseBeforeEachTest.SetupTest()
+	suite.dir = suite.T().TempDir()
+	tests.SetConfig(suite.T(), "filesystems.default", "local")
+	tests.SetConfig(suite.T(), "filesystems.disks.local.path", suite.dir)
+	tests.SetConfig(suite.T(), "filesystems.images.variants", map[string]any{
+		"small": map[string]any{"width": 100, "height": 100},
+	})
+	storage.Reset()
+	suite.T().Cleanup(storage.Reset)
+}
+
+// jpegWithOrientation encodes a width x height JPEG, red on its left half,
+// with an EXIF orientation tag
+func jpegWithOrientation(width, height int, orientation uint16) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{0, 0, 255, 255}
+			if x < width/2 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var encoded bytes.Buffer
+	jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 95})
+
+	// Big endian TIFF header with a single IFD entry: orientation, SHORT, 1
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	var out bytes.Buffer
+	out.Write(encoded.Bytes()[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(encoded.Bytes()[2:])
+	return out.Bytes()
+}
+
+func (suite *ImagesSuite) store(content []byte, mimeType string) *models.File {
+	disk, err := storage.Open("local")
+	suite.Require().NoError(err)
+	file := &models.File{Disk: "local", Path: "files/photo.jpg", Name: "photo.jpg", MimeType: mimeType, Size: int64(len(content))}
+	suite.Require().NoError(disk.Put(context.Background(), file.Path, bytes.NewReader(content), mimeType))
+	suite.Require().NoError(database.Connect.Create(file).Error)
+	return file
+}
+
+func (suite *ImagesSuite) TestAppliesExifOrientation() {
+	// Turned a quarter counterclockwise: the red left half ends up on top
+	file := suite.store(jpegWithOrientation(200, 100, 6), "image/jpeg")
+
+	variants, err := images.Generate(context.Background(), file)
+	suite.Require().NoError(err)
+	suite.Require().Len(variants, 1)
+	suite.Equal("files/photo-small.jpg", variants[0].Path)
+	suite.Equal([2]int{50, 100}, [2]int{variants[0].Width, variants[0].Height})
+
+	content, err := os.ReadFile(filepath.Join(suite.dir, variants[0].Path))
+	suite.Require().NoError(err)
+	img, err := jpeg.Decode(bytes.NewReader(content))
+	suite.Require().NoError(err)
+	top, _, _, _ := img.At(25, 10).RGBA()
+	bottom, _, _, _ := img.At(25, 90).RGBA()
+	suite.Greater(top, uint32(0xc000))
+	suite.Less(bottom, uint32(0x4000))
+
+	// Generating again replaces the variant
+	_, err = images.Generate(context.Background(), file)
+	suite.Require().NoError(err)
+	var count int64
+	database.Connect.Model(&models.FileVariant{}).Count(&count)
+	suite.Equal(int64(1), count)
+}
+
+func (suite *ImagesSuite) TestFillsDegenerateImages() {
+	tests.SetConfig(suite.T(), "filesystems.images.variants", map[string]any{
+		"banner": map[string]any{"width": 200, "height": 100, "mode": "fill"},
+		"tall":   map[string]any{"width": 100, "height": 200, "mode": "fill"},
+	})
+
+	for _, size := range [][2]int{{1, 50}, {50, 1}, {1, 1}} {
+		file := suite.store(jpegWithOrientation(size[0], size[1], 1), "image/jpeg")
+
+		variants, err := images.Generate(context.Background(), file)
+		suite.Require().NoError(err, size)
+		suite.Require().Len(variants, 2)
+		for _, variant := range variants {
+			suite.GreaterOrEqual(variant.Width, 1, size)
+			suite.GreaterOrEqual(variant.Height, 1, size)
+		}
+		suite.Require().NoError(database.Connect.Delete(file).Error)
+	}
+}
+
+func (suite *ImagesSuite) TestRejectsInvalidConfig() {
+	file := suite.store(jpegWithOrientation(20, 10, 1), "image/jpeg")
+
+	for _, variant := range []map[string]any{
+		{"width": 100, "format": "webp"},
+		{"width": 100, "mode": "fill"},
+		{"mode": "fit"},
+	} {
+		tests.SetConfig(suite.T(), "filesystems.images.variants", map[string]any{"bad": variant})
+		_, err := images.Generate(context.Background(), file)
+		suite.Error(err, variant)
+	}
+
+	tests.SetConfig(suite.T(), "filesystems.images.variants", map[string]any{"small": map[string]any{"width": 10}})
+	tests.SetConfig(suite.T(), "filesystems.images.max_pixels", 100)
+	_, err := images.Generate(context.Background(), file)
+	suite.ErrorContains(err, "more than 100 pixels")
+}
+
+func TestImagesSuiteRun(t *testing.T) {
+	suite.Run(t, new(ImagesSuite))
+}
@@ -0,0 +1,177 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/models"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/pkg/lifecycle"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type LifecycleSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (t *LifecycleSuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+}
+
+func freeAddr(suite *LifecycleSuite) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func (suite *LifecycleSuite) TestServeDrainsRequestsThenRunsHooksInReverse() {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	inRequest := make(chan struct{})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(inRequest)
+		time.Sleep(300 * time.Millisecond)
+		return c.SendString("done")
+	})
+
+	var order []string
+	manager := lifecycle.NewManager()
+	manager.OnStart("warmup", func(ctx context.Context) error {
+		order = append(order, "start")
+		return nil
+	})
+	manager.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	manager.OnShutdown("queue", func(ctx context.Context) error {
+		order = append(order, "queue")
+		return nil
+	})
+
+	addr := freeAddr(suite)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- manager.Serve(ctx, app, addr, 5*time.Second)
+	}()
+
+	var resp *http.Response
+	responded := make(chan error, 1)
+	go func() {
+		var err error
+		for i := 0; i < 50; i++ {
+			resp, err = http.Get(fmt.Sprintf("http://%s/slow", addr))
+			if err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		responded <- err
+	}()
+
+	<-inRequest
+	cancel()
+
+	suite.Require().NoError(<-responded)
+	body, _ := io.ReadAll(resp.Body)
+	suite.Equal("done", string(body))
+
+	suite.Require().NoError(<-served)
+	suite.Equal([]string{"start", "queue", "database"}, order)
+}
+
+func (suite *LifecycleSuite) TestShutdownRunsAllHooksAndJoinsErrors() {
+	var ran []string
+	manager := lifecycle.NewManager()
+	manager.OnShutdown("database", func(ctx context.Context) error {
+		ran = append(ran, "database")
+		return nil
+	})
+	manager.OnShutdown("queue", func(ctx context.Context) error {
+		ran = append(ran, "queue")
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := manager.Shutdown(50 * time.Millisecond)
+
+	suite.True(errors.Is(err, context.DeadlineExceeded))
+	suite.Contains(err.Error(), "shutdown hook queue")
+	suite.Equal([]string{"queue", "database"}, ran)
+}
+
+// claim starts job like the workers of github.com/galaplate/core/queue do
+func claim(suite *LifecycleSuite, db *gorm.DB, job *models.Job) {
+	start := time.Now()
+	result := db.Model(job).
+		Where("id = ? AND state = ?", job.ID, models.JobPending).
+		Updates(models.Job{State: models.JobStarted, StartedAt: &start, Attempts: job.Attempts + 1})
+	suite.Require().NoError(result.Error)
+	suite.Require().Equal(int64(1), result.RowsAffected)
+}
+
+func (suite *LifecycleSuite) TestRequeuesOnlyJobsClaimedByThisInstance() {
+	// Another instance has its own connection to the same database
+	other, err := gorm.Open(database.Connect.Dialector, &gorm.Config{})
+	suite.Require().NoError(err)
+	ours, theirs := jobs.NewClaims(), jobs.NewClaims()
+	suite.Require().NoError(database.Connect.Use(ours))
+	suite.Require().NoError(other.Use(theirs))
+	suite.T().Cleanup(func() {
+		if sqlDB, err := other.DB(); err == nil {
+			sqlDB.Close()
+		}
+		database.Connect.Callback().Update().Remove("jobs:claims")
+		delete(database.Connect.Config.Plugins, ours.Name())
+	})
+
+	earlier := time.Now().Add(-time.Hour)
+	newJob := func(kind string) *models.Job {
+		job := &models.Job{Type: kind, Payload: json.RawMessage(`[]`), State: models.JobPending, AvailableAt: earlier, CreatedAt: earlier}
+		suite.Require().NoError(database.Connect.Create(job).Error)
+		return job
+	}
+	interrupted, finished, otherInstance := newJob("interrupted"), newJob("finished"), newJob("other_instance")
+
+	claim(suite, database.Connect, interrupted)
+	claim(suite, database.Connect, finished)
+	suite.Require().NoError(database.Connect.Model(finished).Updates(models.Job{State: models.JobFinished}).Error)
+	// Started after this instance booted, which used to be requeued too
+	claim(suite, other, otherInstance)
+
+	requeued, err := ours.Requeue()
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), requeued)
+
+	states := map[uint]models.JobState{}
+	for _, job := range []*models.Job{interrupted, finished, otherInstance} {
+		var reloaded models.Job
+		suite.Require().NoError(database.Connect.First(&reloaded, job.ID).Error)
+		states[job.ID] = reloaded.State
+		if job == interrupted {
+			suite.Nil(reloaded.StartedAt)
+			suite.Equal(1, reloaded.Attempts)
+		}
+	}
+	suite.Equal(map[uint]models.JobState{
+		interrupted.ID:   models.JobPending,
+		finished.ID:      models.JobFinished,
+		otherInstance.ID: models.JobStarted,
+	}, states)
+}
+
+func TestLifecycleSuiteRun(t *testing.T) {
+	suite.Run(t, new(LifecycleSuite))
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsSuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (t *MetricsSuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+}
+
+func (suite *MetricsSuite) scrape() string {
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	suite.Require().NoError(err)
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(200, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	return string(body)
+}
+
+func (suite *MetricsSuite) TestExposesRequestMetricsByRoutePattern() {
+	t := suite.T()
+
+	req, err := http.NewRequest("GET", "/api/test/99", nil)
+	assert.NoError(t, err)
+	_, err = suite.App.Test(req)
+	suite.NoError(err)
+
+	body := suite.scrape()
+	suite.Contains(body, `http_requests_total{method="GET",route="/api/v1/test/:id",status="200"}`)
+	suite.Contains(body, `http_request_duration_seconds_bucket{method="GET",route="/api/v1/test/:id",status="200"`)
+	suite.Contains(body, "go_goroutines")
+	suite.Contains(body, "go_sql_open_connections")
+	suite.Contains(body, `queue_jobs{state="pending"} 0`)
+	suite.Contains(body, "queue_oldest_pending_job_age_seconds 0")
+}
+
+func (suite *MetricsSuite) TestCountsFailedLogins() {
+	t := suite.T()
+
+	req, err := http.NewRequest("POST", "/api/login", strings.NewReader(`{"email": "nobody@example.com", "password": "password123"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.App.Test(req)
+	suite.NoError(err)
+	suite.Equal(401, resp.StatusCode)
+
+	suite.Contains(suite.scrape(), `auth_login_attempts_total{result="failure"}`)
+}
+
+func TestMetricsSuiteRun(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccessLogSuite struct {
+	tests.TestCase
+}
+
+func (t *AccessLogSuite) SetupTest() {
+	t.TestCase.SetupTest()
+}
+
+func (suite *AccessLogSuite) findEntry(requestID string) map[string]any {
+	file, err := os.Open(fmt.Sprintf("storage/logs/access.%s.log", time.Now().Format("2006-01-02")))
+	suite.Require().NoError(err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]any
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		info, _ := entry["additional_info"].(map[string]any)
+		if info != nil && info["request_id"] == requestID {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (suite *AccessLogSuite) TestWritesEntryWithRoutePattern() {
+	t := suite.T()
+	requestID := fmt.Sprintf("access-log-%d", time.Now().UnixNano())
+
+	req, err := http.NewRequest("GET", "/api/test/42?token=secret&page=2", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", requestID)
+
+	resp, err := suite.App.Test(req)
+	suite.NoError(err)
+	suite.Equal(200, resp.StatusCode)
+
+	entry := suite.findEntry(requestID)
+	suite.Require().NotNil(entry)
+	suite.Equal("GET /api/v1/test/:id 200", entry["message"])
+
+	info := entry["additional_info"].(map[string]any)
+	suite.Equal("/api/v1/test/:id", info["route"])
+	suite.Equal(float64(200), info["status"])
+	suite.Equal("page=2&token=%5BREDACTED%5D", info["query"])
+}
+
+func (suite *AccessLogSuite) TestLogsHandlerErrors() {
+	t := suite.T()
+	requestID := fmt.Sprintf("access-log-%d", time.Now().UnixNano())
+
+	req, err := http.NewRequest("POST", "/api/test", strings.NewReader(`{"name": ""}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", requestID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := suite.App.Test(req)
+	suite.NoError(err)
+	suite.Equal(422, resp.StatusCode)
+
+	entry := suite.findEntry(requestID)
+	suite.Require().NotNil(entry)
+	suite.Equal("WARN", entry["level"])
+}
+
+func TestAccessLogSuiteRun(t *testing.T) {
+	suite.Run(t, new(AccessLogSuite))
+}
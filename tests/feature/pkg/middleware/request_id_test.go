@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RequestIDSuite struct {
+	tests.TestCase
+}
+
+func (t *RequestIDSuite) SetupTest() {
+	t.TestCase.SetupTest()
+}
+
+func (suite *RequestIDSuite) TestGeneratesRequestID() {
+	t := suite.T()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.NoError(t, err)
+
+	resp, err := suite.App.Test(req)
+	suite.NoError(err)
+	suite.Equal(200, resp.StatusCode)
+	suite.Len(resp.Header.Get("X-Request-ID"), 36)
+}
+
+func (suite *RequestIDSuite) TestReusesIncomingRequestID() {
+	t := suite.T()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", "client-generated-id-123")
+
+	resp, err := suite.App.Test(req)
+	suite.NoError(err)
+	suite.Equal("client-generated-id-123", resp.Header.Get("X-Request-ID"))
+}
+
+func (suite *RequestIDSuite) TestReplacesInvalidRequestID() {
+	t := suite.T()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", "not valid <script>")
+
+	resp, err := suite.App.Test(req)
+	suite.NoError(err)
+	suite.NotEqual("not valid <script>", resp.Header.Get("X-Request-ID"))
+	suite.Len(resp.Header.Get("X-Request-ID"), 36)
+}
+
+func TestRequestIDSuiteRun(t *testing.T) {
+	suite.Run(t, new(RequestIDSuite))
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/galaplate/galaplate/pkg/middleware"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type SecurityHeadersSuite struct {
+	tests.TestCase
+}
+
+func (t *SecurityHeadersSuite) SetupTest() {
+	t.TestCase.SetupTest()
+}
+
+var cspNonce = regexp.MustCompile(`'nonce-([A-Za-z0-9_-]+)'`)
+
+func (suite *SecurityHeadersSuite) TestSetsSecurityHeaders() {
+	req, err := http.NewRequest("GET", "/", nil)
+	suite.Require().NoError(err)
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+
+	suite.Equal("DENY", resp.Header.Get("X-Frame-Options"))
+	suite.Equal("strict-origin-when-cross-origin", resp.Header.Get("Referrer-Policy"))
+	suite.Equal("nosniff", resp.Header.Get("X-Content-Type-Options"))
+	suite.Contains(resp.Header.Get("Content-Security-Policy"), "default-src 'self'")
+	suite.Regexp(cspNonce, resp.Header.Get("Content-Security-Policy"))
+	suite.Empty(resp.Header.Get("Strict-Transport-Security"), "HSTS must not be sent over plain HTTP")
+}
+
+func (suite *SecurityHeadersSuite) TestSendsHSTSOverHTTPS() {
+	// Requests sent with App.Test come from 0.0.0.0
+	tests.SetConfig(suite.T(), "http.trusted_proxies", "0.0.0.0")
+	middleware.ResetTrustedProxies()
+	suite.T().Cleanup(middleware.ResetTrustedProxies)
+
+	req, err := http.NewRequest("GET", "/", nil)
+	suite.Require().NoError(err)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal("max-age=31536000; includeSubDomains", resp.Header.Get("Strict-Transport-Security"))
+}
+
+func (suite *SecurityHeadersSuite) TestIgnoresSchemeOfUntrustedPeers() {
+	middleware.ResetTrustedProxies()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	suite.Require().NoError(err)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Empty(resp.Header.Get("Strict-Transport-Security"))
+}
+
+func (suite *SecurityHeadersSuite) TestNonceIsFreshPerRequest() {
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "/", nil)
+		suite.Require().NoError(err)
+		resp, err := suite.App.Test(req)
+		suite.Require().NoError(err)
+		nonces = append(nonces, cspNonce.FindStringSubmatch(resp.Header.Get("Content-Security-Policy"))[1])
+	}
+	suite.NotEqual(nonces[0], nonces[1])
+}
+
+func (suite *SecurityHeadersSuite) TestLogViewerUsesNonceForInlineCode() {
+	req, err := http.NewRequest("GET", "/admin/logs", nil)
+	suite.Require().NoError(err)
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(200, resp.StatusCode)
+
+	nonce := cspNonce.FindStringSubmatch(resp.Header.Get("Content-Security-Policy"))[1]
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+
+	suite.Contains(string(body), `<script nonce="`+nonce+`">`)
+	suite.Contains(string(body), `<style nonce="`+nonce+`">`)
+	suite.NotRegexp(`\son[a-z]+="`, string(body), "inline event handlers are blocked by the CSP")
+}
+
+func (suite *SecurityHeadersSuite) TestCORSAllowsAPIOrigins() {
+	req, err := http.NewRequest("OPTIONS", "/api/test", nil)
+	suite.Require().NoError(err)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(204, resp.StatusCode)
+	suite.Equal("*", resp.Header.Get("Access-Control-Allow-Origin"))
+	suite.Contains(resp.Header.Get("Access-Control-Allow-Methods"), "PATCH")
+}
+
+func (suite *SecurityHeadersSuite) TestCORSGroupOverrideDisablesAdmin() {
+	req, err := http.NewRequest("GET", "/admin/logs", nil)
+	suite.Require().NoError(err)
+	req.Header.Set("Origin", "https://evil.example.com")
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Empty(resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestSecurityHeadersSuiteRun(t *testing.T) {
+	suite.Run(t, new(SecurityHeadersSuite))
+}
@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/galaplate/galaplate/console/commands"
+	"github.com/galaplate/galaplate/pkg/openapi"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenAPISuite struct {
+	tests.TestCase
+}
+
+func (t *OpenAPISuite) SetupTest() {
+	t.TestCase.SetupTest()
+}
+
+func (suite *OpenAPISuite) fetchSpec() map[string]any {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+
+	var spec map[string]any
+	suite.Require().NoError(json.Unmarshal(body, &spec))
+	return spec
+}
+
+func (suite *OpenAPISuite) TestServesDocumentOfRegisteredRoutes() {
+	spec := suite.fetchSpec()
+	suite.Equal("3.1.0", spec["openapi"])
+
+	paths := spec["paths"].(map[string]any)
+	suite.Contains(paths, "/api/v1/register")
+	suite.Contains(paths, "/api/v1/test/{id}")
+	suite.Contains(paths, "/admin/logs/export")
+	suite.NotContains(paths, "/openapi.json")
+	suite.NotContains(paths, "/")
+
+	register := paths["/api/v1/register"].(map[string]any)["post"].(map[string]any)
+	suite.Equal("auth.register", register["operationId"])
+	body := register["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)
+	suite.Equal("#/components/schemas/AuthRegisterRequest", body["schema"].(map[string]any)["$ref"])
+
+	responses := register["responses"].(map[string]any)
+	suite.Contains(responses, "201")
+	suite.Contains(responses, "409")
+	suite.Contains(responses["422"].(map[string]any)["content"], "application/problem+json")
+
+	show := paths["/api/v1/test/{id}"].(map[string]any)["get"].(map[string]any)
+	param := show["parameters"].([]any)[0].(map[string]any)
+	suite.Equal("id", param["name"])
+	suite.Equal("path", param["in"])
+
+	profile := paths["/api/v1/profile"].(map[string]any)["get"].(map[string]any)
+	suite.Equal([]any{map[string]any{"bearerAuth": []any{}}}, profile["security"])
+}
+
+func (suite *OpenAPISuite) TestTranslatesValidateTags() {
+	schemas := suite.fetchSpec()["components"].(map[string]any)["schemas"].(map[string]any)
+
+	register := schemas["AuthRegisterRequest"].(map[string]any)
+	suite.ElementsMatch([]any{"username", "email", "password"}, register["required"])
+
+	properties := register["properties"].(map[string]any)
+	suite.Equal(map[string]any{"type": "string", "minLength": float64(3), "maxLength": float64(50)}, properties["username"])
+	suite.Equal(map[string]any{"type": "string", "format": "email"}, properties["email"])
+
+	user := schemas["User"].(map[string]any)["properties"].(map[string]any)
+	suite.NotContains(user, "password")
+	suite.Equal(map[string]any{"type": []any{"string", "null"}, "format": "date-time"}, user["deleted_at"])
+}
+
+type widgetBase struct {
+	ID uint `json:"id"`
+}
+
+type widgetRequest struct {
+	widgetBase
+	Name  string   `json:"name" validate:"required,alphanum,len=8"`
+	Count int      `json:"count" validate:"gte=1,lt=10"`
+	Kind  string   `json:"kind" validate:"omitempty,oneof=small large"`
+	Size  int      `json:"size" validate:"oneof=1 2 3"`
+	Tags  []string `json:"tags" validate:"max=3,dive,min=2"`
+	Note  *string  `json:"note,omitempty"`
+}
+
+func (suite *OpenAPISuite) TestGenerateForCustomRoutes() {
+	app := fiber.New()
+	app.Put("/widgets/:id<int>", func(c *fiber.Ctx) error { return nil }).Name("widgets.update")
+	app.Get("/widgets", func(c *fiber.Ctx) error { return nil })
+	app.Post("/widgets/:id<int>/image", func(c *fiber.Ctx) error { return nil }).Name("widgets.image")
+
+	openapi.Describe("widgets.update", openapi.Operation{
+		Tags:      []string{"Widgets"},
+		Request:   widgetRequest{},
+		Responses: map[int]any{200: []widgetRequest{}},
+	})
+	openapi.Describe("widgets.image", openapi.Operation{
+		Form: struct {
+			Image *multipart.FileHeader `json:"image" validate:"required"`
+		}{},
+	})
+
+	doc := openapi.Generate(app, openapi.Info{Title: "Widgets", Version: "2.0.0"})
+	suite.Equal([]openapi.Tag{{Name: "Widgets"}}, doc.Tags)
+	suite.Contains(doc.Paths, "/widgets")
+	suite.Contains(*doc.Paths["/widgets"], "get")
+
+	update := (*doc.Paths["/widgets/{id}"])["put"]
+	suite.Require().NotNil(update)
+	suite.Equal("id", update.Parameters[0].Name)
+
+	schema := doc.Components.Schemas["WidgetRequest"]
+	suite.Require().NotNil(schema)
+	suite.Equal([]string{"name"}, schema.Required)
+	suite.Contains(schema.Properties, "id")
+	suite.Contains(schema.Properties, "note")
+
+	name := schema.Properties["name"]
+	suite.Equal("^[a-zA-Z0-9]+$", name.Pattern)
+	suite.Equal(8, *name.MinLength)
+	suite.Equal(8, *name.MaxLength)
+
+	count := schema.Properties["count"]
+	suite.Equal(1.0, *count.Minimum)
+	suite.Equal(10.0, *count.ExclusiveMaximum)
+
+	suite.Equal([]any{"small", "large"}, schema.Properties["kind"].Enum)
+	suite.Equal([]any{1.0, 2.0, 3.0}, schema.Properties["size"].Enum)
+
+	tags := schema.Properties["tags"]
+	suite.Equal(3, *tags.MaxItems)
+	suite.Nil(tags.Items.MinLength)
+
+	image := (*doc.Paths["/widgets/{id}/image"])["post"]
+	suite.Require().NotNil(image)
+	form := image.RequestBody.Content["multipart/form-data"].Schema
+	suite.Equal([]string{"image"}, form.Required)
+	suite.Equal(&openapi.Schema{Type: "string", Format: "binary"}, form.Properties["image"])
+}
+
+func (suite *OpenAPISuite) TestServesDocsUIWithNonce() {
+	req, _ := http.NewRequest("GET", "/docs", nil)
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(200, resp.StatusCode)
+	suite.Contains(resp.Header.Get("Content-Type"), "text/html")
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+
+	nonce := regexp.MustCompile(`'nonce-([^']+)'`).FindStringSubmatch(resp.Header.Get("Content-Security-Policy"))
+	suite.Require().Len(nonce, 2)
+	suite.Contains(string(body), `<script nonce="`+nonce[1]+`">`)
+	suite.Contains(string(body), "/openapi.json")
+}
+
+func (suite *OpenAPISuite) TestGenerateCommandWritesDocument() {
+	output := filepath.Join(suite.T().TempDir(), "api", "openapi.json")
+
+	command := &commands.OpenAPIGenerateCommand{App: suite.App}
+	suite.Require().NoError(command.Execute([]string{"--output=" + output}))
+
+	content, err := os.ReadFile(output)
+	suite.Require().NoError(err)
+
+	var doc openapi.Document
+	suite.Require().NoError(json.Unmarshal(content, &doc))
+	suite.Equal(openapi.Version, doc.OpenAPI)
+	suite.Contains(doc.Paths, "/api/v1/login")
+}
+
+func TestOpenAPISuiteRun(t *testing.T) {
+	suite.Run(t, new(OpenAPISuite))
+}
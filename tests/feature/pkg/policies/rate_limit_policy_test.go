@@ -0,0 +1,92 @@
+package policies
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "github.com/galaplate/galaplate/db/migrations"
+	"github.com/galaplate/galaplate/pkg/policies"
+	"github.com/galaplate/galaplate/pkg/ratelimit"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitPolicySuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (t *RateLimitPolicySuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+}
+
+func (suite *RateLimitPolicySuite) login() *http.Response {
+	body := `{"email": "nobody@example.com", "password": "password123"}`
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest("POST", "/api/login", body), "")
+}
+
+func (suite *RateLimitPolicySuite) TestLoginIsLimitedPerIP() {
+	first := suite.login()
+	suite.Equal(401, first.StatusCode)
+	suite.Equal("5", first.Header.Get("RateLimit-Limit"))
+	suite.Equal("4", first.Header.Get("RateLimit-Remaining"))
+	suite.Equal("5;w=60", first.Header.Get("RateLimit-Policy"))
+	suite.NotEmpty(first.Header.Get("RateLimit-Reset"))
+
+	for i := 0; i < 4; i++ {
+		suite.Equal(401, suite.login().StatusCode)
+	}
+
+	limited := suite.login()
+	suite.Equal(429, limited.StatusCode)
+	suite.Equal("0", limited.Header.Get("RateLimit-Remaining"))
+	suite.NotEmpty(limited.Header.Get("Retry-After"))
+}
+
+func (suite *RateLimitPolicySuite) profile(token string) *http.Response {
+	return tests.Send(suite.T(), suite.App, tests.JSONRequest("GET", "/api/profile", ""), token)
+}
+
+func (suite *RateLimitPolicySuite) TestAuthenticatedRoutesAreLimitedPerUser() {
+	tests.SetConfig(suite.T(), "ratelimit.limiters.api.limit", 2)
+	policies.RegisterRateLimitPolicies()
+	suite.T().Cleanup(policies.RegisterRateLimitPolicies)
+
+	_, alice := tests.RegisterUser(suite.T(), suite.App, "alice")
+	_, bob := tests.RegisterUser(suite.T(), suite.App, "bob")
+
+	first := suite.profile(alice)
+	suite.Equal(200, first.StatusCode)
+	suite.Equal("2", first.Header.Get("RateLimit-Limit"))
+	suite.Equal(200, suite.profile(alice).StatusCode)
+	suite.Equal(429, suite.profile(alice).StatusCode)
+
+	suite.Equal(200, suite.profile(bob).StatusCode, "every user has their own counter")
+}
+
+func (suite *RateLimitPolicySuite) TestDatabaseStoreIsSharedBetweenInstances() {
+	rule := ratelimit.Rule{Name: "shared", Limit: 3, Window: time.Hour, Key: ratelimit.KeyIP}
+	instanceA := ratelimit.New(ratelimit.NewDatabaseStore())
+	instanceB := ratelimit.New(ratelimit.NewDatabaseStore())
+	ctx := context.Background()
+
+	for i, limiter := range []*ratelimit.Limiter{instanceA, instanceB, instanceA} {
+		result, err := limiter.Allow(ctx, rule, "ip:10.0.0.1")
+		suite.Require().NoError(err)
+		suite.True(result.Allowed)
+		suite.Equal(2-i, result.Remaining)
+	}
+
+	result, err := instanceB.Allow(ctx, rule, "ip:10.0.0.1")
+	suite.Require().NoError(err)
+	suite.False(result.Allowed)
+
+	other, err := instanceB.Allow(ctx, rule, "ip:10.0.0.2")
+	suite.Require().NoError(err)
+	suite.True(other.Allowed)
+}
+
+func TestRateLimitPolicySuiteRun(t *testing.T) {
+	suite.Run(t, new(RateLimitPolicySuite))
+}
@@ -0,0 +1,153 @@
+package query
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	_ "github.com/galaplate/galaplate/db/migrations"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/galaplate/galaplate/pkg/query"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type QuerySuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+}
+
+func (t *QuerySuite) SetupTest() {
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+
+	opts := query.Options{
+		Sortable: []string{"id", "username", "status", "created_at"},
+		Filterable: map[string][]string{
+			"status":     {query.OpEq},
+			"username":   nil,
+			"created_at": {query.OpGte, query.OpLt},
+		},
+		DefaultPerPage: 2,
+		MaxPerPage:     3,
+	}
+	t.App.Get("/__query/users", func(c *fiber.Ctx) error {
+		page, err := query.Paginate[models.User](c, database.Connect, opts)
+		if err != nil {
+			return err
+		}
+		return c.JSON(page)
+	}).Name("test.users")
+
+	created := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"dave", "alice", "carol", "bob", "erin"} {
+		user := models.User{
+			Username:  name,
+			Email:     name + "@example.com",
+			Password:  "secret",
+			Status:    i%2 == 0,
+			CreatedAt: created.AddDate(0, 0, i),
+		}
+		t.Require().NoError(database.Connect.Create(&user).Error)
+	}
+}
+
+func (suite *QuerySuite) list(params string) query.Page[models.User] {
+	resp := suite.get(params)
+	suite.Require().Equal(200, resp.StatusCode)
+	return tests.DecodeJSON[query.Page[models.User]](suite.T(), resp)
+}
+
+func (suite *QuerySuite) get(params string) *http.Response {
+	req, err := http.NewRequest("GET", "/__query/users?"+params, nil)
+	suite.Require().NoError(err)
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func usernames(users []models.User) []string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
+
+func (suite *QuerySuite) TestPaginatesByPage() {
+	page := suite.list("sort=username&page=2")
+	suite.Equal([]string{"carol", "dave"}, usernames(page.Data))
+	suite.Equal(query.Meta{Page: 2, PerPage: 2, Total: 5, TotalPages: 3, NextCursor: page.Meta.NextCursor}, page.Meta)
+	suite.NotEmpty(page.Meta.NextCursor)
+
+	suite.Equal("/__query/users?sort=username&page=2", page.Links.Self)
+	suite.Equal("/__query/users?sort=username", page.Links.First)
+	suite.Equal("/__query/users?page=1&sort=username", page.Links.Prev)
+	suite.Equal("/__query/users?page=3&sort=username", page.Links.Next)
+	suite.Equal("/__query/users?page=3&sort=username", page.Links.Last)
+
+	page = suite.list("sort=username&page=3")
+	suite.Equal([]string{"erin"}, usernames(page.Data))
+	suite.Empty(page.Links.Next)
+	suite.Empty(page.Meta.NextCursor)
+
+	suite.Len(suite.list("per_page=50").Data, 3, "per_page is capped")
+}
+
+func (suite *QuerySuite) TestSortsByManyColumns() {
+	page := suite.list("sort=-status,username&per_page=3")
+	suite.Equal([]string{"carol", "dave", "erin"}, usernames(page.Data))
+
+	page = suite.list("sort=-created_at&per_page=3")
+	suite.Equal([]string{"erin", "bob", "carol"}, usernames(page.Data))
+}
+
+func (suite *QuerySuite) TestWalksPagesWithACursor() {
+	page := suite.list("sort=-status,username")
+	names := usernames(page.Data)
+	for range 5 {
+		if page.Links.Next == "" {
+			break
+		}
+		page = suite.list("sort=-status,username&cursor=" + url.QueryEscape(page.Meta.NextCursor))
+		names = append(names, usernames(page.Data)...)
+		suite.Zero(page.Meta.Page, "cursor pages have no number")
+	}
+	suite.Equal([]string{"carol", "dave", "erin", "alice", "bob"}, names)
+
+	suite.Equal(400, suite.get("cursor=not-a-cursor").StatusCode)
+}
+
+func (suite *QuerySuite) TestFilters() {
+	suite.Equal([]string{"dave", "carol"}, usernames(suite.list("filter[status]=true").Data))
+	suite.Equal([]string{"alice", "bob"}, usernames(suite.list("filter[status]=false").Data))
+	suite.Equal([]string{"carol"}, usernames(suite.list("filter[username][like]=aro").Data))
+	suite.Equal([]string{"alice", "erin"}, usernames(suite.list("filter[username][in]=alice,erin&sort=username").Data))
+	suite.Equal([]string{"bob"}, usernames(suite.list("filter[created_at][gte]=2026-01-04&filter[created_at][lt]=2026-01-05").Data))
+
+	page := suite.list("filter[username][ne]=dave&per_page=3")
+	suite.Equal(int64(4), page.Meta.Total)
+	suite.Equal("/__query/users?filter%5Busername%5D%5Bne%5D=dave&page=2&per_page=3", page.Links.Next)
+}
+
+func (suite *QuerySuite) TestRejectsUnknownParameters() {
+	for _, params := range []string{
+		"sort=password",
+		"filter[password]=secret",
+		"filter[status][like]=tr",
+		"filter[status]=maybe",
+		"filter[created_at][gte]=yesterday",
+		"page=0",
+		"per_page=abc",
+	} {
+		resp := suite.get(params)
+		suite.Equal(400, resp.StatusCode, params)
+		suite.NotEmpty(tests.DecodeError(suite.T(), resp).Message, params)
+	}
+}
+
+func TestQuerySuiteRun(t *testing.T) {
+	suite.Run(t, new(QuerySuite))
+}
@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/galaplate/galaplate/console/commands"
+	"github.com/galaplate/galaplate/pkg/routes"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type RoutesSuite struct {
+	tests.TestCase
+}
+
+func (suite *RoutesSuite) byName() map[string]routes.Route {
+	list := map[string]routes.Route{}
+	for _, route := range routes.List(suite.App) {
+		list[route.Name] = route
+	}
+	return list
+}
+
+func (suite *RoutesSuite) run(args ...string) string {
+	var out bytes.Buffer
+	command := &commands.RouteListCommand{App: suite.App, Output: &out}
+	suite.Require().NoError(command.Execute(args))
+	return out.String()
+}
+
+func (suite *RoutesSuite) TestListsHandlersAndMiddleware() {
+	list := suite.byName()
+
+	login := list["auth.login"]
+	suite.Equal("POST", login.Method)
+	suite.Equal("/api/v1/login", login.Path)
+	suite.Equal("v1", login.Version)
+	suite.Equal("controllers.AuthController.Login", login.Handler)
+	suite.True(slices.Contains(login.Middleware, routes.Middleware{Name: "policies.Enforce(rate_limit:login)"}))
+	suite.True(login.HasMiddleware("requestid"), "app-wide middleware is part of the chain")
+	suite.False(login.HasMiddleware("JWT"))
+
+	profile := list["profile.show"]
+	suite.True(profile.HasMiddleware("JWT"))
+	jwt := slices.IndexFunc(profile.Middleware, func(m routes.Middleware) bool {
+		return m.Name == "middleware.JWTService.AuthMiddleware"
+	})
+	suite.Require().NotEqual(-1, jwt)
+	suite.Empty(profile.Middleware[jwt].Prefix, "route middleware has no mount prefix")
+	suite.True(profile.HasMiddleware("rate_limit:api"))
+
+	users := list["admin.users.index"]
+	suite.True(slices.Contains(users.Middleware, routes.Middleware{Name: "policies.Enforce(admin)", Prefix: "/api/v1/admin/users"}))
+
+	suite.Empty(list["health.live"].Version)
+	for _, route := range routes.List(suite.App) {
+		suite.NotEqual("HEAD", route.Method)
+	}
+}
+
+// List reads the names recorded by routes.Router when the middleware and
+// routes are registered
+func (suite *RoutesSuite) TestListsRoutesRegisteredThroughRouter() {
+	app := fiber.New()
+	r := routes.New(app)
+	r.Use(func(c *fiber.Ctx) error { return c.Next() })
+	api := r.Group("/api", routes.Named{Name: "api-key", Handler: func(c *fiber.Ctx) error { return c.Next() }})
+	api.Get("/widgets", routes.Named{Name: "widgets-cache", Handler: func(c *fiber.Ctx) error { return c.Next() }}, func(c *fiber.Ctx) error { return nil }).Name("widgets.index")
+	app.Get("/unlisted", func(c *fiber.Ctx) error { return nil })
+
+	list := routes.List(app)
+	suite.Require().Len(list, 1, "routes registered on fiber directly are not recorded")
+	suite.Equal("widgets.index", list[0].Name)
+	suite.Equal("/api/widgets", list[0].Path)
+	suite.Require().Len(list[0].Middleware, 3)
+	suite.Equal([]string{"/", "/api", ""}, []string{list[0].Middleware[0].Prefix, list[0].Middleware[1].Prefix, list[0].Middleware[2].Prefix})
+	suite.Equal("api-key", list[0].Middleware[1].Name)
+	suite.Equal("widgets-cache", list[0].Middleware[2].Name)
+
+	suite.Panics(func() { r.Get("/bad", "not a handler") })
+
+	suite.Require().NoError(app.Shutdown())
+	suite.Empty(routes.List(app), "the registry is dropped on shutdown")
+}
+
+func (suite *RoutesSuite) TestCommandPrintsTable() {
+	out := suite.run()
+	suite.Contains(out, "METHOD")
+	suite.Contains(out, "/api/v1/profile")
+	suite.Contains(out, "middleware.JWTService.AuthMiddleware")
+	suite.Contains(out, "policies.Enforce(rate_limit:login)")
+	suite.Contains(out, "App-wide middleware: ")
+}
+
+func (suite *RoutesSuite) TestCommandFiltersAsJSON() {
+	var list []routes.Route
+	suite.Require().NoError(json.Unmarshal([]byte(suite.run("--middleware=jwt", "--prefix=/api/v1/profile", "--json")), &list))
+	suite.Require().Len(list, 4)
+	suite.Equal("profile.show", list[0].Name)
+	suite.Equal("profile.update", list[1].Name)
+	suite.Equal("profile.avatar", list[2].Name)
+	suite.Equal("profile.password", list[3].Name)
+
+	list = nil
+	suite.Require().NoError(json.Unmarshal([]byte(suite.run("--middleware=jwt", "--prefix=/api/v1/profile", "--method=patch", "--json")), &list))
+	suite.Require().Len(list, 1)
+	suite.Equal("profile.update", list[0].Name)
+
+	list = nil
+	suite.Require().NoError(json.Unmarshal([]byte(suite.run("--prefix=/api/v1", "--method=post", "--json")), &list))
+	suite.NotEmpty(list)
+	for _, route := range list {
+		suite.Equal("POST", route.Method)
+		suite.Contains(route.Path, "/api/v1/")
+	}
+}
+
+func (suite *RoutesSuite) TestCommandRejectsUnknownArguments() {
+	command := &commands.RouteListCommand{App: suite.App, Output: &bytes.Buffer{}}
+	suite.Error(command.Execute([]string{"--verbose"}))
+}
+
+func TestRoutesSuiteRun(t *testing.T) {
+	suite.Run(t, new(RoutesSuite))
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/galaplate/galaplate/console/commands"
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/galaplate/galaplate/tests"
+)
+
+func (suite *StorageSuite) read(disk storage.Disk, path string) string {
+	body, err := disk.Get(context.Background(), path)
+	suite.Require().NoError(err)
+	defer body.Close()
+	content, _ := io.ReadAll(body)
+	return string(content)
+}
+
+func (suite *StorageSuite) TestPutsAndGetsFiles() {
+	source := filepath.Join(suite.T().TempDir(), "report.pdf")
+	suite.Require().NoError(os.WriteFile(source, []byte("%PDF-1.4\n"), 0o644))
+
+	put := &commands.StoragePutCommand{}
+	suite.Require().NoError(put.Execute([]string{source, "reports/report.pdf", "--disk=s3"}))
+	object, ok := suite.s3.Object("uploads", "app/reports/report.pdf")
+	suite.Require().True(ok)
+	suite.Equal("application/pdf", object.ContentType)
+	suite.Error(put.Execute([]string{source}))
+
+	var out bytes.Buffer
+	get := &commands.StorageGetCommand{Output: &out}
+	suite.Require().NoError(get.Execute([]string{"reports/report.pdf", "--disk=s3"}))
+	suite.Equal("%PDF-1.4\n", out.String())
+
+	target := filepath.Join(suite.T().TempDir(), "copy.pdf")
+	suite.Require().NoError(get.Execute([]string{"reports/report.pdf", target, "--disk=s3"}))
+	content, _ := os.ReadFile(target)
+	suite.Equal("%PDF-1.4\n", string(content))
+	suite.ErrorContains(get.Execute([]string{"reports/report.pdf", target, "--disk=s3"}), "--force")
+	suite.NoError(get.Execute([]string{"reports/report.pdf", target, "--disk=s3", "--force"}))
+	suite.ErrorContains(get.Execute([]string{"missing.pdf", "--disk=s3"}), "no file")
+
+	del := &commands.StorageDeleteCommand{}
+	suite.Require().NoError(del.Execute([]string{"reports/report.pdf", "missing.pdf", "--disk=s3"}))
+	suite.Empty(suite.s3.Keys())
+	suite.Error(del.Execute(nil))
+}
+
+func (suite *StorageSuite) TestCopiesBetweenDisks() {
+	ctx := context.Background()
+	local, s3 := suite.open("local"), suite.open("s3")
+	suite.Require().NoError(local.Put(ctx, "files/a.txt", bytes.NewReader([]byte("a")), "text/plain"))
+	suite.Require().NoError(local.Put(ctx, "files/b.pdf", bytes.NewReader([]byte("%PDF-1.4\n")), "application/pdf"))
+	suite.Require().NoError(local.Put(ctx, "avatars/c.txt", bytes.NewReader([]byte("c")), "text/plain"))
+	suite.Require().NoError(s3.Put(ctx, "files/a.txt", bytes.NewReader([]byte("already there")), "text/plain"))
+
+	var out bytes.Buffer
+	copyCommand := &commands.StorageCopyCommand{Output: &out}
+	suite.Require().NoError(copyCommand.Execute([]string{"--from=local", "--to=s3", "--dry-run"}))
+	suite.Contains(out.String(), "[1/3] avatars/c.txt (1 B) would be copied")
+	suite.Contains(out.String(), "[2/3] files/a.txt (1 B) skipped, already on s3")
+	suite.Len(suite.s3.Keys(), 1, "a dry run copies nothing")
+
+	out.Reset()
+	suite.Require().NoError(copyCommand.Execute([]string{"files", "--from=local", "--to=s3"}))
+	suite.Contains(out.String(), "[2/2] files/b.pdf (9 B) copied")
+	suite.Equal("already there", suite.read(s3, "files/a.txt"))
+	object, ok := suite.s3.Object("uploads", "app/files/b.pdf")
+	suite.Require().True(ok)
+	suite.Equal("application/pdf", object.ContentType)
+	exists, _ := s3.Exists(ctx, "avatars/c.txt")
+	suite.False(exists, "only the given directory is copied")
+
+	suite.Require().NoError(copyCommand.Execute([]string{"--from=local", "--to=s3", "--overwrite"}))
+	suite.Equal("a", suite.read(s3, "files/a.txt"))
+	suite.Equal("c", suite.read(s3, "avatars/c.txt"))
+
+	suite.Error(copyCommand.Execute([]string{"--from=local"}))
+	suite.Error(copyCommand.Execute([]string{"--from=local", "--to=local"}))
+	suite.Error(copyCommand.Execute([]string{"--from=missing", "--to=s3"}))
+}
+
+func (suite *StorageSuite) TestListsAndSumsFiles() {
+	ctx := context.Background()
+	local := suite.open("local")
+	suite.Require().NoError(local.Put(ctx, "files/a.txt", bytes.NewReader(make([]byte, 2048)), "text/plain"))
+	suite.Require().NoError(local.Put(ctx, "files/b.txt", bytes.NewReader([]byte("b")), "text/plain"))
+	suite.Require().NoError(local.Put(ctx, "root.txt", bytes.NewReader([]byte("r")), "text/plain"))
+
+	var out bytes.Buffer
+	list := &commands.StorageListCommand{Output: &out}
+	suite.Require().NoError(list.Execute([]string{"files", "--disk=local"}))
+	suite.Contains(out.String(), "files/a.txt")
+	suite.Contains(out.String(), "2.0 KiB")
+	suite.NotContains(out.String(), "root.txt")
+	suite.Contains(out.String(), "2 files, 2.0 KiB")
+
+	out.Reset()
+	usage := &commands.StorageUsageCommand{Output: &out}
+	suite.Require().NoError(usage.Execute([]string{"--disk=local"}))
+	suite.Regexp(`\.\s+1\s+1 B`, out.String())
+	suite.Regexp(`files\s+2\s+2\.0 KiB`, out.String())
+	suite.Regexp(`TOTAL\s+3\s+2\.0 KiB`, out.String())
+
+	out.Reset()
+	suite.Require().NoError(usage.Execute(nil))
+	suite.Regexp(`local\s+3\s+2\.0 KiB`, out.String())
+	suite.Regexp(`s3\s+0\s+0 B`, out.String())
+	suite.Regexp(`gcs\s+0\s+0 B`, out.String())
+	suite.Regexp(`google_drive\s+0\s+0 B`, out.String())
+}
+
+// The commands work on the disks of every driver of pkg/storage and reject
+// the other drivers by name
+func (suite *StorageSuite) TestCommandsCoverGoogleDisks() {
+	ctx := context.Background()
+	local := suite.open("local")
+	suite.Require().NoError(local.Put(ctx, "files/a.txt", bytes.NewReader([]byte("a")), "text/plain"))
+	suite.Require().NoError(local.Put(ctx, "files/b.pdf", bytes.NewReader([]byte("%PDF-1.4\n")), "application/pdf"))
+
+	var out bytes.Buffer
+	copyCommand := &commands.StorageCopyCommand{Output: &out}
+	suite.Require().NoError(copyCommand.Execute([]string{"--from=local", "--to=gcs"}))
+	suite.Require().NoError(copyCommand.Execute([]string{"--from=gcs", "--to=google_drive"}))
+	suite.Contains(out.String(), "[2/2] files/b.pdf (9 B) copied")
+	suite.ElementsMatch([]string{"uploads/app/files/a.txt", "uploads/app/files/b.pdf"}, suite.gcs.Names())
+	suite.Len(suite.drive.Files(), 2)
+
+	out.Reset()
+	list := &commands.StorageListCommand{Output: &out}
+	suite.Require().NoError(list.Execute([]string{"files", "--disk=google_drive"}))
+	suite.Contains(out.String(), "files/b.pdf")
+	suite.Contains(out.String(), "2 files, 10 B")
+
+	out.Reset()
+	get := &commands.StorageGetCommand{Output: &out}
+	suite.Require().NoError(get.Execute([]string{"files/b.pdf", "--disk=google_drive"}))
+	suite.Equal("%PDF-1.4\n", out.String())
+
+	del := &commands.StorageDeleteCommand{}
+	suite.Require().NoError(del.Execute([]string{"files/a.txt", "--disk=gcs"}))
+	suite.Require().NoError(del.Execute([]string{"files/a.txt", "--disk=google_drive"}))
+	suite.Equal([]string{"uploads/app/files/b.pdf"}, suite.gcs.Names())
+	suite.Len(suite.drive.Files(), 1)
+
+	tests.SetConfig(suite.T(), "filesystems.disks.ftp.driver", "ftp")
+	out.Reset()
+	usage := &commands.StorageUsageCommand{Output: &out}
+	suite.Require().NoError(usage.Execute(nil))
+	suite.Regexp(`gcs\s+1\s+9 B`, out.String())
+	suite.Regexp(`google_drive\s+1\s+9 B`, out.String())
+	suite.Regexp(`ftp\s+-\s+storage: driver "ftp" of disk "ftp" is not supported`, out.String())
+	suite.ErrorContains(list.Execute([]string{"--disk=ftp"}), `driver "ftp" of disk "ftp" is not supported`)
+}
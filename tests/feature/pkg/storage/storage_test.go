@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/galaplate/galaplate/pkg/storage"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+)
+
+type StorageSuite struct {
+	tests.TestCase
+	s3    *tests.S3Stub
+	gcs   *tests.GCSStub
+	drive *tests.DriveStub
+}
+
+func (t *StorageSuite) SetupTest() {
+	t.TestCase.SetupTest()
+
+	t.s3 = tests.NewS3Stub()
+	t.T().Cleanup(t.s3.Close)
+
+	tests.SetConfig(t.T(), "filesystems.disks.local.path", t.T().TempDir())
+	tests.SetConfig(t.T(), "filesystems.disks.s3.bucket", "uploads")
+	tests.SetConfig(t.T(), "filesystems.disks.s3.key", "test-key")
+	tests.SetConfig(t.T(), "filesystems.disks.s3.secret", "test-secret")
+	tests.SetConfig(t.T(), "filesystems.disks.s3.endpoint", t.s3.URL)
+	tests.SetConfig(t.T(), "filesystems.disks.s3.use_path_style_endpoint", true)
+	tests.SetConfig(t.T(), "filesystems.disks.s3.path_prefix", "app")
+
+	t.gcs = tests.NewGCSStub()
+	t.T().Cleanup(t.gcs.Close)
+	tests.SetConfig(t.T(), "filesystems.disks.gcs.bucket", "uploads")
+	tests.SetConfig(t.T(), "filesystems.disks.gcs.key_file", "")
+	tests.SetConfig(t.T(), "filesystems.disks.gcs.endpoint", t.gcs.URL)
+	tests.SetConfig(t.T(), "filesystems.disks.gcs.path_prefix", "app")
+
+	t.drive = tests.NewDriveStub()
+	t.T().Cleanup(t.drive.Close)
+	tests.SetConfig(t.T(), "filesystems.disks.google_drive.service_account_file", "")
+	tests.SetConfig(t.T(), "filesystems.disks.google_drive.folder_id", "folder-1")
+	tests.SetConfig(t.T(), "filesystems.disks.google_drive.endpoint", t.drive.URL)
+	storage.Reset()
+	t.T().Cleanup(storage.Reset)
+}
+
+func (suite *StorageSuite) open(name string) storage.Disk {
+	disk, err := storage.Open(name)
+	suite.Require().NoError(err)
+	return disk
+}
+
+// roundTrip runs the operations every disk must support
+func (suite *StorageSuite) roundTrip(disk storage.Disk) {
+	ctx := context.Background()
+
+	suite.Require().NoError(disk.Put(ctx, "docs/a.txt", bytes.NewReader([]byte("hello")), "text/plain"))
+	exists, err := disk.Exists(ctx, "docs/a.txt")
+	suite.Require().NoError(err)
+	suite.True(exists)
+
+	body, err := disk.Get(ctx, "docs/a.txt")
+	suite.Require().NoError(err)
+	content, _ := io.ReadAll(body)
+	body.Close()
+	suite.Equal("hello", string(content))
+
+	suite.Require().NoError(disk.Put(ctx, "docs/a.txt", bytes.NewReader([]byte("replaced")), "text/plain"))
+	body, err = disk.Get(ctx, "docs/a.txt")
+	suite.Require().NoError(err)
+	content, _ = io.ReadAll(body)
+	body.Close()
+	suite.Equal("replaced", string(content))
+
+	suite.Require().NoError(disk.Delete(ctx, "docs/a.txt"))
+	suite.NoError(disk.Delete(ctx, "docs/a.txt"), "deleting a missing file is not an error")
+	exists, err = disk.Exists(ctx, "docs/a.txt")
+	suite.Require().NoError(err)
+	suite.False(exists)
+
+	_, err = disk.Get(ctx, "docs/a.txt")
+	suite.ErrorIs(err, storage.ErrNotFound)
+}
+
+func (suite *StorageSuite) TestLocalDisk() {
+	disk := suite.open("local")
+	suite.roundTrip(disk)
+
+	err := disk.Put(context.Background(), "../escaped.txt", bytes.NewReader(nil), "text/plain")
+	suite.Error(err)
+}
+
+func (suite *StorageSuite) TestS3Disk() {
+	disk := suite.open("s3")
+	suite.roundTrip(disk)
+
+	suite.Require().NoError(disk.Put(context.Background(), "b.pdf", bytes.NewReader([]byte("%PDF-")), "application/pdf"))
+	object, ok := suite.s3.Object("uploads", "app/b.pdf")
+	suite.Require().True(ok)
+	suite.Equal("application/pdf", object.ContentType)
+}
+
+func (suite *StorageSuite) TestGCSDisk() {
+	disk := suite.open("gcs")
+	suite.roundTrip(disk)
+
+	suite.Require().NoError(disk.Put(context.Background(), "b.pdf", bytes.NewReader([]byte("%PDF-")), "application/pdf"))
+	object, ok := suite.gcs.Object("uploads", "app/b.pdf")
+	suite.Require().True(ok)
+	suite.Equal("application/pdf", object.ContentType)
+}
+
+func (suite *StorageSuite) TestGoogleDriveDisk() {
+	disk := suite.open("google_drive")
+	suite.roundTrip(disk)
+
+	ctx := context.Background()
+	suite.Require().NoError(disk.Put(ctx, "docs/it's.pdf", bytes.NewReader([]byte("%PDF-")), "application/pdf"))
+	suite.Require().NoError(disk.Put(ctx, "docs/it's.pdf", bytes.NewReader([]byte("%PDF-1.4")), "application/pdf"))
+	files := suite.drive.Files()
+	suite.Require().Len(files, 1, "a file is replaced in place")
+	suite.Equal("docs/it's.pdf", files[0].Name)
+	suite.Equal("folder-1", files[0].Parent)
+	suite.Equal("application/pdf", files[0].MimeType)
+	suite.Equal("%PDF-1.4", suite.read(disk, "docs/it's.pdf"))
+}
+
+// listing checks the List of disk, which must be empty at first
+func (suite *StorageSuite) listing(disk storage.Disk) {
+	ctx := context.Background()
+	lister, ok := disk.(storage.Lister)
+	suite.Require().True(ok)
+
+	entries, err := lister.List(ctx, "")
+	suite.Require().NoError(err)
+	suite.Empty(entries)
+
+	for _, path := range []string{"files/b.txt", "files/a/c.txt", "files.txt", "other/d.txt"} {
+		suite.Require().NoError(disk.Put(ctx, path, bytes.NewReader([]byte(path)), "text/plain"))
+	}
+
+	entries, err = lister.List(ctx, "")
+	suite.Require().NoError(err)
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+		suite.Equal(int64(len(entry.Path)), entry.Size)
+		suite.False(entry.LastModified.IsZero())
+	}
+	suite.Equal([]string{"files.txt", "files/a/c.txt", "files/b.txt", "other/d.txt"}, paths)
+
+	// A prefix is a directory, not a string prefix
+	entries, err = lister.List(ctx, "files/")
+	suite.Require().NoError(err)
+	suite.Len(entries, 2)
+	entries, err = lister.List(ctx, "missing")
+	suite.Require().NoError(err)
+	suite.Empty(entries)
+}
+
+func (suite *StorageSuite) TestListsFiles() {
+	suite.listing(suite.open("local"))
+	suite.listing(suite.open("s3"))
+	suite.listing(suite.open("gcs"))
+	suite.listing(suite.open("google_drive"))
+
+	// Paths are relative to the path prefix of the s3 disk
+	suite.Contains(suite.s3.Keys(), "uploads/app/other/d.txt")
+}
+
+func (suite *StorageSuite) TestOpensConfiguredDisks() {
+	suite.Same(suite.open(""), suite.open(storage.DefaultDisk()))
+
+	_, err := storage.Open("missing")
+	suite.ErrorContains(err, "not configured")
+
+	tests.SetConfig(suite.T(), "filesystems.disks.ftp.driver", "ftp")
+	_, err = storage.Open("ftp")
+	suite.ErrorContains(err, "not supported")
+}
+
+func TestStorageSuiteRun(t *testing.T) {
+	suite.Run(t, new(StorageSuite))
+}
@@ -0,0 +1,130 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/galaplate/core/database"
+	"github.com/galaplate/core/models"
+	"github.com/galaplate/galaplate/pkg/jobs"
+	"github.com/galaplate/galaplate/tests"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type testJob struct{}
+
+func (j *testJob) Type() string                         { return "telemetry_test_job" }
+func (j *testJob) Handle(payload json.RawMessage) error { return nil }
+func (j *testJob) MaxAttempts() int                     { return 1 }
+func (j *testJob) RetryAfter() time.Duration            { return 0 }
+
+type TelemetrySuite struct {
+	tests.RefreshDatabaseBeforeEachTest
+	exporter *tracetest.InMemoryExporter
+}
+
+func (t *TelemetrySuite) SetupTest() {
+	t.exporter = tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(t.exporter)))
+	t.RefreshDatabaseBeforeEachTest.SetupTest()
+}
+
+func (suite *TelemetrySuite) spanNamed(name string) (tracetest.SpanStub, bool) {
+	for _, span := range suite.exporter.GetSpans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func (suite *TelemetrySuite) TestContinuesIncomingTraceWithQuerySpans() {
+	req, err := http.NewRequest("POST", "/api/login", strings.NewReader(`{"email": "nobody@example.com", "password": "password123"}`))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", traceparent)
+
+	resp, err := suite.App.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(401, resp.StatusCode)
+
+	server, ok := suite.spanNamed("POST /api/v1/login")
+	suite.Require().True(ok, "expected a server span named after the route")
+	suite.Equal(trace.SpanKindServer, server.SpanKind)
+	suite.Equal("4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	suite.Equal("00f067aa0ba902b7", server.Parent.SpanID().String())
+
+	query, ok := suite.spanNamed("gorm.query")
+	suite.Require().True(ok, "expected a span for the user lookup")
+	suite.Equal(server.SpanContext.SpanID(), query.Parent.SpanID())
+
+	var statement string
+	for _, attr := range query.Attributes {
+		if attr.Key == "db.query.text" {
+			statement = attr.Value.AsString()
+		}
+	}
+	suite.Contains(statement, "users")
+}
+
+func (suite *TelemetrySuite) TestPropagatesTraceIntoJobs() {
+	ctx, span := otel.Tracer("test").Start(context.Background(), "dispatch")
+	suite.Require().NoError(jobs.Dispatch(ctx, &testJob{}, "hello"))
+	span.End()
+
+	var job models.Job
+	suite.Require().NoError(database.Connect.Order("id desc").First(&job).Error)
+
+	jobCtx, payload, err := jobs.Decode(job.Payload)
+	suite.Require().NoError(err)
+	suite.Contains(payload.Meta, "traceparent")
+	suite.Equal(span.SpanContext().TraceID(), trace.SpanContextFromContext(jobCtx).TraceID())
+}
+
+func (suite *TelemetrySuite) TestRunsJobsInConsumerSpans() {
+	ctx, span := otel.Tracer("test").Start(context.Background(), "dispatch")
+	suite.Require().NoError(jobs.Dispatch(ctx, &testJob{}, "hello"))
+	span.End()
+
+	var job models.Job
+	suite.Require().NoError(database.Connect.Order("id desc").First(&job).Error)
+
+	failure := errors.New("boom")
+	err := jobs.Handle("telemetry_test_job", job.Payload, func(ctx context.Context, payload jobs.Payload) error {
+		database.Connect.WithContext(ctx).First(&models.Job{}, job.ID)
+		return failure
+	})
+	suite.ErrorIs(err, failure)
+
+	consumer, ok := suite.spanNamed("job.telemetry_test_job")
+	suite.Require().True(ok, "expected a span around the job")
+	suite.Equal(trace.SpanKindConsumer, consumer.SpanKind)
+	suite.Equal(span.SpanContext().SpanID(), consumer.Parent.SpanID())
+	suite.Equal(codes.Error, consumer.Status.Code)
+	suite.Require().Len(consumer.Events, 1)
+	suite.Equal("exception", consumer.Events[0].Name)
+
+	var query tracetest.SpanStub
+	for _, stub := range suite.exporter.GetSpans() {
+		if stub.Name == "gorm.query" {
+			query = stub
+		}
+	}
+	suite.Equal(consumer.SpanContext.SpanID(), query.Parent.SpanID())
+}
+
+func TestTelemetrySuiteRun(t *testing.T) {
+	suite.Run(t, new(TelemetrySuite))
+}
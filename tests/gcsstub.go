@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GCSStub is an in-memory Google Cloud Storage server for storage tests. It
+// serves the object operations of the JSON API used by storage.GCSDisk,
+// with multipart uploads only, and does not check credentials:
+//
+//	stub := tests.NewGCSStub()
+//	defer stub.Close()
+//	config.GetGlobal().Set("filesystems.disks.gcs.endpoint", stub.URL)
+type GCSStub struct {
+	*httptest.Server
+	mu      sync.Mutex
+	objects map[string]GCSObject
+}
+
+type GCSObject struct {
+	Body        []byte
+	ContentType string
+	Updated     time.Time
+}
+
+func NewGCSStub() *GCSStub {
+	stub := &GCSStub{objects: map[string]GCSObject{}}
+	stub.Server = httptest.NewServer(http.HandlerFunc(stub.serve))
+	return stub
+}
+
+// Object returns the object stored under bucket/name
+func (s *GCSStub) Object(bucket, name string) (GCSObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[bucket+"/"+name]
+	return object, ok
+}
+
+// Names lists the bucket/name of every stored object
+func (s *GCSStub) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *GCSStub) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/upload/storage/v1/b/"); ok && r.Method == http.MethodPost {
+		bucket := strings.TrimSuffix(rest, "/o")
+		var metadata struct {
+			Name        string `json:"name"`
+			ContentType string `json:"contentType"`
+		}
+		body, contentType, err := readMultipartUpload(r, &metadata)
+		if err != nil {
+			googleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if metadata.ContentType == "" {
+			metadata.ContentType = contentType
+		}
+		s.objects[bucket+"/"+metadata.Name] = GCSObject{Body: body, ContentType: metadata.ContentType, Updated: time.Now().UTC()}
+		json.NewEncoder(w).Encode(map[string]string{"bucket": bucket, "name": metadata.Name})
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/storage/v1/b/")
+	if !ok {
+		googleError(w, http.StatusNotImplemented, "operation not supported")
+		return
+	}
+	bucket, name, isObject := strings.Cut(rest, "/o/")
+	if !isObject {
+		if r.Method != http.MethodGet || !strings.HasSuffix(rest, "/o") {
+			googleError(w, http.StatusNotImplemented, "bucket operations are not supported")
+			return
+		}
+		s.list(w, strings.TrimSuffix(rest, "/o"), r.URL.Query().Get("prefix"))
+		return
+	}
+
+	object, exists := s.objects[bucket+"/"+name]
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			googleError(w, http.StatusNotFound, "No such object: "+bucket+"/"+name)
+			return
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			w.Header().Set("Content-Type", object.ContentType)
+			w.Header().Set("Content-Length", strconv.Itoa(len(object.Body)))
+			w.Write(object.Body)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"bucket": bucket, "name": name})
+	case http.MethodDelete:
+		if !exists {
+			googleError(w, http.StatusNotFound, "No such object: "+bucket+"/"+name)
+			return
+		}
+		delete(s.objects, bucket+"/"+name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		googleError(w, http.StatusMethodNotAllowed, "method not supported")
+	}
+}
+
+// list answers objects.list with every matching object in a single page
+func (s *GCSStub) list(w http.ResponseWriter, bucket, prefix string) {
+	type item struct {
+		Name    string `json:"name"`
+		Size    string `json:"size"`
+		Updated string `json:"updated"`
+	}
+	items := []item{}
+	for key, object := range s.objects {
+		name, ok := strings.CutPrefix(key, bucket+"/")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		items = append(items, item{
+			Name:    name,
+			Size:    strconv.Itoa(len(object.Body)),
+			Updated: object.Updated.Format(time.RFC3339),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	json.NewEncoder(w).Encode(map[string]any{"kind": "storage#objects", "items": items})
+}
+
+// readMultipartUpload decodes the JSON metadata of a multipart upload of the
+// Google APIs into metadata and returns the media with its content type
+func readMultipartUpload(r *http.Request, metadata any) ([]byte, string, error) {
+	if r.URL.Query().Get("uploadType") != "multipart" {
+		return nil, "", fmt.Errorf("upload type %q is not supported", r.URL.Query().Get("uploadType"))
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	part, err := reader.NextPart()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := json.NewDecoder(part).Decode(metadata); err != nil {
+		return nil, "", err
+	}
+	part, err = reader.NextPart()
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := io.ReadAll(part)
+	return body, part.Header.Get("Content-Type"), err
+}
+
+// googleError writes an error the way the Google APIs do
+func googleError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "message": message},
+	})
+}
@@ -1,6 +1,11 @@
 package tests
 
-import coretesting "github.com/galaplate/core/testing"
+import (
+	"testing"
+
+	"github.com/galaplate/core/config"
+	coretesting "github.com/galaplate/core/testing"
+)
 
 func NewHTTPTestHelper(tc *TestCase) *coretesting.HTTPTestHelper {
 	return coretesting.NewHTTPTestHelper(&tc.TestCase)
@@ -13,3 +18,14 @@ func NewAssertHelper(tc *TestCase) *coretesting.AssertHelper {
 func NewDatabaseHelper(tc *TestCase) *coretesting.DatabaseHelper {
 	return coretesting.NewDatabaseHelper(&tc.TestCase)
 }
+
+// SetConfig overrides the config value at key for the running test. The
+// config is loaded once per process, so it is changed in place and restored
+// when the test ends.
+func SetConfig(t testing.TB, key string, value any) {
+	previous := config.Config(key)
+	config.GetGlobal().Set(key, value)
+	t.Cleanup(func() {
+		config.GetGlobal().Set(key, previous)
+	})
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/galaplate/galaplate/pkg/controllers"
+	"github.com/galaplate/galaplate/pkg/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// Password is the password of the users created with RegisterUser
+const Password = "password123"
+
+// JSONRequest builds a request sending body as JSON
+func JSONRequest(method, path, body string) *http.Request {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// Send sends req to app, authenticated with token, e.g. "Bearer <jwt>",
+// unless it is empty
+//
+//	resp := tests.Send(suite.T(), suite.App, tests.JSONRequest("GET", "/api/profile", ""), token)
+func Send(t testing.TB, app *fiber.App, req *http.Request, token string) *http.Response {
+	t.Helper()
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+// RegisterUser registers name through the API, with the email
+// <name>@example.com and Password, and returns the user with the
+// Authorization header of its token
+//
+//	user, token := tests.RegisterUser(suite.T(), suite.App, "alice")
+func RegisterUser(t testing.TB, app *fiber.App, name string) (*models.User, string) {
+	t.Helper()
+	body := fmt.Sprintf(`{"username": %q, "email": "%s@example.com", "password": %q}`, name, name, Password)
+	resp := Send(t, app, JSONRequest("POST", "/api/register", body), "")
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	auth := DecodeEnvelope[controllers.AuthResponse](t, resp).Data
+	return auth.User, "Bearer " + auth.Token
+}
+
+// LoginUser logs in a user created with RegisterUser and returns the
+// Authorization header of the new token
+func LoginUser(t testing.TB, app *fiber.App, name string) string {
+	t.Helper()
+	body := fmt.Sprintf(`{"email": "%s@example.com", "password": %q}`, name, Password)
+	resp := Send(t, app, JSONRequest("POST", "/api/login", body), "")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	return "Bearer " + DecodeEnvelope[controllers.AuthResponse](t, resp).Data.Token
+}
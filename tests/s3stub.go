@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Stub is an in-memory S3 compatible server for storage tests. It serves
+// the object operations and the ListObjectsV2 bucket operation used by
+// storage.S3Disk with path style URLs and does not check signatures:
+//
+//	stub := tests.NewS3Stub()
+//	defer stub.Close()
+//	config.GetGlobal().Set("filesystems.disks.s3.endpoint", stub.URL)
+type S3Stub struct {
+	*httptest.Server
+	mu      sync.Mutex
+	objects map[string]S3Object
+}
+
+type S3Object struct {
+	Body         []byte
+	ContentType  string
+	LastModified time.Time
+}
+
+func NewS3Stub() *S3Stub {
+	stub := &S3Stub{objects: map[string]S3Object{}}
+	stub.Server = httptest.NewServer(http.HandlerFunc(stub.serve))
+	return stub
+}
+
+// Object returns the object stored under bucket/key
+func (s *S3Stub) Object(bucket, key string) (S3Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[bucket+"/"+key]
+	return object, ok
+}
+
+// Keys lists the bucket/key of every stored object
+func (s *S3Stub) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *S3Stub) serve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.Contains(name, "/") {
+		if r.Method != http.MethodGet || r.URL.Query().Get("list-type") != "2" {
+			http.Error(w, "bucket operations are not supported", http.StatusNotImplemented)
+			return
+		}
+		s.list(w, name, r.URL.Query().Get("prefix"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[name] = S3Object{Body: body, ContentType: r.Header.Get("Content-Type"), LastModified: time.Now().UTC()}
+		w.Header().Set("ETag", `"stub"`)
+	case http.MethodGet, http.MethodHead:
+		object, ok := s.objects[name]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", object.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.Body)))
+		if r.Method == http.MethodGet {
+			w.Write(object.Body)
+		}
+	case http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not supported", http.StatusMethodNotAllowed)
+	}
+}
+
+// list answers ListObjectsV2 with every matching key in a single page
+func (s *S3Stub) list(w http.ResponseWriter, bucket, prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type content struct {
+		Key          string
+		Size         int
+		LastModified string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Name: bucket, Prefix: prefix}
+
+	for name, object := range s.objects {
+		key, ok := strings.CutPrefix(name, bucket+"/")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			Size:         len(object.Body),
+			LastModified: object.LastModified.Format(time.RFC3339),
+		})
+	}
+	sort.Slice(result.Contents, func(i, j int) bool {
+		return result.Contents[i].Key < result.Contents[j].Key
+	})
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(result)
+}
@@ -1,35 +1,81 @@
 package tests
 
 import (
+	"testing"
+
+	"github.com/galaplate/core/bootstrap"
 	coretesting "github.com/galaplate/core/testing"
+	"github.com/galaplate/galaplate/pkg/apperror"
+	"github.com/galaplate/galaplate/pkg/uploads"
 	"github.com/galaplate/galaplate/router"
+	"github.com/gofiber/fiber/v2"
 )
 
 type TestCase struct {
 	coretesting.TestCase
+	Contract Contract
 }
 
 func (tc *TestCase) SetupSuite() {
 	tc.Config = coretesting.DefaultTestConfig()
-	tc.Config.SetupRoutes = router.SetupRouter
+	tc.Config.SetupRoutes = setupRoutes(&tc.Contract)
+	tc.Config.FiberConfig = fiberConfig()
+}
+
+func (tc *TestCase) SetupTest() {
+	tc.Contract.reset(func() testing.TB { return tc.T() })
+	tc.TestCase.SetupTest()
 }
 
 type WithRefreshDatabase struct {
 	coretesting.WithRefreshDatabase
+	Contract Contract
 }
 
 func (w *WithRefreshDatabase) SetupSuite() {
 	w.Config = coretesting.DefaultTestConfig()
-	w.Config.SetupRoutes = router.SetupRouter
+	w.Config.SetupRoutes = setupRoutes(&w.Contract)
+	w.Config.FiberConfig = fiberConfig()
 	w.Config.RefreshDatabase = true
 }
 
+func (w *WithRefreshDatabase) SetupTest() {
+	w.Contract.reset(func() testing.TB { return w.T() })
+	w.WithRefreshDatabase.SetupTest()
+}
+
 type RefreshDatabaseBeforeEachTest struct {
 	coretesting.RefreshDatabaseBeforeEachTest
+	Contract Contract
 }
 
 func (r *RefreshDatabaseBeforeEachTest) SetupSuite() {
 	r.Config = coretesting.DefaultTestConfig()
-	r.Config.SetupRoutes = router.SetupRouter
+	r.Config.SetupRoutes = setupRoutes(&r.Contract)
+	r.Config.FiberConfig = fiberConfig()
 	r.Config.RefreshDatabase = true
 }
+
+func (r *RefreshDatabaseBeforeEachTest) SetupTest() {
+	r.Contract.reset(func() testing.TB { return r.T() })
+	r.RefreshDatabaseBeforeEachTest.SetupTest()
+}
+
+// setupRoutes registers the application routes behind the contract checks
+func setupRoutes(contract *Contract) func(app *fiber.App) {
+	return func(app *fiber.App) {
+		if err := router.SetupDatabase(); err != nil {
+			panic(err)
+		}
+		app.Use(contract.middleware())
+		router.SetupRouter(app)
+	}
+}
+
+// fiberConfig mirrors the fiber config built in main.go
+func fiberConfig() *fiber.Config {
+	cfg := bootstrap.DefaultConfig().FiberConfig
+	apperror.Configure(cfg)
+	cfg.BodyLimit = uploads.BodyLimit()
+	return cfg
+}